@@ -0,0 +1,106 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+)
+
+const (
+	// ociManifestMediaType is the media type of an OCI image manifest, which also carries artifacts
+	ociManifestMediaType = "application/vnd.oci.image.manifest.v1+json"
+
+	// ociEmptyMediaType is the media type of the empty JSON blob "{}", used in place of
+	// a config for artifacts that have none
+	ociEmptyMediaType = "application/vnd.oci.empty.v1+json"
+)
+
+// ArtifactBlob is a file of an artifact pushed with PushArtifact
+type ArtifactBlob struct {
+	MediaType   string
+	Content     []byte
+	Annotations map[string]string // e.g. "org.opencontainers.image.title" for the file name
+}
+
+// artifactManifest is an OCI image manifest describing an artifact
+type artifactManifest struct {
+	SchemaVersion int          `json:"schemaVersion"`
+	MediaType     string       `json:"mediaType"`
+	ArtifactType  string       `json:"artifactType,omitempty"`
+	Config        Descriptor   `json:"config"`
+	Layers        []Descriptor `json:"layers"`
+	Subject       *Descriptor  `json:"subject,omitempty"`
+}
+
+// PushArtifact publishes an OCI artifact (an SBOM, a signature, a configuration file, ...)
+// like ORAS does: it uploads config and each blob, then pushes an OCI image manifest of
+// the given artifactType referencing them under reference.
+// A nil config is replaced by the OCI empty JSON blob, and configMediaType is ignored.
+// When subject is set, the artifact is linked to that manifest and listed by GetReferrers.
+func (c *BaseClient) PushArtifact(ctx context.Context, repository, reference, artifactType string, config []byte, configMediaType string, blobs []ArtifactBlob, subject *Descriptor) (*ManifestResponse, error) {
+	if artifactType == "" && config == nil {
+		return nil, fmt.Errorf("push artifact failed: artifactType is required without a config")
+	}
+	if config == nil {
+		config, configMediaType = []byte("{}"), ociEmptyMediaType
+	}
+
+	c.logDebug("Pushing artifact",
+		"operation", "PushArtifact",
+		"repository", repository,
+		"reference", reference,
+		"artifact_type", artifactType,
+		"blob_count", len(blobs),
+	)
+
+	configDescriptor, err := c.pushDescriptor(ctx, repository, configMediaType, config, nil)
+	if err != nil {
+		return nil, fmt.Errorf("push artifact config failed: %w", err)
+	}
+
+	layers := make([]Descriptor, 0, max(len(blobs), 1))
+	for _, blob := range blobs {
+		layer, err := c.pushDescriptor(ctx, repository, blob.MediaType, blob.Content, blob.Annotations)
+		if err != nil {
+			return nil, fmt.Errorf("push artifact blob failed: %w", err)
+		}
+		layers = append(layers, layer)
+	}
+	// The OCI image spec requires at least one layer
+	if len(layers) == 0 {
+		layer, err := c.pushDescriptor(ctx, repository, ociEmptyMediaType, []byte("{}"), nil)
+		if err != nil {
+			return nil, fmt.Errorf("push artifact blob failed: %w", err)
+		}
+		layers = append(layers, layer)
+	}
+
+	manifest, err := json.Marshal(artifactManifest{
+		SchemaVersion: 2,
+		MediaType:     ociManifestMediaType,
+		ArtifactType:  artifactType,
+		Config:        configDescriptor,
+		Layers:        layers,
+		Subject:       subject,
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return c.PutManifest(ctx, repository, reference, ociManifestMediaType, manifest)
+}
+
+// pushDescriptor uploads content with PushBlob and returns its descriptor
+func (c *BaseClient) pushDescriptor(ctx context.Context, repository, mediaType string, content []byte, annotations map[string]string) (Descriptor, error) {
+	digest, err := c.PushBlob(ctx, repository, content)
+	if err != nil {
+		return Descriptor{}, err
+	}
+	return Descriptor{
+		MediaType:   mediaType,
+		Digest:      digest,
+		Size:        int64(len(content)),
+		Annotations: annotations,
+	}, nil
+}
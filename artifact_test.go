@@ -0,0 +1,69 @@
+package registryclient
+
+import (
+	"context"
+	"testing"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestPushArtifact(t *testing.T) {
+	registry := newFakeRegistry(t)
+	client := registry.client()
+	imageDigest := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("layer")}, "v1")
+
+	sbom := []byte(`{"spdxVersion":"SPDX-2.3"}`)
+	config := []byte(`{"generator":"syft"}`)
+	subject := &Descriptor{MediaType: ociManifestMediaType, Digest: imageDigest, Size: int64(len(registry.manifests[imageDigest]))}
+
+	pushed, err := client.PushArtifact(context.Background(), "app", "v1-sbom", "application/spdx+json",
+		config, "application/vnd.example.sbom.config.v1+json",
+		[]ArtifactBlob{{MediaType: "application/spdx+json", Content: sbom, Annotations: map[string]string{"org.opencontainers.image.title": "sbom.spdx.json"}}},
+		subject)
+	require.NoError(t, err)
+	assert.Equal(t, ociManifestMediaType, pushed.MediaType)
+
+	// Read the artifact back through the registry
+	fetched, err := client.GetManifest(context.Background(), "app", "v1-sbom")
+	require.NoError(t, err)
+	assert.Equal(t, pushed.Digest, fetched.Digest)
+
+	var manifest artifactManifest
+	require.NoError(t, json.Unmarshal(fetched.RawContent, &manifest))
+	assert.Equal(t, "application/spdx+json", manifest.ArtifactType)
+	assert.Equal(t, Descriptor{MediaType: "application/vnd.example.sbom.config.v1+json", Digest: sha256Digest(config), Size: int64(len(config))}, manifest.Config)
+	require.Len(t, manifest.Layers, 1)
+	assert.Equal(t, "sbom.spdx.json", manifest.Layers[0].Annotations["org.opencontainers.image.title"])
+	assert.Equal(t, subject, manifest.Subject)
+
+	blob, err := client.GetBlob(context.Background(), "app", manifest.Layers[0].Digest)
+	require.NoError(t, err)
+	assert.Equal(t, sbom, blob.Content)
+
+	referrers, err := client.GetReferrers(context.Background(), "app", imageDigest, "")
+	require.NoError(t, err)
+	require.Len(t, referrers, 1)
+	assert.Equal(t, pushed.Digest, referrers[0].Digest)
+	assert.Equal(t, "application/spdx+json", referrers[0].ArtifactType)
+}
+
+func TestPushArtifact_WithoutConfig(t *testing.T) {
+	registry := newFakeRegistry(t)
+	client := registry.client()
+
+	pushed, err := client.PushArtifact(context.Background(), "app", "note", "application/vnd.example.note", nil, "", nil, nil)
+	require.NoError(t, err)
+
+	var manifest artifactManifest
+	require.NoError(t, json.Unmarshal(pushed.RawContent, &manifest))
+	empty := Descriptor{MediaType: ociEmptyMediaType, Digest: sha256Digest([]byte("{}")), Size: 2}
+	assert.Equal(t, empty, manifest.Config)
+	assert.Equal(t, []Descriptor{empty}, manifest.Layers)
+	assert.Nil(t, manifest.Subject)
+	assert.Equal(t, []byte("{}"), registry.blobs[empty.Digest])
+
+	_, err = client.PushArtifact(context.Background(), "app", "note", "", nil, "", nil, nil)
+	require.ErrorContains(t, err, "artifactType is required")
+}
@@ -0,0 +1,110 @@
+package registryclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+)
+
+// BatchError reports the items of a batch operation that failed. The other items succeeded
+// and their results are returned alongside it. errors.Is and errors.As see every item error.
+type BatchError struct {
+	Errors map[string]error // Error of each failed item, keyed by the item (reference or digest)
+}
+
+func (e *BatchError) Error() string {
+	return errors.Join(e.Unwrap()...).Error()
+}
+
+// Unwrap returns the item errors, sorted by item and prefixed with it, so errors.Is and
+// errors.As match any of them
+func (e *BatchError) Unwrap() []error {
+	errs := make([]error, 0, len(e.Errors))
+	for _, item := range slices.Sorted(maps.Keys(e.Errors)) {
+		errs = append(errs, fmt.Errorf("%s: %w", item, e.Errors[item]))
+	}
+	return errs
+}
+
+// GetManifests fetches the manifests of several references concurrently, keyed by reference.
+// A failing reference does not stop the others: the manifests fetched are returned together
+// with a *BatchError listing the failures.
+func (c *BaseClient) GetManifests(ctx context.Context, repository string, references []string) (map[string]*ManifestResponse, error) {
+	return runBatch(ctx, c, "GetManifests", references, func(ctx context.Context, reference string) (*ManifestResponse, error) {
+		return c.GetManifest(ctx, repository, reference)
+	})
+}
+
+// HasBlobs checks the existence of several blobs concurrently, keyed by digest.
+// A failing check does not stop the others: the results obtained are returned together
+// with a *BatchError listing the failures.
+func (c *BaseClient) HasBlobs(ctx context.Context, repository string, digests []string) (map[string]bool, error) {
+	return runBatch(ctx, c, "HasBlobs", digests, func(ctx context.Context, digest string) (bool, error) {
+		return c.HasBlob(ctx, repository, digest)
+	})
+}
+
+// DeleteManifests deletes several manifests by digest concurrently (respecting DisableDelete)
+// and returns the digests deleted, in input order. A failing deletion does not stop the
+// others; the failures are returned as a *BatchError.
+func (c *BaseClient) DeleteManifests(ctx context.Context, repository string, digests []string) ([]string, error) {
+	results, err := runBatch(ctx, c, "DeleteManifests", digests, func(ctx context.Context, digest string) (struct{}, error) {
+		return struct{}{}, c.DeleteManifest(ctx, repository, digest)
+	})
+
+	var deleted []string
+	for _, digest := range digests {
+		if _, ok := results[digest]; ok && !slices.Contains(deleted, digest) {
+			deleted = append(deleted, digest)
+		}
+	}
+	return deleted, err
+}
+
+// runBatch calls fn for every distinct item, at most defaultConcurrency at once, and
+// collects the results of the items that succeeded. Failures do not cancel the other
+// items; they are returned as a *BatchError, or nil when every item succeeded.
+func runBatch[T any](ctx context.Context, c *BaseClient, operation string, items []string, fn func(context.Context, string) (T, error)) (map[string]T, error) {
+	sem := make(chan struct{}, defaultConcurrency)
+	results := make(map[string]T, len(items))
+	failures := map[string]error{}
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+
+	for _, item := range slices.Compact(slices.Sorted(slices.Values(items))) {
+		wg.Go(func() {
+			var result T
+			var err error
+			select {
+			case sem <- struct{}{}:
+				result, err = fn(ctx, item)
+				<-sem
+			case <-ctx.Done():
+				err = ctx.Err()
+			}
+
+			mu.Lock()
+			defer mu.Unlock()
+			if err != nil {
+				failures[item] = err
+				return
+			}
+			results[item] = result
+		})
+	}
+	wg.Wait()
+
+	c.logDebug("Batch completed",
+		"operation", operation,
+		"item_count", len(results)+len(failures),
+		"failed_count", len(failures),
+	)
+
+	if len(failures) > 0 {
+		return results, &BatchError{Errors: failures}
+	}
+	return results, nil
+}
@@ -0,0 +1,94 @@
+package registryclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetManifests(t *testing.T) {
+	registry := newFakeRegistry(t)
+	v1 := registry.addImage(t, ConfigBlob{Created: "2024-01-01T00:00:00Z"}, nil, "v1")
+	v2 := registry.addImage(t, ConfigBlob{Created: "2024-02-01T00:00:00Z"}, nil, "v2")
+
+	manifests, err := registry.client().GetManifests(context.Background(), "app", []string{"v1", "missing", "v2", "v1", "gone"})
+
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Errors, 2)
+	assert.Contains(t, batchErr.Errors, "missing")
+	assert.Contains(t, batchErr.Errors, "gone")
+	assert.Contains(t, err.Error(), "gone: get manifest failed: 404")
+	assert.Less(t, strings.Index(err.Error(), "gone:"), strings.Index(err.Error(), "missing:"), "failures are reported in order")
+
+	require.Len(t, manifests, 2)
+	assert.Equal(t, v1, manifests["v1"].Digest)
+	assert.Equal(t, v2, manifests["v2"].Digest)
+	assert.Equal(t, 1, registry.requestCount(http.MethodGet, "/manifests/v1"), "duplicate references are fetched once")
+}
+
+func TestGetManifests_AllSucceed(t *testing.T) {
+	registry := newFakeRegistry(t)
+	registry.addImage(t, ConfigBlob{}, nil, "v1")
+
+	manifests, err := registry.client().GetManifests(context.Background(), "app", []string{"v1"})
+	require.NoError(t, err)
+	assert.Len(t, manifests, 1)
+}
+
+func TestHasBlobs(t *testing.T) {
+	registry := newFakeRegistry(t)
+	present := registry.addBlob([]byte("present"))
+	missing := sha256Digest([]byte("missing"))
+	broken := sha256Digest([]byte("broken"))
+
+	inner := registry.server.Config.Handler
+	registry.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, broken) {
+			w.WriteHeader(http.StatusTeapot)
+			return
+		}
+		inner.ServeHTTP(w, r)
+	})
+
+	exists, err := registry.client().HasBlobs(context.Background(), "app", []string{present, missing, broken})
+
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Contains(t, batchErr.Errors[broken].Error(), "unexpected status")
+	assert.Equal(t, map[string]bool{present: true, missing: false}, exists)
+}
+
+func TestDeleteManifests(t *testing.T) {
+	registry := newFakeRegistry(t)
+	v1 := registry.addImage(t, ConfigBlob{Created: "2024-01-01T00:00:00Z"}, nil)
+	v2 := registry.addImage(t, ConfigBlob{Created: "2024-02-01T00:00:00Z"}, nil)
+
+	deleted, err := registry.client().DeleteManifests(context.Background(), "app", []string{v2, "latest", v1})
+
+	require.ErrorIs(t, err, ErrTagReference)
+	var batchErr *BatchError
+	require.ErrorAs(t, err, &batchErr)
+	assert.Len(t, batchErr.Errors, 1)
+	assert.Contains(t, batchErr.Errors, "latest")
+
+	assert.Equal(t, []string{v2, v1}, deleted)
+	assert.NotContains(t, registry.manifests, v1)
+	assert.NotContains(t, registry.manifests, v2)
+}
+
+func TestBatch_ContextCanceled(t *testing.T) {
+	registry := newFakeRegistry(t)
+	registry.addImage(t, ConfigBlob{}, nil, "v1")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	manifests, err := registry.client().GetManifests(ctx, "app", []string{"v1", "v2"})
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Empty(t, manifests)
+}
@@ -0,0 +1,559 @@
+package registryclient
+
+import (
+	"archive/tar"
+	"bufio"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"net/http"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"sync"
+
+	"github.com/klauspost/compress/zstd"
+)
+
+// ErrBlobTooLarge is returned when a blob exceeds BaseClient.MaxBlobBytes
+var ErrBlobTooLarge = errors.New("blob too large")
+
+// openBlob issues a GET for a blob and returns the response with its body unread.
+// The caller must close the body.
+func (c *BaseClient) openBlob(ctx context.Context, operation, repository, digest string, acceptHeaders []string) (*http.Response, error) {
+	if err := c.checkRepositoryName(repository); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/v2/%s/blobs/%s", c.registryURL(), repository, digest)
+
+	c.logDebug("Registry request",
+		"operation", operation,
+		"method", http.MethodGet,
+		"repository", repository,
+		"digest", digest,
+		"url", url,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	setAcceptHeaders(req, nil, acceptHeaders)
+
+	resp, err := c.doRetryNotFound(req)
+	if err != nil {
+		return nil, err
+	}
+
+	if resp.StatusCode != http.StatusOK {
+		defer c.closeBody(resp.Body)
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get blob failed: %s - %s", resp.Status, string(body))
+	}
+
+	return resp, nil
+}
+
+// GetBlobStream opens a blob for streaming without buffering it in memory.
+// MaxBlobBytes does not apply. The caller must close the returned reader.
+// Optional acceptHeaders are sent as Accept headers; none are sent by default.
+func (c *BaseClient) GetBlobStream(ctx context.Context, repository, digest string, acceptHeaders ...string) (io.ReadCloser, error) {
+	resp, err := c.openBlob(ctx, "GetBlobStream", repository, digest, acceptHeaders)
+	if err != nil {
+		return nil, err
+	}
+	return resp.Body, nil
+}
+
+// GetVerifiedBlobStream is GetBlobStream verifying the content against digest while it is
+// read: the final Read and Close return an error wrapping ErrDigestMismatch on a mismatch.
+// See DigestVerifyingReader.
+func (c *BaseClient) GetVerifiedBlobStream(ctx context.Context, repository, digest string) (io.ReadCloser, error) {
+	if _, err := newDigestHasher(digest); err != nil {
+		return nil, err
+	}
+
+	body, err := c.GetBlobStream(ctx, repository, digest)
+	if err != nil {
+		return nil, err
+	}
+	return NewDigestVerifyingReader(body, digest)
+}
+
+// ErrUnsupportedCompression is returned by GetLayerUncompressed for media types that are
+// not gzip, zstd or uncompressed tar layers
+var ErrUnsupportedCompression = errors.New("unsupported layer compression")
+
+// GetLayerUncompressed streams a layer blob as its uncompressed tar. mediaType is the layer
+// media type from the manifest: gzip and zstd layers are decompressed while they are read,
+// and uncompressed tar layers are returned as-is. When mediaType is empty the compression
+// is detected from the first bytes.
+// The compressed content is verified against digest (see GetVerifiedBlobStream).
+// The caller must close the returned reader.
+func (c *BaseClient) GetLayerUncompressed(ctx context.Context, repository, digest, mediaType string) (io.ReadCloser, error) {
+	compression, err := layerCompression(mediaType)
+	if err != nil {
+		return nil, err
+	}
+
+	body, err := c.GetVerifiedBlobStream(ctx, repository, digest)
+	if err != nil {
+		return nil, err
+	}
+
+	buffered := bufio.NewReader(body)
+	if compression == "" {
+		compression = sniffCompression(buffered)
+	}
+
+	c.logDebug("Decompressing layer",
+		"operation", "GetLayerUncompressed",
+		"repository", repository,
+		"digest", digest,
+		"media_type", mediaType,
+		"compression", compression,
+	)
+
+	switch compression {
+	case "gzip":
+		gz, err := gzip.NewReader(buffered)
+		if err != nil {
+			_ = body.Close()
+			return nil, fmt.Errorf("decompress layer %s: %w", digest, err)
+		}
+		return &layerReader{Reader: gz, closers: []io.Closer{gz, body}}, nil
+	case "zstd":
+		zr, err := zstd.NewReader(buffered)
+		if err != nil {
+			_ = body.Close()
+			return nil, fmt.Errorf("decompress layer %s: %w", digest, err)
+		}
+		decoder := zr.IOReadCloser()
+		return &layerReader{Reader: decoder, closers: []io.Closer{decoder, body}}, nil
+	default:
+		return &layerReader{Reader: buffered, closers: []io.Closer{body}}, nil
+	}
+}
+
+// layerCompression returns the compression of a layer media type: "gzip", "zstd", "none",
+// or "" when mediaType is empty and the content must be sniffed
+func layerCompression(mediaType string) (string, error) {
+	switch {
+	case mediaType == "":
+		return "", nil
+	case strings.HasSuffix(mediaType, "+gzip"), strings.HasSuffix(mediaType, ".tar.gzip"):
+		return "gzip", nil
+	case strings.HasSuffix(mediaType, "+zstd"), strings.HasSuffix(mediaType, ".tar.zstd"):
+		return "zstd", nil
+	case strings.HasSuffix(mediaType, ".tar"):
+		return "none", nil
+	default:
+		return "", fmt.Errorf("%w: media type %s", ErrUnsupportedCompression, mediaType)
+	}
+}
+
+// sniffCompression detects gzip and zstd streams from their magic numbers
+func sniffCompression(r *bufio.Reader) string {
+	magic, _ := r.Peek(4)
+	switch {
+	case bytes.HasPrefix(magic, []byte{0x1f, 0x8b}):
+		return "gzip"
+	case bytes.HasPrefix(magic, []byte{0x28, 0xb5, 0x2f, 0xfd}):
+		return "zstd"
+	default:
+		return "none"
+	}
+}
+
+// layerReader reads a possibly decompressed layer and closes the decompressor and the body
+type layerReader struct {
+	io.Reader
+	closers []io.Closer
+}
+
+// Close closes every closer and returns the first error
+func (r *layerReader) Close() error {
+	var first error
+	for _, closer := range r.closers {
+		if err := closer.Close(); err != nil && first == nil {
+			first = err
+		}
+	}
+	return first
+}
+
+// Whiteout entry names: ".wh.<name>" deletes a path from lower layers, and an opaque
+// whiteout hides all of its directory's lower contents
+const (
+	whiteoutPrefix = ".wh."
+	opaqueWhiteout = ".wh..wh..opq"
+)
+
+// ListLayerFiles streams a layer (see GetLayerUncompressed) and returns the entries of its
+// tar in archive order, without extracting anything. Whiteout entries are reported with
+// Whiteout set and their Path as stored in the tar.
+func (c *BaseClient) ListLayerFiles(ctx context.Context, repository, digest, mediaType string) ([]LayerFile, error) {
+	layer, err := c.GetLayerUncompressed(ctx, repository, digest, mediaType)
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeBody(layer)
+
+	var files []LayerFile
+	tr := tar.NewReader(layer)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, fmt.Errorf("list layer files failed: %s: %w", digest, err)
+		}
+
+		name := path.Clean(header.Name)
+		files = append(files, LayerFile{
+			Path:     name,
+			Size:     header.Size,
+			Mode:     header.FileInfo().Mode(),
+			Whiteout: strings.HasPrefix(path.Base(name), whiteoutPrefix),
+		})
+	}
+
+	// Drain the padding after the last entry so the digest is verified
+	if _, err := io.Copy(io.Discard, layer); err != nil {
+		return nil, fmt.Errorf("list layer files failed: %s: %w", digest, err)
+	}
+	return files, nil
+}
+
+// readBlobBody reads a blob response body, enforcing MaxBlobBytes
+func (c *BaseClient) readBlobBody(resp *http.Response) ([]byte, error) {
+	if c.MaxBlobBytes <= 0 {
+		return io.ReadAll(resp.Body)
+	}
+	if resp.ContentLength > c.MaxBlobBytes {
+		return nil, fmt.Errorf("%w: %d bytes exceeds limit of %d", ErrBlobTooLarge, resp.ContentLength, c.MaxBlobBytes)
+	}
+
+	content, err := io.ReadAll(io.LimitReader(resp.Body, c.MaxBlobBytes+1))
+	if err != nil {
+		return nil, err
+	}
+	if int64(len(content)) > c.MaxBlobBytes {
+		return nil, fmt.Errorf("%w: exceeds limit of %d bytes", ErrBlobTooLarge, c.MaxBlobBytes)
+	}
+	return content, nil
+}
+
+// GetConfigBlob fetches and parses an image config blob.
+// The OCI and Docker config media types are sent as Accept headers unless acceptHeaders overrides them.
+func (c *BaseClient) GetConfigBlob(ctx context.Context, repository, digest string, acceptHeaders ...string) (*ConfigBlob, error) {
+	if len(acceptHeaders) == 0 {
+		acceptHeaders = defaultConfigMediaTypes
+	}
+
+	blob, err := c.GetBlob(ctx, repository, digest, acceptHeaders...)
+	if err != nil {
+		return nil, err
+	}
+	if c.StrictJSON {
+		if err := checkStrictConfig(blob.Content); err != nil {
+			return nil, err
+		}
+	}
+	return ParseConfigBlob(blob.Content)
+}
+
+// DownloadBlob streams a blob into destPath without buffering it in memory.
+// The content is written to a temporary file in the same directory, verified against
+// digest while streaming, and atomically renamed into place. Returns the bytes written.
+func (c *BaseClient) DownloadBlob(ctx context.Context, repository, digest, destPath string) (int64, error) {
+	hasher, err := newDigestHasher(digest)
+	if err != nil {
+		return 0, err
+	}
+
+	resp, err := c.openBlob(ctx, "DownloadBlob", repository, digest, nil)
+	if err != nil {
+		return 0, err
+	}
+	defer c.closeBody(resp.Body)
+
+	written, err := writeVerifiedFile(resp.Body, destPath, digest, hasher)
+	if err != nil {
+		return 0, err
+	}
+
+	c.logDebug("Registry response",
+		"operation", "DownloadBlob",
+		"repository", repository,
+		"digest", digest,
+		"size_bytes", written,
+		"path", destPath,
+	)
+
+	return written, nil
+}
+
+// writeVerifiedFile copies r into a temporary file next to destPath while hashing it,
+// then renames it to destPath if the hash matches digest. The temporary file is removed on failure.
+func writeVerifiedFile(r io.Reader, destPath, digest string, hasher hash.Hash) (written int64, err error) {
+	tmp, err := os.CreateTemp(filepath.Dir(destPath), "."+filepath.Base(destPath)+".*.tmp")
+	if err != nil {
+		return 0, err
+	}
+	defer func() {
+		if err != nil {
+			_ = os.Remove(tmp.Name())
+		}
+	}()
+
+	written, err = io.Copy(io.MultiWriter(tmp, hasher), r)
+	if closeErr := tmp.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return 0, err
+	}
+
+	if actual := formatDigest(digest, hasher); actual != digest {
+		return 0, fmt.Errorf("%w: expected %s, got %s", ErrDigestMismatch, digest, actual)
+	}
+	if err = os.Rename(tmp.Name(), destPath); err != nil {
+		return 0, err
+	}
+	return written, nil
+}
+
+// ErrRangeNotSatisfiable is returned by GetBlobRange when the registry answers 416: the
+// range lies outside the blob
+var ErrRangeNotSatisfiable = errors.New("range not satisfiable")
+
+// GetBlobRange fetches the byte range [start, end] (inclusive) of a blob.
+// The registry must answer with 206 Partial Content; a 416 fails with ErrRangeNotSatisfiable.
+// 400, 404 and 416 responses are never retried, whatever ShouldRetry decides.
+func (c *BaseClient) GetBlobRange(ctx context.Context, repository, digest string, start, end int64) (*BlobResponse, error) {
+	if err := c.checkRepositoryName(repository); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/v2/%s/blobs/%s", c.registryURL(), repository, digest)
+
+	c.logDebug("Registry request",
+		"operation", "GetBlobRange",
+		"method", http.MethodGet,
+		"repository", repository,
+		"digest", digest,
+		"range_start", start,
+		"range_end", end,
+		"url", url,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Range", fmt.Sprintf("bytes=%d-%d", start, end))
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeBody(resp.Body)
+
+	if resp.StatusCode == http.StatusRequestedRangeNotSatisfiable {
+		return nil, fmt.Errorf("get blob range failed: %s: %w", resp.Status, ErrRangeNotSatisfiable)
+	}
+	if resp.StatusCode != http.StatusPartialContent {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get blob range failed: %s - %s", resp.Status, string(body))
+	}
+
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &BlobResponse{
+		Digest:    resp.Header.Get("Docker-Content-Digest"),
+		Content:   content,
+		Size:      int64(len(content)),
+		MediaType: resp.Header.Get("Content-Type"),
+		Headers:   resp.Header.Clone(),
+		Attempts:  ResponseAttempts(resp),
+	}, nil
+}
+
+// GetBlobParallel downloads a blob as concurrent byte-range segments and reassembles it.
+// It falls back to a single GetBlob when the registry does not advertise
+// "Accept-Ranges: bytes" or the blob size is unknown. The result is verified against digest.
+func (c *BaseClient) GetBlobParallel(ctx context.Context, repository, digest string, segments int) (*BlobResponse, error) {
+	stat, err := c.StatBlob(ctx, repository, digest)
+	if err != nil {
+		return nil, err
+	}
+	size, supportsRanges := stat.Size, stat.SupportsRanges
+
+	if segments <= 1 || !supportsRanges || size <= 0 {
+		c.logDebug("Falling back to single stream blob download",
+			"operation", "GetBlobParallel",
+			"repository", repository,
+			"digest", digest,
+			"supports_ranges", supportsRanges,
+			"size_bytes", size,
+		)
+		return c.getVerifiedBlob(ctx, repository, digest)
+	}
+
+	content, err := c.downloadSegments(ctx, repository, digest, size, segments)
+	if errors.Is(err, ErrRangeNotSatisfiable) {
+		// The size StatBlob reported does not match what the registry serves
+		c.logWarn("Range not satisfiable, falling back to single stream blob download",
+			"operation", "GetBlobParallel",
+			"repository", repository,
+			"digest", digest,
+			"size_bytes", size,
+		)
+		return c.getVerifiedBlob(ctx, repository, digest)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if err := VerifyDigest(content, digest); err != nil {
+		return nil, err
+	}
+
+	return &BlobResponse{
+		Digest:    digest,
+		Content:   content,
+		Size:      int64(len(content)),
+		MediaType: stat.MediaType,
+	}, nil
+}
+
+// getVerifiedBlob fetches a blob in a single request and verifies its digest
+func (c *BaseClient) getVerifiedBlob(ctx context.Context, repository, digest string) (*BlobResponse, error) {
+	blob, err := c.GetBlob(ctx, repository, digest)
+	if err != nil {
+		return nil, err
+	}
+	if err := VerifyDigest(blob.Content, digest); err != nil {
+		return nil, err
+	}
+	blob.Digest = digest
+	return blob, nil
+}
+
+// downloadSegments fetches size bytes as concurrent range requests and joins them in order
+func (c *BaseClient) downloadSegments(ctx context.Context, repository, digest string, size int64, segments int) ([]byte, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	chunkSize := (size + int64(segments) - 1) / int64(segments)
+	parts := make([][]byte, segments)
+	errs := make([]error, segments)
+
+	var wg sync.WaitGroup
+	for i := range segments {
+		start := int64(i) * chunkSize
+		if start >= size {
+			break
+		}
+		end := min(start+chunkSize, size) - 1
+
+		wg.Go(func() {
+			blob, err := c.GetBlobRange(ctx, repository, digest, start, end)
+			if err != nil {
+				errs[i] = err
+				cancel()
+				return
+			}
+			parts[i] = blob.Content
+		})
+	}
+	wg.Wait()
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	content := make([]byte, 0, size)
+	for _, part := range parts {
+		content = append(content, part...)
+	}
+	return content, nil
+}
+
+// StatBlob issues a HEAD for a blob and reports its size, media type and whether the
+// registry serves byte ranges for it, without downloading the content
+func (c *BaseClient) StatBlob(ctx context.Context, repository, digest string) (*BlobStat, error) {
+	if err := c.checkRepositoryName(repository); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/v2/%s/blobs/%s", c.registryURL(), repository, digest)
+
+	c.logDebug("Registry request",
+		"operation", "StatBlob",
+		"method", http.MethodHead,
+		"repository", repository,
+		"digest", digest,
+		"url", url,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("stat blob failed: unexpected status: %s", resp.Status)
+	}
+
+	stat := &BlobStat{
+		Digest:         resp.Header.Get("Docker-Content-Digest"),
+		Size:           resp.ContentLength,
+		MediaType:      resp.Header.Get("Content-Type"),
+		SupportsRanges: acceptsByteRanges(resp.Header),
+	}
+	if stat.Digest == "" {
+		stat.Digest = digest
+	}
+
+	c.logDebug("Registry response",
+		"operation", "StatBlob",
+		"repository", repository,
+		"digest", stat.Digest,
+		"size_bytes", stat.Size,
+		"supports_ranges", stat.SupportsRanges,
+	)
+
+	return stat, nil
+}
+
+// acceptsByteRanges reports whether any Accept-Ranges value lists the "bytes" unit.
+// The header may be repeated or hold a comma-separated list; "none" means no ranges.
+func acceptsByteRanges(header http.Header) bool {
+	for _, value := range header.Values("Accept-Ranges") {
+		for unit := range strings.SplitSeq(value, ",") {
+			if strings.EqualFold(strings.TrimSpace(unit), "bytes") {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,713 @@
+package registryclient
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"context"
+	"errors"
+	"io"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/klauspost/compress/zstd"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newRangeBlobServer serves content with full Range support via http.ServeContent
+func newRangeBlobServer(t *testing.T, content []byte, rangeRequests *atomic.Int32) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Range") != "" {
+			rangeRequests.Add(1)
+		}
+		http.ServeContent(w, r, "blob", time.Time{}, bytes.NewReader(content))
+	}))
+}
+
+func TestGetBlobRange(t *testing.T) {
+	content := []byte("0123456789")
+	var rangeRequests atomic.Int32
+	server := newRangeBlobServer(t, content, &rangeRequests)
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	blob, err := client.GetBlobRange(context.Background(), "repo", sha256Digest(content), 2, 5)
+
+	require.NoError(t, err)
+	assert.Equal(t, []byte("2345"), blob.Content)
+	assert.Equal(t, int64(4), blob.Size)
+	assert.Equal(t, int32(1), rangeRequests.Load())
+}
+
+func TestGetBlobRange_NotPartialContent(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("full body"))
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	blob, err := client.GetBlobRange(context.Background(), "repo", "sha256:abc", 0, 3)
+
+	require.Error(t, err)
+	assert.Nil(t, blob)
+	assert.Contains(t, err.Error(), "get blob range failed")
+}
+
+func TestGetBlobRange_NotRetried(t *testing.T) {
+	tests := []struct {
+		status  int
+		wantErr error
+	}{
+		{status: http.StatusRequestedRangeNotSatisfiable, wantErr: ErrRangeNotSatisfiable},
+		{status: http.StatusBadRequest},
+		{status: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(http.StatusText(tt.status), func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				requests.Add(1)
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client := &BaseClient{
+				HTTPClient:   &http.Client{},
+				BaseURL:      server.URL,
+				MaxAttempts:  3,
+				RetryBackoff: time.Millisecond,
+				ShouldRetry:  func(*http.Response, error, int) bool { return true },
+			}
+			_, err := client.GetBlobRange(context.Background(), "repo", "sha256:abc", 100, 199)
+
+			require.Error(t, err)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+			}
+			assert.Equal(t, int32(1), requests.Load(), "range errors are final")
+		})
+	}
+}
+
+func TestStatBlob(t *testing.T) {
+	content := []byte("0123456789")
+	digest := sha256Digest(content)
+
+	tests := []struct {
+		name         string
+		acceptRanges []string
+		wantRanges   bool
+	}{
+		{name: "bytes", acceptRanges: []string{"bytes"}, wantRanges: true},
+		{name: "no header", wantRanges: false},
+		{name: "none", acceptRanges: []string{"none"}, wantRanges: false},
+		{name: "list", acceptRanges: []string{"none, Bytes"}, wantRanges: true},
+		{name: "repeated header", acceptRanges: []string{"none", "bytes"}, wantRanges: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gets atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method != http.MethodHead {
+					gets.Add(1)
+				}
+				for _, value := range tt.acceptRanges {
+					w.Header().Add("Accept-Ranges", value)
+				}
+				w.Header().Set("Content-Type", "application/octet-stream")
+				w.Header().Set("Content-Length", "10")
+				w.WriteHeader(http.StatusOK)
+			}))
+			defer server.Close()
+
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+			stat, err := client.StatBlob(context.Background(), "repo", digest)
+			require.NoError(t, err)
+
+			assert.Equal(t, &BlobStat{Digest: digest, Size: 10, MediaType: "application/octet-stream", SupportsRanges: tt.wantRanges}, stat)
+			assert.Zero(t, gets.Load(), "stat must only send a HEAD")
+		})
+	}
+}
+
+func TestStatBlob_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	stat, err := client.StatBlob(context.Background(), "repo", "sha256:abc")
+
+	require.Error(t, err)
+	assert.Nil(t, stat)
+	assert.Contains(t, err.Error(), "stat blob failed")
+}
+
+func TestGetBlobParallel(t *testing.T) {
+	content := []byte(strings.Repeat("layer-data-", 100))
+	digest := sha256Digest(content)
+
+	tests := []struct {
+		name      string
+		segments  int
+		wantRange int32
+	}{
+		{name: "four segments", segments: 4, wantRange: 4},
+		{name: "more segments than bytes needed", segments: 7, wantRange: 7},
+		{name: "single segment falls back", segments: 1, wantRange: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var rangeRequests atomic.Int32
+			server := newRangeBlobServer(t, content, &rangeRequests)
+			defer server.Close()
+
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+			blob, err := client.GetBlobParallel(context.Background(), "repo", digest, tt.segments)
+
+			require.NoError(t, err)
+			assert.Equal(t, content, blob.Content)
+			assert.Equal(t, digest, blob.Digest)
+			assert.Equal(t, tt.wantRange, rangeRequests.Load())
+		})
+	}
+}
+
+func TestGetBlobParallel_NoRangeSupport(t *testing.T) {
+	content := []byte("small blob without range support")
+	var gets atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Empty(t, r.Header.Get("Range"), "Range header should not be sent")
+		if r.Method == http.MethodGet {
+			gets.Add(1)
+		}
+		_, _ = w.Write(content)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	blob, err := client.GetBlobParallel(context.Background(), "repo", sha256Digest(content), 4)
+
+	require.NoError(t, err)
+	assert.Equal(t, content, blob.Content)
+	assert.Equal(t, int32(1), gets.Load())
+}
+
+func TestGetBlobParallel_RangeNotSatisfiable(t *testing.T) {
+	content := []byte(strings.Repeat("layer-data-", 10))
+	var rangeRequests, fullRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Accept-Ranges", "bytes")
+		switch {
+		case r.Method == http.MethodHead:
+			// A stale size: the blob is smaller than reported, so most ranges are out of bounds
+			w.Header().Set("Content-Length", "10000")
+		case r.Header.Get("Range") != "":
+			rangeRequests.Add(1)
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+		default:
+			fullRequests.Add(1)
+			_, _ = w.Write(content)
+		}
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, MaxAttempts: 3}
+	blob, err := client.GetBlobParallel(context.Background(), "repo", sha256Digest(content), 4)
+
+	require.NoError(t, err)
+	assert.Equal(t, content, blob.Content)
+	assert.LessOrEqual(t, rangeRequests.Load(), int32(4), "416 is not retried")
+	assert.Equal(t, int32(1), fullRequests.Load())
+}
+
+func TestGetBlobParallel_DigestMismatch(t *testing.T) {
+	content := []byte(strings.Repeat("x", 64))
+	var rangeRequests atomic.Int32
+	server := newRangeBlobServer(t, content, &rangeRequests)
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	blob, err := client.GetBlobParallel(context.Background(), "repo", sha256Digest([]byte("other")), 4)
+
+	require.Error(t, err)
+	assert.Nil(t, blob)
+	assert.True(t, errors.Is(err, ErrDigestMismatch))
+}
+
+func TestGetBlobParallel_NotFound(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	blob, err := client.GetBlobParallel(context.Background(), "repo", "sha256:abc", 4)
+
+	require.Error(t, err)
+	assert.Nil(t, blob)
+}
+
+func TestGetBlob_MaxBlobBytes(t *testing.T) {
+	content := []byte("0123456789")
+	registry := newFakeRegistry(t)
+	digest := registry.addBlob(content)
+
+	tests := []struct {
+		name    string
+		limit   int64
+		wantErr bool
+	}{
+		{name: "no limit", limit: 0},
+		{name: "exact limit", limit: 10},
+		{name: "over limit", limit: 9, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := registry.client()
+			client.MaxBlobBytes = tt.limit
+
+			blob, err := client.GetBlob(context.Background(), "app", digest)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.ErrorIs(t, err, ErrBlobTooLarge)
+				assert.Nil(t, blob)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, content, blob.Content)
+		})
+	}
+}
+
+func TestReadBlobBody_UnknownLength(t *testing.T) {
+	client := &BaseClient{MaxBlobBytes: 4}
+	resp := &http.Response{ContentLength: -1, Body: io.NopCloser(strings.NewReader("too long"))}
+
+	content, err := client.readBlobBody(resp)
+	require.ErrorIs(t, err, ErrBlobTooLarge)
+	assert.Nil(t, content)
+}
+
+func TestGetBlob_AcceptHeaders(t *testing.T) {
+	var accept []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Values("Accept")
+		_, _ = w.Write([]byte(`{"architecture":"amd64","os":"linux"}`))
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+
+	tests := []struct {
+		name       string
+		call       func() error
+		wantAccept []string
+	}{
+		{
+			name: "GetBlob sends none by default",
+			call: func() error {
+				_, err := client.GetBlob(context.Background(), "repo", "sha256:abc")
+				return err
+			},
+		},
+		{
+			name: "GetBlob sends custom headers",
+			call: func() error {
+				_, err := client.GetBlob(context.Background(), "repo", "sha256:abc", "application/custom")
+				return err
+			},
+			wantAccept: []string{"application/custom"},
+		},
+		{
+			name: "GetConfigBlob sends config media types",
+			call: func() error {
+				config, err := client.GetConfigBlob(context.Background(), "repo", "sha256:abc")
+				if err == nil {
+					assert.Equal(t, "amd64", config.Architecture)
+				}
+				return err
+			},
+			wantAccept: []string{"application/vnd.oci.image.config.v1+json", "application/vnd.docker.container.image.v1+json"},
+		},
+		{
+			name: "GetConfigBlob custom headers override defaults",
+			call: func() error {
+				_, err := client.GetConfigBlob(context.Background(), "repo", "sha256:abc", "application/vnd.oci.image.config.v1+json")
+				return err
+			},
+			wantAccept: []string{"application/vnd.oci.image.config.v1+json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accept = nil
+			require.NoError(t, tt.call())
+			assert.Equal(t, tt.wantAccept, accept)
+		})
+	}
+}
+
+func TestBlobResponse_MediaType(t *testing.T) {
+	content := []byte(strings.Repeat("gzip layer ", 20))
+	digest := sha256Digest(content)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.oci.image.layer.v1.tar+gzip")
+		http.ServeContent(w, r, "blob", time.Time{}, bytes.NewReader(content))
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+
+	tests := []struct {
+		name string
+		get  func() (*BlobResponse, error)
+	}{
+		{name: "GetBlob", get: func() (*BlobResponse, error) { return client.GetBlob(context.Background(), "repo", digest) }},
+		{name: "GetBlobRange", get: func() (*BlobResponse, error) { return client.GetBlobRange(context.Background(), "repo", digest, 0, 9) }},
+		{name: "GetBlobParallel", get: func() (*BlobResponse, error) { return client.GetBlobParallel(context.Background(), "repo", digest, 3) }},
+		{name: "GetBlobParallel fallback", get: func() (*BlobResponse, error) { return client.GetBlobParallel(context.Background(), "repo", digest, 1) }},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			blob, err := tt.get()
+			require.NoError(t, err)
+			assert.Equal(t, "application/vnd.oci.image.layer.v1.tar+gzip", blob.MediaType)
+		})
+	}
+}
+
+func TestDownloadBlob(t *testing.T) {
+	content := []byte(strings.Repeat("streamed layer ", 1000))
+	registry := newFakeRegistry(t)
+	digest := registry.addBlob(content)
+	dest := filepath.Join(t.TempDir(), "layer.tar.gz")
+
+	written, err := registry.client().DownloadBlob(context.Background(), "app", digest, dest)
+
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(content)), written)
+	got, err := os.ReadFile(dest)
+	require.NoError(t, err)
+	assert.Equal(t, content, got)
+	assertOnlyFile(t, filepath.Dir(dest), "layer.tar.gz")
+}
+
+func TestDownloadBlob_DigestMismatch(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("corrupted content"))
+	}))
+	defer server.Close()
+
+	dest := filepath.Join(t.TempDir(), "layer.tar.gz")
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+
+	written, err := client.DownloadBlob(context.Background(), "app", sha256Digest([]byte("original content")), dest)
+
+	require.ErrorIs(t, err, ErrDigestMismatch)
+	assert.Zero(t, written)
+	assertOnlyFile(t, filepath.Dir(dest))
+}
+
+func TestDownloadBlob_Errors(t *testing.T) {
+	registry := newFakeRegistry(t)
+	digest := registry.addBlob([]byte("content"))
+	client := registry.client()
+
+	t.Run("invalid digest", func(t *testing.T) {
+		_, err := client.DownloadBlob(context.Background(), "app", "invalid", filepath.Join(t.TempDir(), "blob"))
+		require.Error(t, err)
+		assert.Zero(t, registry.requestCount(http.MethodGet, "/invalid"))
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		_, err := client.DownloadBlob(context.Background(), "app", sha256Digest([]byte("missing")), filepath.Join(t.TempDir(), "blob"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "get blob failed")
+	})
+
+	t.Run("missing directory", func(t *testing.T) {
+		_, err := client.DownloadBlob(context.Background(), "app", digest, filepath.Join(t.TempDir(), "missing", "blob"))
+		require.Error(t, err)
+	})
+}
+
+// assertOnlyFile asserts dir contains exactly the named files
+func assertOnlyFile(t *testing.T, dir string, names ...string) {
+	t.Helper()
+	entries, err := os.ReadDir(dir)
+	require.NoError(t, err)
+
+	got := make([]string, 0, len(entries))
+	for _, entry := range entries {
+		got = append(got, entry.Name())
+	}
+	assert.ElementsMatch(t, names, got)
+}
+
+func TestGetBlobStream(t *testing.T) {
+	content := []byte(strings.Repeat("streamed layer ", 1000))
+	registry := newFakeRegistry(t)
+	digest := registry.addBlob(content)
+
+	stream, err := registry.client().GetBlobStream(context.Background(), "app", digest)
+	require.NoError(t, err)
+	got, err := io.ReadAll(stream)
+	require.NoError(t, err)
+	require.NoError(t, stream.Close())
+	assert.Equal(t, content, got)
+
+	_, err = registry.client().GetBlobStream(context.Background(), "app", sha256Digest([]byte("missing")))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "get blob failed")
+}
+
+func TestGetVerifiedBlobStream(t *testing.T) {
+	content := []byte(strings.Repeat("streamed layer ", 1000))
+	registry := newFakeRegistry(t)
+	digest := registry.addBlob(content)
+	client := registry.client()
+
+	t.Run("valid", func(t *testing.T) {
+		stream, err := client.GetVerifiedBlobStream(context.Background(), "app", digest)
+		require.NoError(t, err)
+		got, err := io.ReadAll(stream)
+		require.NoError(t, err)
+		require.NoError(t, stream.Close())
+		assert.Equal(t, content, got)
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		corrupt := sha256Digest([]byte("original content"))
+		registry.blobs[corrupt] = []byte("corrupted content")
+
+		stream, err := client.GetVerifiedBlobStream(context.Background(), "app", corrupt)
+		require.NoError(t, err)
+		_, err = io.ReadAll(stream)
+		require.ErrorIs(t, err, ErrDigestMismatch)
+		require.ErrorIs(t, stream.Close(), ErrDigestMismatch)
+	})
+
+	t.Run("invalid digest", func(t *testing.T) {
+		_, err := client.GetVerifiedBlobStream(context.Background(), "app", "invalid")
+		require.Error(t, err)
+		assert.Zero(t, registry.requestCount(http.MethodGet, "/invalid"))
+	})
+}
+
+// tarLayer returns a tar archive holding files given as name, content pairs.
+// Names ending in "/" are stored as directories.
+func tarLayer(t *testing.T, files ...string) []byte {
+	t.Helper()
+	require.Zero(t, len(files)%2, "files must be name, content pairs")
+	var buf bytes.Buffer
+	tw := tar.NewWriter(&buf)
+	for i := 0; i < len(files); i += 2 {
+		header := &tar.Header{Name: files[i], Mode: 0o644, Size: int64(len(files[i+1]))}
+		if strings.HasSuffix(files[i], "/") {
+			header = &tar.Header{Name: files[i], Mode: 0o755, Typeflag: tar.TypeDir}
+		}
+		require.NoError(t, tw.WriteHeader(header))
+		_, err := tw.Write([]byte(files[i+1]))
+		require.NoError(t, err)
+	}
+	require.NoError(t, tw.Close())
+	return buf.Bytes()
+}
+
+func gzipBytes(t *testing.T, content []byte) []byte {
+	t.Helper()
+	var buf bytes.Buffer
+	gz := gzip.NewWriter(&buf)
+	_, err := gz.Write(content)
+	require.NoError(t, err)
+	require.NoError(t, gz.Close())
+	return buf.Bytes()
+}
+
+func zstdBytes(t *testing.T, content []byte) []byte {
+	t.Helper()
+	encoder, err := zstd.NewWriter(nil)
+	require.NoError(t, err)
+	defer func() { _ = encoder.Close() }()
+	return encoder.EncodeAll(content, nil)
+}
+
+func TestGetLayerUncompressed(t *testing.T) {
+	layerTar := tarLayer(t, "etc/os-release", "ID=test\n")
+	registry := newFakeRegistry(t)
+	gzipped := registry.addBlob(gzipBytes(t, layerTar))
+	zstded := registry.addBlob(zstdBytes(t, layerTar))
+	plain := registry.addBlob(layerTar)
+
+	tests := []struct {
+		name      string
+		digest    string
+		mediaType string
+	}{
+		{name: "oci gzip", digest: gzipped, mediaType: "application/vnd.oci.image.layer.v1.tar+gzip"},
+		{name: "docker gzip", digest: gzipped, mediaType: "application/vnd.docker.image.rootfs.diff.tar.gzip"},
+		{name: "gzip detected", digest: gzipped, mediaType: ""},
+		{name: "oci zstd", digest: zstded, mediaType: "application/vnd.oci.image.layer.v1.tar+zstd"},
+		{name: "docker zstd", digest: zstded, mediaType: "application/vnd.docker.image.rootfs.diff.tar.zstd"},
+		{name: "zstd detected", digest: zstded, mediaType: ""},
+		{name: "uncompressed", digest: plain, mediaType: "application/vnd.oci.image.layer.v1.tar"},
+		{name: "uncompressed detected", digest: plain, mediaType: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer, err := registry.client().GetLayerUncompressed(context.Background(), "app", tt.digest, tt.mediaType)
+			require.NoError(t, err)
+			defer func() { require.NoError(t, layer.Close()) }()
+
+			tr := tar.NewReader(layer)
+			header, err := tr.Next()
+			require.NoError(t, err)
+			assert.Equal(t, "etc/os-release", header.Name)
+			content, err := io.ReadAll(tr)
+			require.NoError(t, err)
+			assert.Equal(t, "ID=test\n", string(content))
+		})
+	}
+}
+
+func TestGetLayerUncompressed_Errors(t *testing.T) {
+	registry := newFakeRegistry(t)
+	gzipped := registry.addBlob(gzipBytes(t, []byte("layer")))
+	notGzip := registry.addBlob([]byte("not gzip"))
+
+	tests := []struct {
+		name      string
+		digest    string
+		mediaType string
+		wantIs    error
+		wantErr   string
+	}{
+		{name: "not a layer", digest: gzipped, mediaType: "application/vnd.oci.image.config.v1+json", wantIs: ErrUnsupportedCompression},
+		{name: "corrupt gzip", digest: notGzip, mediaType: "application/vnd.oci.image.layer.v1.tar+gzip", wantErr: "decompress layer"},
+		{name: "missing blob", digest: sha256Digest([]byte("missing")), mediaType: "application/vnd.oci.image.layer.v1.tar+gzip", wantErr: "404"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			layer, err := registry.client().GetLayerUncompressed(context.Background(), "app", tt.digest, tt.mediaType)
+			require.Error(t, err)
+			assert.Nil(t, layer)
+			if tt.wantIs != nil {
+				assert.ErrorIs(t, err, tt.wantIs)
+			}
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestGetLayerUncompressed_CorruptZstd(t *testing.T) {
+	registry := newFakeRegistry(t)
+	corrupt := registry.addBlob([]byte{0x28, 0xb5, 0x2f, 0xfd, 0x00})
+
+	layer, err := registry.client().GetLayerUncompressed(context.Background(), "app", corrupt, "")
+	require.NoError(t, err, "zstd frames are decoded as they are read")
+	defer func() { _ = layer.Close() }()
+
+	_, err = io.ReadAll(layer)
+	require.Error(t, err)
+}
+
+func TestGetLayerUncompressed_DigestMismatch(t *testing.T) {
+	registry := newFakeRegistry(t)
+	content := gzipBytes(t, []byte("layer"))
+	wrong := sha256Digest([]byte("other"))
+	registry.blobs[wrong] = content
+
+	layer, err := registry.client().GetLayerUncompressed(context.Background(), "app", wrong, "application/vnd.oci.image.layer.v1.tar+gzip")
+	require.NoError(t, err)
+	defer func() { _ = layer.Close() }()
+
+	_, err = io.ReadAll(layer)
+	require.ErrorIs(t, err, ErrDigestMismatch)
+}
+
+func TestListLayerFiles(t *testing.T) {
+	layerTar, err := os.ReadFile("testdata/layers/small.tar")
+	require.NoError(t, err)
+
+	registry := newFakeRegistry(t)
+	gzipped := registry.addBlob(gzipBytes(t, layerTar))
+	plain := registry.addBlob(layerTar)
+
+	want := []LayerFile{
+		{Path: "etc", Mode: fs.ModeDir | 0o755},
+		{Path: "etc/os-release", Size: 8, Mode: 0o644},
+		{Path: "usr/bin/app", Size: 18, Mode: 0o755},
+		{Path: "usr/bin/sh", Mode: fs.ModeSymlink | 0o777},
+		{Path: "var/cache/.wh.apk", Mode: 0o644, Whiteout: true},
+		{Path: "tmp/.wh..wh..opq", Mode: 0o644, Whiteout: true},
+	}
+
+	tests := []struct {
+		name      string
+		digest    string
+		mediaType string
+	}{
+		{name: "gzip layer", digest: gzipped, mediaType: "application/vnd.oci.image.layer.v1.tar+gzip"},
+		{name: "uncompressed layer", digest: plain, mediaType: "application/vnd.oci.image.layer.v1.tar"},
+		{name: "detected compression", digest: gzipped},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := registry.client().ListLayerFiles(context.Background(), "app", tt.digest, tt.mediaType)
+			require.NoError(t, err)
+			assert.Equal(t, want, files)
+		})
+	}
+}
+
+func TestListLayerFiles_Errors(t *testing.T) {
+	layerTar, err := os.ReadFile("testdata/layers/small.tar")
+	require.NoError(t, err)
+
+	registry := newFakeRegistry(t)
+	truncated := registry.addBlob(layerTar[:700])
+	wrong := sha256Digest([]byte("other"))
+	registry.blobs[wrong] = layerTar
+
+	tests := []struct {
+		name    string
+		digest  string
+		wantIs  error
+		wantErr string
+	}{
+		{name: "truncated tar", digest: truncated, wantErr: "list layer files failed"},
+		{name: "digest mismatch", digest: wrong, wantIs: ErrDigestMismatch},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			files, err := registry.client().ListLayerFiles(context.Background(), "app", tt.digest, "application/vnd.oci.image.layer.v1.tar")
+			require.Error(t, err)
+			assert.Nil(t, files)
+			if tt.wantIs != nil {
+				assert.ErrorIs(t, err, tt.wantIs)
+			}
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
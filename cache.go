@@ -0,0 +1,79 @@
+package registryclient
+
+import (
+	"container/list"
+	"sync"
+)
+
+// ManifestCache stores manifests by repository and digest. Content addressed by a digest
+// never changes, so GetManifest can answer digest references from the cache instead of the
+// registry. Keys have the form repository@digest: a manifest fetched from one repository is
+// never served for another, where it may not exist or the caller may not be authorized.
+// Implementations must be safe for concurrent use.
+type ManifestCache interface {
+	// Get returns the content and media type stored for key (repository@digest)
+	Get(key string) (content []byte, mediaType string, ok bool)
+	// Add stores the content and media type of the manifest with the given key (repository@digest)
+	Add(key string, content []byte, mediaType string)
+}
+
+// LRUManifestCache is a ManifestCache holding up to a fixed number of manifests, evicting
+// the least recently used one when full
+type LRUManifestCache struct {
+	mu         sync.Mutex
+	maxEntries int
+	entries    map[string]*list.Element
+	order      *list.List // Most recently used first
+}
+
+type manifestCacheEntry struct {
+	key       string
+	content   []byte
+	mediaType string
+}
+
+// NewLRUManifestCache returns an LRUManifestCache holding up to maxEntries manifests
+// (values below 1 hold one)
+func NewLRUManifestCache(maxEntries int) *LRUManifestCache {
+	return &LRUManifestCache{
+		maxEntries: max(maxEntries, 1),
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+}
+
+// Get returns the manifest stored for key and marks it as recently used
+func (c *LRUManifestCache) Get(key string) ([]byte, string, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	element, ok := c.entries[key]
+	if !ok {
+		return nil, "", false
+	}
+	c.order.MoveToFront(element)
+	entry := element.Value.(*manifestCacheEntry)
+	return entry.content, entry.mediaType, true
+}
+
+// Add stores a manifest, evicting the least recently used one when the cache is full
+func (c *LRUManifestCache) Add(key string, content []byte, mediaType string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if element, ok := c.entries[key]; ok {
+		c.order.MoveToFront(element)
+		return
+	}
+	c.entries[key] = c.order.PushFront(&manifestCacheEntry{key: key, content: content, mediaType: mediaType})
+	if c.order.Len() > c.maxEntries {
+		oldest := c.order.Back()
+		c.order.Remove(oldest)
+		delete(c.entries, oldest.Value.(*manifestCacheEntry).key)
+	}
+}
+
+// Len returns the number of manifests in the cache
+func (c *LRUManifestCache) Len() int {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.order.Len()
+}
@@ -0,0 +1,107 @@
+package registryclient
+
+import (
+	"context"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestLRUManifestCache(t *testing.T) {
+	cache := NewLRUManifestCache(2)
+	cache.Add("sha256:a", []byte("a"), "type/a")
+	cache.Add("sha256:b", []byte("b"), "type/b")
+
+	// Reading a makes b the least recently used
+	content, mediaType, ok := cache.Get("sha256:a")
+	require.True(t, ok)
+	assert.Equal(t, "a", string(content))
+	assert.Equal(t, "type/a", mediaType)
+
+	cache.Add("sha256:c", []byte("c"), "type/c")
+	assert.Equal(t, 2, cache.Len())
+
+	_, _, ok = cache.Get("sha256:b")
+	assert.False(t, ok, "least recently used entry is evicted")
+	_, _, ok = cache.Get("sha256:a")
+	assert.True(t, ok)
+	_, _, ok = cache.Get("sha256:c")
+	assert.True(t, ok)
+
+	cache.Add("sha256:c", []byte("c"), "type/c")
+	assert.Equal(t, 2, cache.Len(), "adding a cached digest again does not duplicate it")
+
+	assert.Equal(t, 1, NewLRUManifestCache(0).maxEntries)
+}
+
+func TestGetManifest_ManifestCache(t *testing.T) {
+	registry := newFakeRegistry(t)
+	digest := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("layer")}, "latest")
+
+	client := registry.client()
+	client.ManifestCache = NewLRUManifestCache(10)
+
+	first, err := client.GetManifest(context.Background(), "app", digest)
+	require.NoError(t, err)
+	second, err := client.GetManifest(context.Background(), "app", digest)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, registry.requestCount(http.MethodGet, "/manifests/"+digest), "second fetch is served from the cache")
+	assert.Equal(t, first.RawContent, second.RawContent)
+	assert.Equal(t, first.MediaType, second.MediaType)
+	assert.Equal(t, first.ManifestData, second.ManifestData)
+	assert.Equal(t, digest, second.Digest)
+	assert.Nil(t, second.Headers, "cached manifests were not fetched from the registry")
+
+	second.RawContent[0] = 'x'
+	third, err := client.GetManifest(context.Background(), "app", digest)
+	require.NoError(t, err)
+	assert.Equal(t, first.RawContent, third.RawContent, "callers cannot modify the cached content")
+}
+
+func TestGetManifest_ManifestCachePerRepository(t *testing.T) {
+	registry := newFakeRegistry(t)
+	digest := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("layer")}, "latest")
+
+	client := registry.client()
+	client.ManifestCache = NewLRUManifestCache(10)
+
+	_, err := client.GetManifest(context.Background(), "app", digest)
+	require.NoError(t, err)
+	_, err = client.GetManifest(context.Background(), "other", digest)
+	require.NoError(t, err)
+
+	assert.Equal(t, 1, registry.requestCount(http.MethodGet, "/v2/app/manifests/"+digest))
+	assert.Equal(t, 1, registry.requestCount(http.MethodGet, "/v2/other/manifests/"+digest), "a digest cached from app is not served for other")
+}
+
+func TestGetManifest_ManifestCacheSkipped(t *testing.T) {
+	registry := newFakeRegistry(t)
+	digest := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("layer")}, "latest")
+	wrong := sha256Digest([]byte("other"))
+	registry.manifests[wrong] = registry.manifests[digest]
+
+	cache := NewLRUManifestCache(10)
+	client := registry.client()
+	client.ManifestCache = cache
+
+	t.Run("tags are not cached", func(t *testing.T) {
+		for range 2 {
+			_, err := client.GetManifest(context.Background(), "app", "latest")
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, registry.requestCount(http.MethodGet, "/manifests/latest"))
+		assert.Zero(t, cache.Len())
+	})
+
+	t.Run("content not matching the digest is not cached", func(t *testing.T) {
+		for range 2 {
+			_, err := client.GetManifest(context.Background(), "app", wrong)
+			require.NoError(t, err)
+		}
+		assert.Equal(t, 2, registry.requestCount(http.MethodGet, "/manifests/"+wrong))
+		assert.Zero(t, cache.Len())
+	})
+}
@@ -0,0 +1,175 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"strings"
+)
+
+// probeDigest is a manifest digest that cannot exist, used to probe endpoints without
+// touching real content
+const probeDigest = "sha256:0000000000000000000000000000000000000000000000000000000000000000"
+
+// Capabilities probes what the registry supports, for tooling that adapts to registry
+// differences. /v2/ reports the API version and whether the credentials are accepted;
+// the catalog is then listed with a one-item page. When it names a repository, that
+// repository is used to probe the Referrers API, manifest deletion and range requests
+// (a HEAD on a blob of its first tag). Deletion is probed by sending a real DELETE of a
+// digest that cannot exist (405 means deletion is disabled); with DisableDelete set no
+// DELETE is sent and Delete stays false. Probes that fail leave their capability false; only an unreachable
+// registry or an unexpected /v2/ status is returned as an error.
+func (c *BaseClient) Capabilities(ctx context.Context) (*RegistryCapabilities, error) {
+	url := fmt.Sprintf("%s/v2/", c.registryURL())
+
+	c.logDebug("Registry request",
+		"operation", "Capabilities",
+		"method", http.MethodGet,
+		"url", url,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeBody(resp.Body)
+
+	caps := &RegistryCapabilities{
+		APIVersion: resp.Header.Get("Docker-Distribution-API-Version"),
+	}
+	switch resp.StatusCode {
+	case http.StatusOK:
+		caps.Authenticated = true
+	case http.StatusUnauthorized:
+		// Nothing else can be probed without valid credentials
+		caps.AuthScheme, _, _ = strings.Cut(resp.Header.Get("WWW-Authenticate"), " ")
+		return caps, nil
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("capabilities failed: %s - %s", resp.Status, string(body))
+	}
+
+	catalog, err := c.GetCatalog(ctx, &PaginationParams{N: 1})
+	if err != nil {
+		if ctx.Err() != nil {
+			return nil, ctx.Err()
+		}
+		c.logDebug("Catalog not available", "operation", "Capabilities", "error", err.Error())
+		return caps, nil
+	}
+	caps.Catalog = true
+	caps.Pagination = catalog.HasMore
+	if len(catalog.Repositories) == 0 {
+		return caps, nil
+	}
+
+	caps.Repository = catalog.Repositories[0]
+	if err := c.probeRepository(ctx, caps); err != nil {
+		return nil, err
+	}
+
+	c.logDebug("Registry response",
+		"operation", "Capabilities",
+		"repository", caps.Repository,
+		"referrers", caps.Referrers,
+		"delete", caps.Delete,
+		"range_requests", caps.RangeRequests,
+	)
+
+	return caps, nil
+}
+
+// probeRepository fills the capabilities that need a repository. Only a cancelled
+// context is returned as an error.
+func (c *BaseClient) probeRepository(ctx context.Context, caps *RegistryCapabilities) error {
+	repository := caps.Repository
+
+	referrers, err := c.probeStatus(ctx, http.MethodGet, fmt.Sprintf("%s/v2/%s/referrers/%s", c.registryURL(), repository, probeDigest))
+	if err != nil {
+		return err
+	}
+	caps.Referrers = referrers == http.StatusOK
+
+	// Deleting an unknown manifest answers 404 (or 202) when deletion is enabled
+	if c.DisableDelete {
+		c.logInfo("DELETE DISABLED (dry-run mode): deletion not probed",
+			"operation", "Capabilities",
+			"repository", repository,
+		)
+	} else {
+		deletion, err := c.probeStatus(ctx, http.MethodDelete, fmt.Sprintf("%s/v2/%s/manifests/%s", c.registryURL(), repository, probeDigest))
+		if err != nil {
+			return err
+		}
+		caps.Delete = deletion == http.StatusNotFound || deletion == http.StatusAccepted
+	}
+
+	blob, err := c.probeBlob(ctx, repository)
+	if err != nil || blob == "" {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return nil
+	}
+	stat, err := c.StatBlob(ctx, repository, blob)
+	if err != nil {
+		if ctx.Err() != nil {
+			return ctx.Err()
+		}
+		return nil
+	}
+	caps.RangeRequests = stat.SupportsRanges
+	return nil
+}
+
+// probeStatus sends a request without a body and returns its status code, or 0 when the
+// request failed for another reason than a cancelled context
+func (c *BaseClient) probeStatus(ctx context.Context, method, url string) (int, error) {
+	req, err := http.NewRequestWithContext(ctx, method, url, nil)
+	if err != nil {
+		return 0, err
+	}
+	resp, err := c.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			return 0, ctx.Err()
+		}
+		c.logDebug("Probe failed", "operation", "Capabilities", "method", method, "url", url, "error", err.Error())
+		return 0, nil
+	}
+	defer c.closeBody(resp.Body)
+	return resp.StatusCode, nil
+}
+
+// probeBlob returns the digest of a blob of the repository, the config of its first tag
+// (of the first child for an index), or "" when the repository has no tags
+func (c *BaseClient) probeBlob(ctx context.Context, repository string) (string, error) {
+	tags, err := c.ListTags(ctx, repository, &PaginationParams{N: 1})
+	if err != nil || len(tags.Tags) == 0 {
+		return "", err
+	}
+
+	manifest, err := c.GetManifest(ctx, repository, tags.Tags[0])
+	if err != nil {
+		return "", err
+	}
+	if index, ok := manifest.ManifestData.(ManifestList); ok {
+		if len(index.Manifests) == 0 {
+			return "", nil
+		}
+		if manifest, err = c.getChildManifest(ctx, repository, index.Manifests[0]); err != nil {
+			return "", err
+		}
+	}
+
+	image, ok := manifest.ManifestData.(ImageManifest)
+	if !ok {
+		return "", nil
+	}
+	return image.Config.Digest, nil
+}
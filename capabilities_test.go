@@ -0,0 +1,172 @@
+package registryclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync/atomic"
+	"testing"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// capabilityRegistry serves /v2/ and the catalog around a fakeRegistry, with each
+// capability switchable
+type capabilityRegistry struct {
+	repositories  []string
+	noCatalog     bool
+	noReferrers   bool
+	noDelete      bool
+	rangeRequests bool
+}
+
+func (c capabilityRegistry) wrap(inner http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/":
+			w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+			w.WriteHeader(http.StatusOK)
+		case r.URL.Path == "/v2/_catalog":
+			if c.noCatalog {
+				w.WriteHeader(http.StatusNotFound)
+				return
+			}
+			repositories := c.repositories
+			if len(repositories) > 1 {
+				repositories = repositories[:1]
+				w.Header().Set("Link", `</v2/_catalog?last=`+repositories[0]+`&n=1>; rel="next"`)
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"repositories": repositories})
+		case c.noReferrers && strings.Contains(r.URL.Path, "/referrers/"):
+			w.WriteHeader(http.StatusNotFound)
+		case c.noDelete && r.Method == http.MethodDelete:
+			w.WriteHeader(http.StatusMethodNotAllowed)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"UNSUPPORTED"}]}`))
+		default:
+			if c.rangeRequests && strings.Contains(r.URL.Path, "/blobs/") {
+				w.Header().Set("Accept-Ranges", "bytes")
+			}
+			inner.ServeHTTP(w, r)
+		}
+	})
+}
+
+func TestCapabilities(t *testing.T) {
+	tests := []struct {
+		name     string
+		registry capabilityRegistry
+		want     RegistryCapabilities
+	}{
+		{
+			name:     "full featured",
+			registry: capabilityRegistry{repositories: []string{"app", "other"}, rangeRequests: true},
+			want: RegistryCapabilities{
+				APIVersion: "registry/2.0", Authenticated: true, Catalog: true, Pagination: true,
+				Repository: "app", Referrers: true, Delete: true, RangeRequests: true,
+			},
+		},
+		{
+			name:     "minimal",
+			registry: capabilityRegistry{repositories: []string{"app"}, noReferrers: true, noDelete: true},
+			want: RegistryCapabilities{
+				APIVersion: "registry/2.0", Authenticated: true, Catalog: true, Repository: "app",
+			},
+		},
+		{
+			name:     "empty catalog",
+			registry: capabilityRegistry{rangeRequests: true},
+			want:     RegistryCapabilities{APIVersion: "registry/2.0", Authenticated: true, Catalog: true},
+		},
+		{
+			name:     "no catalog",
+			registry: capabilityRegistry{noCatalog: true},
+			want:     RegistryCapabilities{APIVersion: "registry/2.0", Authenticated: true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry := newFakeRegistry(t)
+			registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("layer")}, "latest")
+			registry.server.Config.Handler = tt.registry.wrap(registry.server.Config.Handler)
+
+			caps, err := registry.client().Capabilities(context.Background())
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, *caps)
+		})
+	}
+}
+
+func TestCapabilities_IndexRangeProbe(t *testing.T) {
+	registry := newFakeRegistry(t)
+	amd64 := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("layer")})
+	registry.addIndex(t, map[string]Platform{amd64: {OS: "linux", Architecture: "amd64"}}, "latest")
+	registry.server.Config.Handler = capabilityRegistry{repositories: []string{"app"}, rangeRequests: true}.wrap(registry.server.Config.Handler)
+
+	caps, err := registry.client().Capabilities(context.Background())
+	require.NoError(t, err)
+	assert.True(t, caps.RangeRequests)
+}
+
+func TestCapabilities_DisableDelete(t *testing.T) {
+	registry := newFakeRegistry(t)
+	registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("layer")}, "latest")
+	var deletes atomic.Int32
+	inner := capabilityRegistry{repositories: []string{"app"}}.wrap(registry.server.Config.Handler)
+	registry.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deletes.Add(1)
+		}
+		inner.ServeHTTP(w, r)
+	})
+
+	client := registry.client()
+	client.DisableDelete = true
+	caps, err := client.Capabilities(context.Background())
+	require.NoError(t, err)
+	assert.False(t, caps.Delete)
+	assert.True(t, caps.Referrers, "the other probes still run")
+	assert.Zero(t, deletes.Load(), "no DELETE is sent in dry-run mode")
+}
+
+func TestCapabilities_Unauthenticated(t *testing.T) {
+	var paths []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		paths = append(paths, r.URL.Path)
+		w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: server.Client(), BaseURL: server.URL}
+	caps, err := client.Capabilities(context.Background())
+	require.NoError(t, err)
+	assert.Equal(t, RegistryCapabilities{AuthScheme: "Basic"}, *caps)
+	assert.Equal(t, []string{"/v2/"}, paths, "nothing else is probed without credentials")
+}
+
+func TestCapabilities_Errors(t *testing.T) {
+	t.Run("server error", func(t *testing.T) {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusInternalServerError)
+		}))
+		defer server.Close()
+
+		client := &BaseClient{HTTPClient: server.Client(), BaseURL: server.URL}
+		_, err := client.Capabilities(context.Background())
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "capabilities failed: 500")
+	})
+
+	t.Run("unreachable", func(t *testing.T) {
+		server := httptest.NewServer(http.NotFoundHandler())
+		server.Close()
+
+		client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+		_, err := client.Capabilities(context.Background())
+		require.Error(t, err)
+	})
+}
@@ -0,0 +1,159 @@
+package registryclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"iter"
+	"net/http"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+)
+
+// CatalogStream lists every repository of the registry, following the Link header from
+// page to page like ListAllTags. Each page is decoded one repository at a time instead
+// of being buffered, so memory stays flat on registries with huge catalogs.
+// pagination sets the first page (nil starts at the beginning with DefaultPageSize).
+// Iteration stops after the first error, which is yielded with an empty name.
+func (c *BaseClient) CatalogStream(ctx context.Context, pagination *PaginationParams) iter.Seq2[string, error] {
+	return func(yield func(string, error) bool) {
+		params := PaginationParams{N: c.pageSize(0, 0)}
+		if pagination != nil {
+			params = *pagination
+		}
+
+		for page := 1; ; page++ {
+			if err := c.checkPageLimit("CatalogStream", page); err != nil {
+				yield("", err)
+				return
+			}
+
+			next, count, err := c.streamCatalogPage(ctx, &params, yield)
+			if err != nil {
+				if !errors.Is(err, errStopIteration) {
+					yield("", err)
+				}
+				return
+			}
+			if !next.HasMore || next.Cursor == "" || count == 0 {
+				return
+			}
+			params.Cursor = next.Cursor
+			if next.N > 0 {
+				params.N = next.N
+			}
+		}
+	}
+}
+
+// errStopIteration reports that the consumer of an iterator stopped early
+var errStopIteration = errors.New("iteration stopped")
+
+// streamCatalogPage requests one catalog page and yields its repositories as they are
+// decoded. It returns the pagination of the next page and the number of repositories read.
+func (c *BaseClient) streamCatalogPage(ctx context.Context, pagination *PaginationParams, yield func(string, error) bool) (PaginatedResponse, int, error) {
+	url := fmt.Sprintf("%s/v2/_catalog", c.registryURL())
+
+	c.logDebug("Registry request",
+		"operation", "CatalogStream",
+		"method", http.MethodGet,
+		"url", url,
+		"page_size", pagination.N,
+		"last", pagination.position(),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return PaginatedResponse{}, 0, err
+	}
+	sent := c.pagination(&c.catalogPageSize, pagination)
+	applyPagination(req, sent)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return PaginatedResponse{}, 0, err
+	}
+	defer c.closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return PaginatedResponse{}, 0, fmt.Errorf("get catalog failed: %s - %s", resp.Status, string(body))
+	}
+
+	count, err := decodeCatalogStream(json.NewTokenDecoder(resp.Body), yield)
+	if err != nil {
+		return PaginatedResponse{}, count, err
+	}
+
+	next := parseLinkHeader(resp.Header.Get("Link"))
+	c.notePageSize(&c.catalogPageSize, "CatalogStream", sent, count, next)
+	c.logDebug("Registry response",
+		"operation", "CatalogStream",
+		"repository_count", count,
+		"has_more", next.HasMore,
+	)
+	return next, count, nil
+}
+
+// decodeCatalogStream reads a {"repositories": [...]} document token by token,
+// yielding each repository. Other fields are skipped.
+func decodeCatalogStream(dec *json.TokenDecoder, yield func(string, error) bool) (int, error) {
+	if err := expectDelim(dec, '{'); err != nil {
+		return 0, err
+	}
+
+	count := 0
+	for dec.More() {
+		key, err := dec.Token()
+		if err != nil {
+			return count, err
+		}
+		if key != "repositories" {
+			var skip any
+			if err := dec.Decode(&skip); err != nil {
+				return count, err
+			}
+			continue
+		}
+
+		// Some registries return "repositories": null for an empty catalog
+		tok, err := dec.Token()
+		if err != nil {
+			return count, err
+		}
+		if tok == nil {
+			continue
+		}
+		if tok != json.Delim('[') {
+			return count, fmt.Errorf("get catalog failed: repositories is not an array")
+		}
+
+		for dec.More() {
+			var name string
+			if err := dec.Decode(&name); err != nil {
+				return count, err
+			}
+			count++
+			if !yield(name, nil) {
+				return count, errStopIteration
+			}
+		}
+		if err := expectDelim(dec, ']'); err != nil {
+			return count, err
+		}
+	}
+	return count, expectDelim(dec, '}')
+}
+
+// expectDelim reads the next token and checks that it is delim
+func expectDelim(dec *json.TokenDecoder, delim json.Delim) error {
+	tok, err := dec.Token()
+	if err != nil {
+		return err
+	}
+	if tok != delim {
+		return fmt.Errorf("invalid catalog response: expected %q, got %v", delim, tok)
+	}
+	return nil
+}
@@ -0,0 +1,121 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newLargeCatalogServer serves a first page of size repositories followed by a
+// one-repository page, linked with a Link header
+func newLargeCatalogServer(t *testing.T, size int) (*httptest.Server, *[]string) {
+	t.Helper()
+	var lasts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lasts = append(lasts, r.URL.Query().Get("last"))
+		if r.URL.Query().Get("last") != "" {
+			_, _ = w.Write([]byte(`{"repositories":["zz/last"]}`))
+			return
+		}
+
+		w.Header().Set("Link", fmt.Sprintf(`</v2/_catalog?last=repo-%06d&n=%d>; rel="next"`, size-1, size))
+		var body strings.Builder
+		body.WriteString(`{"meta":{"ignored":[1,2]},"repositories":[`)
+		for i := range size {
+			if i > 0 {
+				body.WriteByte(',')
+			}
+			fmt.Fprintf(&body, `"repo-%06d"`, i)
+		}
+		body.WriteString(`]}`)
+		_, _ = w.Write([]byte(body.String()))
+	}))
+	t.Cleanup(server.Close)
+	return server, &lasts
+}
+
+func TestCatalogStream(t *testing.T) {
+	const size = 100000
+	server, lasts := newLargeCatalogServer(t, size)
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+
+	var repos []string
+	for repo, err := range client.CatalogStream(context.Background(), nil) {
+		require.NoError(t, err)
+		repos = append(repos, repo)
+	}
+
+	require.Len(t, repos, size+1)
+	assert.Equal(t, "repo-000000", repos[0])
+	assert.Equal(t, fmt.Sprintf("repo-%06d", size-1), repos[size-1])
+	assert.Equal(t, "zz/last", repos[size])
+	assert.Equal(t, []string{"", fmt.Sprintf("repo-%06d", size-1)}, *lasts)
+}
+
+func TestCatalogStream_Break(t *testing.T) {
+	server, lasts := newLargeCatalogServer(t, 1000)
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+
+	var repos []string
+	for repo, err := range client.CatalogStream(context.Background(), &PaginationParams{N: 1000}) {
+		require.NoError(t, err)
+		repos = append(repos, repo)
+		if len(repos) == 3 {
+			break
+		}
+	}
+
+	assert.Equal(t, []string{"repo-000000", "repo-000001", "repo-000002"}, repos)
+	assert.Len(t, *lasts, 1, "the next page must not be requested after break")
+}
+
+func TestCatalogStream_Errors(t *testing.T) {
+	tests := []struct {
+		name      string
+		status    int
+		body      string
+		wantRepos []string
+		wantErr   string
+	}{
+		{name: "null repositories", status: http.StatusOK, body: `{"repositories":null}`},
+		{name: "partial page", status: http.StatusOK, body: `{"repositories":["a","b"`, wantRepos: []string{"a", "b"}, wantErr: "EOF"},
+		{name: "not an array", status: http.StatusOK, body: `{"repositories":"a"}`, wantErr: "repositories is not an array"},
+		{name: "not an object", status: http.StatusOK, body: `["a"]`, wantErr: "invalid catalog response"},
+		{name: "unauthorized", status: http.StatusUnauthorized, body: `denied`, wantErr: "get catalog failed: 401"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+			var repos []string
+			var errs []error
+			for repo, err := range client.CatalogStream(context.Background(), nil) {
+				if err != nil {
+					errs = append(errs, err)
+					continue
+				}
+				repos = append(repos, repo)
+			}
+
+			assert.Equal(t, tt.wantRepos, repos)
+			if tt.wantErr == "" {
+				assert.Empty(t, errs)
+				return
+			}
+			require.Len(t, errs, 1)
+			assert.Contains(t, errs[0].Error(), tt.wantErr)
+		})
+	}
+}
@@ -1,10 +1,16 @@
 package registryclient
 
 import (
+	"bytes"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
+	"net/url"
 	"strconv"
+	"strings"
+	"sync/atomic"
 	"time"
 )
 
@@ -20,6 +26,12 @@ type Logger interface {
 	Error(msg string, args ...any)
 }
 
+// RateLimiter paces outgoing requests. *rate.Limiter from golang.org/x/time/rate satisfies it.
+type RateLimiter interface {
+	// Wait blocks until a request may be sent, or returns an error when ctx is done first
+	Wait(ctx context.Context) error
+}
+
 // BasicAuth implements HTTP Basic Authentication
 type BasicAuth struct {
 	Username string
@@ -32,30 +44,206 @@ func (b BasicAuth) Apply(req *http.Request) {
 
 // BearerAuth implements HTTP Bearer Token Authentication
 type BearerAuth struct {
-	Token string
+	Token  string
+	Scheme string // Authorization scheme, e.g. "token" (empty = "Bearer")
 }
 
 func (b BearerAuth) Apply(req *http.Request) {
-	req.Header.Set("Authorization", "Bearer "+b.Token)
+	scheme := b.Scheme
+	if scheme == "" {
+		scheme = "Bearer"
+	}
+	req.Header.Set("Authorization", scheme+" "+b.Token)
 }
 
 // BaseClient wraps http.Client with registry-specific configuration
 type BaseClient struct {
 	HTTPClient    *http.Client // HTTP client for making requests
-	BaseURL       string
+	BaseURL       string       // Registry URL, optionally with a path prefix kept before /v2/
 	Auth          Auth
 	RetryBackoff  time.Duration // Initial backoff duration for retries
 	MaxAttempts   int           // Maximum number of retry attempts (0 = no retries)
 	Logger        Logger        // Optional logger (nil = no logging)
 	DisableDelete bool          // When true, delete operations will only log and not execute
+	MaxBlobBytes  int64         // Maximum blob size GetBlob buffers in memory (0 = no limit)
+
+	ResolveTagsOnDelete bool // When true, DeleteManifest resolves a tag to its digest instead of failing
+	RetryNonIdempotent  bool // When true, POST and PATCH requests are retried too (body must be rewindable)
+	MaxPages            int  // Maximum pages fetched by helpers that follow pagination (0 = 10000)
+	MaxManifestBytes    int  // Maximum manifest size GetManifest accepts (0 = DefaultMaxManifestBytes, negative = no limit)
+	RetryNotFound       bool // When true, GetManifest and GetBlob retry 404s with backoff (eventually consistent storage)
+	NotFoundRetries     int  // Number of 404 retries when RetryNotFound is set (0 = 3)
+
+	DigestFunc    func([]byte) string // Computes manifest digests locally when the registry reports none (nil = sha256)
+	ValidateNames bool                // When true, repository names are checked with ValidateRepositoryName before each request
+	AuthAllHosts  bool                // When true, Auth is applied to requests for any host, not only the BaseURL host
+	PreferIndex   bool                // When true, manifest requests list index media types first in Accept, so multi-arch tags resolve to their index
+	StrictJSON    bool                // When true, fetched manifests and configs with fields their specification does not define are rejected
+
+	DefaultPageSize int           // Page size requested by helpers that drain every page, e.g. ListAllTags (0 = registry default)
+	RateLimiter     RateLimiter   // Optional limiter waited on before every attempt, retries included (nil = unlimited)
+	MaxRetryElapsed time.Duration // Time a request may spend on retries from its first attempt; no retry starts past it (0 = no limit)
+	ManifestCache   ManifestCache // Optional cache GetManifest answers digest references from (nil = no caching)
+
+	// ShouldRetry, when set, replaces the default retry decision (transport errors, 5xx and 429).
+	// It is called after every attempt with either resp or err set, and may read resp.Body:
+	// what it reads is replayed to the caller. MaxAttempts and idempotency rules still apply.
+	ShouldRetry func(resp *http.Response, err error, attempt int) bool
+
+	// DeleteSuccessStatuses lists the statuses DeleteManifest accepts as a deletion
+	// (nil = 200, 202 and 204). The spec requires 202; some registries answer 200 or 204.
+	DeleteSuccessStatuses []int
+
+	// AllowedHosts, when non-empty, lists the only hosts the client may contact, redirect targets,
+	// token realms and GitHub or Docker Hub APIs included; other requests fail with
+	// ErrHostNotAllowed. An entry without a port matches the host on any port; comparison
+	// is case-insensitive.
+	AllowedHosts []string
+
+	// What paginated responses revealed about the registry's support of n, per endpoint
+	// (see notePageSize): registries may treat the catalog and tag lists differently
+	catalogPageSize pageSizeState
+	tagsPageSize    pageSizeState
+}
+
+// pageSizeState is what the responses of one paginated endpoint revealed about n
+type pageSizeState struct {
+	ignored atomic.Bool  // A page held more items than n asked for: n is no longer sent
+	max     atomic.Int64 // Page size the registry capped n to through Link (0 = not capped)
 }
 
-// Do applies auth before performing the request with retry logic
+// defaultMaxPages bounds pagination loops when MaxPages is not set
+const defaultMaxPages = 10000
+
+// defaultConcurrency bounds the requests helpers such as RepositoryBlobs send at once
+const defaultConcurrency = 8
+
+// ErrTooManyPages is returned when a paginating helper exceeds MaxPages,
+// which usually means the registry keeps returning the same page
+var ErrTooManyPages = errors.New("too many pages")
+
+// ErrHostNotAllowed is returned when a request or redirect targets a host outside AllowedHosts
+var ErrHostNotAllowed = errors.New("host not allowed")
+
+// Do applies auth before performing the request with retry logic.
+// Each attempt is sent as a clone of req, so req itself is never mutated.
+// When Auth is a TokenRefresher, a 401 refreshes the token and the request is sent once more.
 func (c *BaseClient) Do(req *http.Request) (*http.Response, error) {
+	if err := c.checkHost(req.URL); err != nil {
+		return nil, err
+	}
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return nil, err
+	}
+	return c.refreshAndRetry(req, resp)
+}
+
+// newAttempt clones req for a single attempt, rewinding the body when possible, and applies auth
+func (c *BaseClient) newAttempt(req *http.Request) (*http.Request, error) {
+	attemptReq := req.Clone(req.Context())
+	if req.GetBody != nil && req.Body != nil && req.Body != http.NoBody {
+		body, err := req.GetBody()
+		if err != nil {
+			return nil, err
+		}
+		attemptReq.Body = body
+	}
 	if c.Auth != nil {
-		c.Auth.Apply(req)
+		if c.authorizes(req.URL) {
+			c.Auth.Apply(attemptReq)
+		} else {
+			c.logDebug("Not sending credentials to foreign host",
+				"method", req.Method,
+				"url", req.URL.String(),
+			)
+		}
+	}
+	return attemptReq, nil
+}
+
+// registryURL returns BaseURL without trailing slashes, so "/v2/..." can be appended to it.
+// Any path prefix of BaseURL is preserved.
+func (c *BaseClient) registryURL() string {
+	return strings.TrimRight(c.BaseURL, "/")
+}
+
+// authorizes reports whether Auth may be applied to a request for u: only when u has the
+// host of BaseURL, so credentials never leak to external storage or mirrors, unless
+// AuthAllHosts is set. Without a BaseURL there is no origin to compare and Auth is applied.
+func (c *BaseClient) authorizes(u *url.URL) bool {
+	return c.AuthAllHosts || c.BaseURL == "" || sameHost(u, c.BaseURL)
+}
+
+// sameHost reports whether u has the same scheme, host and port as baseURL,
+// treating a missing port as the scheme's default
+func sameHost(u *url.URL, baseURL string) bool {
+	base, err := url.Parse(baseURL)
+	if err != nil {
+		return false
+	}
+	return strings.EqualFold(u.Scheme, base.Scheme) &&
+		strings.EqualFold(u.Hostname(), base.Hostname()) &&
+		portOrDefault(u) == portOrDefault(base)
+}
+
+// portOrDefault returns the port of u, or the default port of its scheme
+func portOrDefault(u *url.URL) string {
+	if port := u.Port(); port != "" {
+		return port
+	}
+	switch strings.ToLower(u.Scheme) {
+	case "http":
+		return "80"
+	case "https":
+		return "443"
+	}
+	return ""
+}
+
+// checkHost returns ErrHostNotAllowed when AllowedHosts is set and does not list u's host
+func (c *BaseClient) checkHost(u *url.URL) error {
+	if len(c.AllowedHosts) == 0 {
+		return nil
+	}
+	for _, host := range c.AllowedHosts {
+		if strings.EqualFold(host, u.Host) || strings.EqualFold(host, u.Hostname()) {
+			return nil
+		}
+	}
+	return fmt.Errorf("%w: %s", ErrHostNotAllowed, u.Host)
+}
+
+// httpClient returns HTTPClient, or when AllowedHosts is set a copy of it whose
+// CheckRedirect refuses redirects to other hosts before following the original policy
+func (c *BaseClient) httpClient() *http.Client {
+	if len(c.AllowedHosts) == 0 {
+		return c.HTTPClient
+	}
+	client := *c.HTTPClient
+	next := client.CheckRedirect
+	client.CheckRedirect = func(req *http.Request, via []*http.Request) error {
+		if err := c.checkHost(req.URL); err != nil {
+			return err
+		}
+		if next != nil {
+			return next(req, via)
+		}
+		if len(via) >= 10 { // net/http's default policy
+			return errors.New("stopped after 10 redirects")
+		}
+		return nil
+	}
+	return &client
+}
+
+// doWithoutAuth sends req once, without Auth or retries, for APIs separate from the registry
+// such as GitHub's and Docker Hub's. AllowedHosts still applies, redirects included.
+func (c *BaseClient) doWithoutAuth(req *http.Request) (*http.Response, error) {
+	if err := c.checkHost(req.URL); err != nil {
+		return nil, err
 	}
-	return c.doWithRetry(req)
+	return c.httpClient().Do(req)
 }
 
 // retryState holds the state for a retry attempt
@@ -67,20 +255,57 @@ type retryState struct {
 // doWithRetry executes the request with exponential backoff retry logic
 func (c *BaseClient) doWithRetry(req *http.Request) (*http.Response, error) {
 	maxAttempts := c.maxAttempts()
+	if maxAttempts > 1 && !c.isRetrySafe(req) {
+		c.logDebug("Retries disabled for non-idempotent request",
+			"method", req.Method,
+			"url", req.URL.String(),
+		)
+		maxAttempts = 1
+	}
 	backoff := c.backoff()
 	state := &retryState{}
+	start := time.Now()
+	httpClient := c.httpClient()
 
 	for attempt := 1; attempt <= maxAttempts; attempt++ {
-		resp, err := c.HTTPClient.Do(req)
+		if err := c.waitRateLimit(req); err != nil {
+			if state.lastResp != nil {
+				c.closeBody(state.lastResp.Body)
+			}
+			return nil, err
+		}
 
-		if shouldReturnImmediately(resp, err) {
-			return resp, nil
+		attemptReq, err := c.newAttempt(req)
+		if err != nil {
+			return nil, err
+		}
+
+		resp, err := httpClient.Do(attemptReq)
+
+		if !c.isRetryable(resp, err, attempt) {
+			if err != nil {
+				return nil, err
+			}
+			if attempt > 1 {
+				c.logRetrySucceeded(req, attempt, resp)
+			}
+			return withAttempts(resp, attempt), nil
 		}
 
 		c.updateRetryState(state, resp, err)
 
 		if shouldRetry(attempt, maxAttempts) {
 			sleepDuration := getRetryDelay(state.lastResp, attempt, backoff)
+			if c.retryBudgetSpent(start, sleepDuration) {
+				c.logWarn("Registry request retry time budget exhausted",
+					"method", req.Method,
+					"url", req.URL.String(),
+					"attempts", attempt,
+					"elapsed", time.Since(start).String(),
+					"max_retry_elapsed", c.MaxRetryElapsed.String(),
+				)
+				return c.handleMaxRetriesExceeded(req, attempt, state)
+			}
 			c.logRetryAttempt(req, attempt, maxAttempts, state.lastErr, sleepDuration, state.lastResp)
 			time.Sleep(sleepDuration)
 		}
@@ -89,6 +314,129 @@ func (c *BaseClient) doWithRetry(req *http.Request) (*http.Response, error) {
 	return c.handleMaxRetriesExceeded(req, maxAttempts, state)
 }
 
+// waitRateLimit waits on RateLimiter, if set, honoring the request context
+func (c *BaseClient) waitRateLimit(req *http.Request) error {
+	if c.RateLimiter == nil {
+		return nil
+	}
+	return c.RateLimiter.Wait(req.Context())
+}
+
+// isRetrySafe reports whether req can be sent again without duplicating side effects.
+// GET, HEAD, DELETE, OPTIONS and TRACE are always safe. PUT is safe when its body can be
+// replayed, since registry PUTs are digest or tag addressed. POST and PATCH are only
+// retried when RetryNonIdempotent is set and their body can be replayed.
+func (c *BaseClient) isRetrySafe(req *http.Request) bool {
+	switch req.Method {
+	case http.MethodGet, http.MethodHead, http.MethodDelete, http.MethodOptions, http.MethodTrace:
+		return true
+	case http.MethodPut:
+		return isBodyReplayable(req)
+	default:
+		return c.RetryNonIdempotent && isBodyReplayable(req)
+	}
+}
+
+// isBodyReplayable reports whether req has no body or a body that GetBody can rewind
+func isBodyReplayable(req *http.Request) bool {
+	return req.Body == nil || req.Body == http.NoBody || req.GetBody != nil
+}
+
+// doRetryNotFound performs the request like Do and, when RetryNotFound is set, retries
+// 404 responses with exponential backoff. Registries backed by eventually consistent
+// storage can briefly report content that was just pushed as missing.
+func (c *BaseClient) doRetryNotFound(req *http.Request) (*http.Response, error) {
+	resp, err := c.Do(req)
+	if !c.RetryNotFound {
+		return resp, err
+	}
+
+	retries := c.notFoundRetries()
+	for attempt := 1; err == nil && resp.StatusCode == http.StatusNotFound && attempt <= retries; attempt++ {
+		attempts := ResponseAttempts(resp)
+		c.closeBody(resp.Body)
+		sleepDuration := calculateBackoff(attempt, c.backoff())
+		c.logWarn("Retrying registry request after not found",
+			"method", req.Method,
+			"url", req.URL.String(),
+			"attempt", attempt,
+			"max_retries", retries,
+			"backoff", sleepDuration.String(),
+		)
+		if err := sleepContext(req.Context(), sleepDuration); err != nil {
+			return nil, err
+		}
+		if resp, err = c.Do(req); err == nil {
+			resp = withAttempts(resp, attempts+ResponseAttempts(resp))
+		}
+	}
+	return resp, err
+}
+
+// sleepContext waits for d or until ctx is done
+func sleepContext(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// notFoundRetries returns the number of 404 retries with default fallback
+func (c *BaseClient) notFoundRetries() int {
+	if c.NotFoundRetries <= 0 {
+		return 3
+	}
+	return c.NotFoundRetries
+}
+
+// isRetryable reports whether an attempt's outcome should be retried, using ShouldRetry when set.
+// The body ShouldRetry reads is buffered and put back in front of the unread rest.
+func (c *BaseClient) isRetryable(resp *http.Response, err error, attempt int) bool {
+	if resp != nil && isFinalRangeStatus(resp) {
+		return false
+	}
+	if errors.Is(err, ErrHostNotAllowed) {
+		return false
+	}
+	if c.ShouldRetry == nil {
+		return !shouldReturnImmediately(resp, err)
+	}
+	if resp == nil {
+		return c.ShouldRetry(nil, err, attempt)
+	}
+
+	body := resp.Body
+	var peeked bytes.Buffer
+	resp.Body = io.NopCloser(io.TeeReader(body, &peeked))
+	retry := c.ShouldRetry(resp, err, attempt)
+	resp.Body = replayedBody{Reader: io.MultiReader(&peeked, body), Closer: body}
+	return retry
+}
+
+// isFinalRangeStatus reports whether resp answers a byte-range request with a status that
+// retrying cannot change: 416 (the range lies outside the blob), 400 or 404
+func isFinalRangeStatus(resp *http.Response) bool {
+	if resp.Request == nil || resp.Request.Header.Get("Range") == "" {
+		return false
+	}
+	switch resp.StatusCode {
+	case http.StatusBadRequest, http.StatusNotFound, http.StatusRequestedRangeNotSatisfiable:
+		return true
+	default:
+		return false
+	}
+}
+
+// replayedBody reads a response body's peeked prefix followed by the rest, and closes the original
+type replayedBody struct {
+	io.Reader
+	io.Closer
+}
+
 // shouldReturnImmediately checks if we should return the response without retrying
 func shouldReturnImmediately(resp *http.Response, err error) bool {
 	if err != nil {
@@ -108,6 +456,9 @@ func (c *BaseClient) updateRetryState(state *retryState, resp *http.Response, er
 		state.lastErr = fmt.Errorf("retryable status code: %d", resp.StatusCode)
 	} else {
 		state.lastErr = err
+		// A transport error (e.g. EOF on a stale keep-alive connection) may leave other
+		// pooled connections stale too, so make the next attempt dial a fresh one
+		c.HTTPClient.CloseIdleConnections()
 	}
 }
 
@@ -116,6 +467,12 @@ func shouldRetry(attempt, maxAttempts int) bool {
 	return attempt < maxAttempts
 }
 
+// retryBudgetSpent reports whether waiting sleepDuration before another attempt would take
+// the request past MaxRetryElapsed, counted from its first attempt
+func (c *BaseClient) retryBudgetSpent(start time.Time, sleepDuration time.Duration) bool {
+	return c.MaxRetryElapsed > 0 && time.Since(start)+sleepDuration > c.MaxRetryElapsed
+}
+
 // getRetryDelay calculates the delay before the next retry attempt
 func getRetryDelay(resp *http.Response, attempt int, backoff time.Duration) time.Duration {
 	if resp != nil {
@@ -141,12 +498,33 @@ func (c *BaseClient) handleMaxRetriesExceeded(req *http.Request, maxAttempts int
 
 	// If we have a response with a retryable status, return it instead of error
 	if state.lastResp != nil {
-		return state.lastResp, nil
+		return withAttempts(state.lastResp, maxAttempts), nil
 	}
 
 	return nil, fmt.Errorf("max retries exceeded: %w", state.lastErr)
 }
 
+// attemptsContextKey is the context key of the attempt count recorded on a response's request
+type attemptsContextKey struct{}
+
+// withAttempts records on resp that it took attempts requests to obtain
+func withAttempts(resp *http.Response, attempts int) *http.Response {
+	if resp.Request != nil {
+		resp.Request = resp.Request.WithContext(context.WithValue(resp.Request.Context(), attemptsContextKey{}, attempts))
+	}
+	return resp
+}
+
+// ResponseAttempts returns how many requests Do sent to obtain resp, retries and the
+// request repeated after a token refresh included. It returns 0 for responses Do did not return.
+func ResponseAttempts(resp *http.Response) int {
+	if resp == nil || resp.Request == nil {
+		return 0
+	}
+	attempts, _ := resp.Request.Context().Value(attemptsContextKey{}).(int)
+	return attempts
+}
+
 // maxAttempts returns the maximum number of attempts (at least 1)
 func (c *BaseClient) maxAttempts() int {
 	if c.MaxAttempts <= 0 {
@@ -155,6 +533,43 @@ func (c *BaseClient) maxAttempts() int {
 	return c.MaxAttempts
 }
 
+// checkRepositoryName validates a repository name when ValidateNames is set
+func (c *BaseClient) checkRepositoryName(repository string) error {
+	if !c.ValidateNames {
+		return nil
+	}
+	return ValidateRepositoryName(repository)
+}
+
+// maxPages returns the page limit for paginating helpers with default fallback
+func (c *BaseClient) maxPages() int {
+	if c.MaxPages <= 0 {
+		return defaultMaxPages
+	}
+	return c.MaxPages
+}
+
+// pageSize returns DefaultPageSize, or fallback when it is not set, capped at limit (0 = no cap)
+func (c *BaseClient) pageSize(fallback, limit int) int {
+	size := c.DefaultPageSize
+	if size <= 0 {
+		size = fallback
+	}
+	if limit > 0 && size > limit {
+		size = limit
+	}
+	return size
+}
+
+// checkPageLimit returns ErrTooManyPages once page exceeds the page limit
+func (c *BaseClient) checkPageLimit(operation string, page int) error {
+	if page <= c.maxPages() {
+		return nil
+	}
+	c.logError("Pagination limit exceeded", "operation", operation, "max_pages", c.maxPages())
+	return fmt.Errorf("%s: %w (limit %d)", operation, ErrTooManyPages, c.maxPages())
+}
+
 // backoff returns the initial backoff duration with default fallback
 func (c *BaseClient) backoff() time.Duration {
 	if c.RetryBackoff <= 0 {
@@ -211,6 +626,16 @@ func (c *BaseClient) logRetry(req *http.Request, attempt, maxAttempts int, err e
 	)
 }
 
+// logRetrySucceeded logs that a request got a final response after one or more retries
+func (c *BaseClient) logRetrySucceeded(req *http.Request, attempts int, resp *http.Response) {
+	c.logInfo("Registry request succeeded after retry",
+		"method", req.Method,
+		"url", req.URL.String(),
+		"attempts", attempts,
+		"status_code", resp.StatusCode,
+	)
+}
+
 // logRetryWithRetryAfter logs a retry attempt with Retry-After header if a logger is configured
 func (c *BaseClient) logRetryWithRetryAfter(req *http.Request, attempt, maxAttempts int, err error, retryAfter time.Duration) {
 	c.logWarn("Retrying registry request",
@@ -234,37 +659,37 @@ func (c *BaseClient) logMaxRetriesExceeded(req *http.Request, maxAttempts int, e
 	)
 }
 
-// closeBody closes the response body and logs any error if a logger is configured
+// closeBody closes the response body and logs any error at debug level
 func (c *BaseClient) closeBody(body io.Closer) {
 	if err := body.Close(); err != nil {
 		c.logDebug("Failed to close response body", "error", err.Error())
 	}
 }
 
-// logDebug logs a debug message if a logger is configured
-func (c *BaseClient) logDebug(msg string, args ...any) {
-	if c.Logger != nil {
-		c.Logger.Debug(msg, args...)
+// logger returns the configured logger, or NoopLogger when Logger is nil
+func (c *BaseClient) logger() Logger {
+	if c.Logger == nil {
+		return NoopLogger{}
 	}
+	return c.Logger
+}
+
+// logDebug logs a debug message through the client's logger
+func (c *BaseClient) logDebug(msg string, args ...any) {
+	c.logger().Debug(msg, args...)
 }
 
-// logInfo logs an info message if a logger is configured
+// logInfo logs an info message through the client's logger
 func (c *BaseClient) logInfo(msg string, args ...any) {
-	if c.Logger != nil {
-		c.Logger.Info(msg, args...)
-	}
+	c.logger().Info(msg, args...)
 }
 
-// logWarn logs a warning message if a logger is configured
+// logWarn logs a warning message through the client's logger
 func (c *BaseClient) logWarn(msg string, args ...any) {
-	if c.Logger != nil {
-		c.Logger.Warn(msg, args...)
-	}
+	c.logger().Warn(msg, args...)
 }
 
-// logError logs an error message if a logger is configured
+// logError logs an error message through the client's logger
 func (c *BaseClient) logError(msg string, args ...any) {
-	if c.Logger != nil {
-		c.Logger.Error(msg, args...)
-	}
+	c.logger().Error(msg, args...)
 }
@@ -1,14 +1,19 @@
 package registryclient
 
 import (
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	json "github.com/eznix86/registry-client/jsoncompat"
 	"github.com/stretchr/testify/assert"
 	"github.com/stretchr/testify/require"
 )
@@ -16,6 +21,7 @@ import (
 // mockLogger implements the Logger interface for testing
 type mockLogger struct {
 	debugCalls []logCall
+	infoCalls  []logCall
 	warnCalls  []logCall
 	errorCalls []logCall
 }
@@ -29,7 +35,9 @@ func (m *mockLogger) Debug(msg string, args ...any) {
 	m.debugCalls = append(m.debugCalls, logCall{msg: msg, args: args})
 }
 
-func (m *mockLogger) Info(msg string, args ...any) {}
+func (m *mockLogger) Info(msg string, args ...any) {
+	m.infoCalls = append(m.infoCalls, logCall{msg: msg, args: args})
+}
 
 func (m *mockLogger) Warn(msg string, args ...any) {
 	m.warnCalls = append(m.warnCalls, logCall{msg: msg, args: args})
@@ -66,6 +74,27 @@ func TestBearerAuth_Apply(t *testing.T) {
 	assert.Equal(t, "Bearer test-token-123", authHeader)
 }
 
+func TestBearerAuth_Apply_Scheme(t *testing.T) {
+	tests := []struct {
+		name   string
+		scheme string
+		want   string
+	}{
+		{name: "default", want: "Bearer test-token-123"},
+		{name: "token", scheme: "token", want: "token test-token-123"},
+		{name: "explicit bearer", scheme: "Bearer", want: "Bearer test-token-123"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
+			BearerAuth{Token: "test-token-123", Scheme: tt.scheme}.Apply(req)
+
+			assert.Equal(t, tt.want, req.Header.Get("Authorization"))
+		})
+	}
+}
+
 func TestClient_Do_AppliesAuth(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		username, password, ok := r.BasicAuth()
@@ -115,15 +144,92 @@ func TestClient_Do_NoAuth(t *testing.T) {
 	require.NoError(t, resp.Body.Close())
 }
 
+func TestClient_Do_AuthSameOriginOnly(t *testing.T) {
+	newServer := func(authorization *string) *httptest.Server {
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			*authorization = r.Header.Get("Authorization")
+			w.WriteHeader(http.StatusOK)
+		}))
+		t.Cleanup(server.Close)
+		return server
+	}
+	var registryAuth, storageAuth string
+	registry := newServer(&registryAuth)
+	storage := newServer(&storageAuth)
+
+	tests := []struct {
+		name         string
+		authAllHosts bool
+		wantForeign  bool
+	}{
+		{name: "same origin only", authAllHosts: false, wantForeign: false},
+		{name: "all hosts", authAllHosts: true, wantForeign: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registryAuth, storageAuth = "", ""
+			client := &BaseClient{
+				HTTPClient:   &http.Client{},
+				BaseURL:      registry.URL,
+				Auth:         BearerAuth{Token: "secret"},
+				AuthAllHosts: tt.authAllHosts,
+			}
+
+			for _, target := range []string{registry.URL + "/v2/", storage.URL + "/blobs/sha256:abc"} {
+				req, err := http.NewRequest(http.MethodGet, target, nil)
+				require.NoError(t, err)
+				resp, err := client.Do(req)
+				require.NoError(t, err)
+				require.NoError(t, resp.Body.Close())
+			}
+
+			assert.Equal(t, "Bearer secret", registryAuth)
+			if tt.wantForeign {
+				assert.Equal(t, "Bearer secret", storageAuth)
+			} else {
+				assert.Empty(t, storageAuth, "credentials must not be sent to a foreign host")
+			}
+		})
+	}
+}
+
+func TestSameHost(t *testing.T) {
+	tests := []struct {
+		target  string
+		baseURL string
+		want    bool
+	}{
+		{target: "https://ghcr.io/v2/app/tags/list", baseURL: "https://ghcr.io", want: true},
+		{target: "https://GHCR.io:443/v2/", baseURL: "https://ghcr.io", want: true},
+		{target: "http://localhost:5000/v2/", baseURL: "http://localhost:5000", want: true},
+		{target: "http://localhost:5001/v2/", baseURL: "http://localhost:5000", want: false},
+		{target: "http://ghcr.io/v2/", baseURL: "https://ghcr.io", want: false},
+		{target: "https://pkg-containers.githubusercontent.com/blob", baseURL: "https://ghcr.io", want: false},
+		{target: "https://ghcr.io.evil.example/v2/", baseURL: "https://ghcr.io", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.target, func(t *testing.T) {
+			u, err := url.Parse(tt.target)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, sameHost(u, tt.baseURL))
+		})
+	}
+}
+
 func TestClient_DoWithRetry_Success(t *testing.T) {
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
 		w.WriteHeader(http.StatusOK)
 	}))
 	defer server.Close()
 
+	logger := &mockLogger{}
 	client := &BaseClient{
-		HTTPClient: &http.Client{},
-		BaseURL:    server.URL,
+		HTTPClient:  &http.Client{},
+		BaseURL:     server.URL,
+		MaxAttempts: 3,
+		Logger:      logger,
 	}
 
 	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
@@ -134,6 +240,7 @@ func TestClient_DoWithRetry_Success(t *testing.T) {
 	defer func() { require.NoError(t, resp.Body.Close()) }()
 
 	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Empty(t, logger.infoCalls, "no success log without a retry")
 }
 
 func TestClient_DoWithRetry_RetryableError(t *testing.T) {
@@ -169,6 +276,11 @@ func TestClient_DoWithRetry_RetryableError(t *testing.T) {
 
 	// Verify retry logs (2 retries = 2 warn calls)
 	assert.Len(t, logger.warnCalls, 2, "Expected 2 warn logs for retries")
+
+	// And one confirmation that the request eventually succeeded
+	require.Len(t, logger.infoCalls, 1)
+	assert.Equal(t, "Registry request succeeded after retry", logger.infoCalls[0].msg)
+	assert.Subset(t, logger.infoCalls[0].args, []any{"attempts", 3, "status_code", http.StatusOK})
 }
 
 func TestClient_DoWithRetry_MaxRetriesExceeded(t *testing.T) {
@@ -202,6 +314,166 @@ func TestClient_DoWithRetry_MaxRetriesExceeded(t *testing.T) {
 	assert.Len(t, logger.errorCalls, 1, "Expected 1 error log")
 }
 
+func TestResponseAttempts(t *testing.T) {
+	tests := []struct {
+		name        string
+		failures    int
+		maxAttempts int
+		want        int
+	}{
+		{name: "first attempt", failures: 0, maxAttempts: 3, want: 1},
+		{name: "after retries", failures: 2, maxAttempts: 3, want: 3},
+		{name: "retries exhausted", failures: 5, maxAttempts: 3, want: 3},
+		{name: "no retries", failures: 1, maxAttempts: 0, want: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if int(requests.Add(1)) <= tt.failures {
+					w.WriteHeader(http.StatusServiceUnavailable)
+				}
+			}))
+			defer server.Close()
+
+			client := &BaseClient{HTTPClient: &http.Client{}, MaxAttempts: tt.maxAttempts, RetryBackoff: time.Millisecond}
+			req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer func() { _ = resp.Body.Close() }()
+
+			assert.Equal(t, tt.want, ResponseAttempts(resp))
+			assert.Equal(t, int32(tt.want), requests.Load())
+		})
+	}
+
+	assert.Zero(t, ResponseAttempts(nil))
+	assert.Zero(t, ResponseAttempts(&http.Response{Request: httptest.NewRequest(http.MethodGet, "/", nil)}))
+}
+
+func TestResponseAttempts_Responses(t *testing.T) {
+	registry := newFakeRegistry(t)
+	digest := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("layer")}, "latest")
+	layer := sha256Digest([]byte("layer"))
+
+	// Every path fails once before it is served
+	var mu sync.Mutex
+	failed := map[string]bool{}
+	inner := registry.server.Config.Handler
+	registry.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		first := !failed[r.Method+r.URL.Path]
+		failed[r.Method+r.URL.Path] = true
+		mu.Unlock()
+		if first {
+			w.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		inner.ServeHTTP(w, r)
+	})
+
+	client := registry.client()
+	client.MaxAttempts = 3
+	client.RetryBackoff = time.Millisecond
+	client.ManifestCache = NewLRUManifestCache(10)
+
+	manifest, err := client.GetManifest(context.Background(), "app", digest)
+	require.NoError(t, err)
+	assert.Equal(t, 2, manifest.Attempts)
+
+	cached, err := client.GetManifest(context.Background(), "app", digest)
+	require.NoError(t, err)
+	assert.Zero(t, cached.Attempts, "served from the cache")
+
+	head, err := client.HeadManifest(context.Background(), "app", "latest")
+	require.NoError(t, err)
+	assert.Equal(t, 2, head.Attempts)
+
+	blob, err := client.GetBlob(context.Background(), "app", layer)
+	require.NoError(t, err)
+	assert.Equal(t, 2, blob.Attempts)
+
+	blob, err = client.GetBlob(context.Background(), "app", layer)
+	require.NoError(t, err)
+	assert.Equal(t, 1, blob.Attempts)
+}
+
+func TestClient_DoWithRetry_MaxRetryElapsed(t *testing.T) {
+	t.Run("stops at the elapsed cap", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.WriteHeader(http.StatusServiceUnavailable)
+		}))
+		defer server.Close()
+
+		logger := &mockLogger{}
+		client := &BaseClient{
+			HTTPClient:      &http.Client{},
+			MaxAttempts:     100,
+			RetryBackoff:    20 * time.Millisecond,
+			MaxRetryElapsed: 100 * time.Millisecond,
+			Logger:          logger,
+		}
+
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		start := time.Now()
+		resp, err := client.Do(req)
+		elapsed := time.Since(start)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode, "the last response is returned")
+		// Backoffs of 20ms and 40ms fit in 100ms; the next 80ms would not
+		assert.Equal(t, int32(3), attempts.Load())
+		assert.Less(t, elapsed, 100*time.Millisecond+50*time.Millisecond)
+		require.Len(t, logger.warnCalls, 3, "two retries, then the exhausted budget")
+		assert.Equal(t, "Registry request retry time budget exhausted", logger.warnCalls[2].msg)
+		assert.Len(t, logger.errorCalls, 1)
+	})
+
+	t.Run("Retry-After beyond the cap", func(t *testing.T) {
+		var attempts atomic.Int32
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			attempts.Add(1)
+			w.Header().Set("Retry-After", "60")
+			w.WriteHeader(http.StatusTooManyRequests)
+		}))
+		defer server.Close()
+
+		client := &BaseClient{HTTPClient: &http.Client{}, MaxAttempts: 3, MaxRetryElapsed: time.Second}
+		req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer func() { _ = resp.Body.Close() }()
+
+		assert.Equal(t, http.StatusTooManyRequests, resp.StatusCode)
+		assert.Equal(t, int32(1), attempts.Load(), "no retry is started that would end past the cap")
+	})
+
+	t.Run("transport errors", func(t *testing.T) {
+		client := &BaseClient{
+			HTTPClient:      &http.Client{Transport: &fakeRoundTripper{}},
+			MaxAttempts:     100,
+			RetryBackoff:    20 * time.Millisecond,
+			MaxRetryElapsed: 50 * time.Millisecond,
+		}
+		req, err := http.NewRequest(http.MethodGet, "http://registry.invalid/v2/", nil)
+		require.NoError(t, err)
+
+		_, err = client.Do(req)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "max retries exceeded")
+	})
+}
+
 func TestClient_DoWithRetry_TooManyRequests(t *testing.T) {
 	attemptCount := 0
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
@@ -581,3 +853,464 @@ func TestClient_DoWithRetry_NoRetryAfter(t *testing.T) {
 	}
 	assert.True(t, hasBackoff, "Expected backoff to be logged")
 }
+
+func TestClient_DoWithRetry_ClonesRequestPerAttempt(t *testing.T) {
+	var rawQueries []string
+	var bodies []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawQueries = append(rawQueries, r.URL.RawQuery)
+		body, _ := io.ReadAll(r.Body)
+		bodies = append(bodies, string(body))
+		assert.Equal(t, "Bearer token", r.Header.Get("Authorization"))
+		if len(rawQueries) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{
+		HTTPClient:   &http.Client{},
+		BaseURL:      server.URL,
+		Auth:         BearerAuth{Token: "token"},
+		MaxAttempts:  3,
+		RetryBackoff: time.Millisecond,
+	}
+
+	t.Run("GET query is not duplicated", func(t *testing.T) {
+		rawQueries, bodies = nil, nil
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/v2/_catalog", nil)
+		require.NoError(t, err)
+		applyPagination(req, &PaginationParams{N: 10, Last: "repo"})
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		assert.Equal(t, []string{"last=repo&n=10", "last=repo&n=10", "last=repo&n=10"}, rawQueries)
+		assert.Empty(t, req.Header.Get("Authorization"), "caller's request must not be mutated")
+	})
+
+	t.Run("PUT body is replayed", func(t *testing.T) {
+		rawQueries, bodies = nil, nil
+		req, err := http.NewRequest(http.MethodPut, server.URL+"/v2/repo/manifests/v1", strings.NewReader("payload"))
+		require.NoError(t, err)
+
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		assert.Equal(t, []string{"payload", "payload", "payload"}, bodies)
+	})
+}
+
+func TestClient_Do_GetBodyError(t *testing.T) {
+	client := &BaseClient{HTTPClient: &http.Client{}}
+
+	req, err := http.NewRequest(http.MethodPut, "http://example.com", strings.NewReader("payload"))
+	require.NoError(t, err)
+	req.GetBody = func() (io.ReadCloser, error) { return nil, fmt.Errorf("cannot rewind") }
+
+	resp, err := client.Do(req) //nolint:bodyclose // response is nil on error
+	require.Error(t, err)
+	assert.Nil(t, resp)
+}
+
+func TestClient_DoWithRetry_Idempotency(t *testing.T) {
+	tests := []struct {
+		name               string
+		method             string
+		body               io.Reader
+		retryNonIdempotent bool
+		wantAttempts       int32
+	}{
+		{name: "GET is retried", method: http.MethodGet, wantAttempts: 3},
+		{name: "HEAD is retried", method: http.MethodHead, wantAttempts: 3},
+		{name: "DELETE is retried", method: http.MethodDelete, wantAttempts: 3},
+		{name: "PUT with buffered body is retried", method: http.MethodPut, body: strings.NewReader("manifest"), wantAttempts: 3},
+		{name: "PUT with streamed body is not retried", method: http.MethodPut, body: io.MultiReader(strings.NewReader("manifest")), wantAttempts: 1},
+		{name: "POST is not retried", method: http.MethodPost, wantAttempts: 1},
+		{name: "PATCH is not retried", method: http.MethodPatch, body: strings.NewReader("chunk"), wantAttempts: 1},
+		{name: "POST retried with override", method: http.MethodPost, retryNonIdempotent: true, wantAttempts: 3},
+		{name: "PATCH with streamed body not retried with override", method: http.MethodPatch, body: io.MultiReader(strings.NewReader("chunk")), retryNonIdempotent: true, wantAttempts: 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var attempts atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				attempts.Add(1)
+				w.WriteHeader(http.StatusServiceUnavailable)
+			}))
+			defer server.Close()
+
+			client := &BaseClient{
+				HTTPClient:         &http.Client{},
+				MaxAttempts:        3,
+				RetryBackoff:       time.Millisecond,
+				RetryNonIdempotent: tt.retryNonIdempotent,
+			}
+
+			req, err := http.NewRequest(tt.method, server.URL, tt.body)
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			require.NoError(t, resp.Body.Close())
+
+			assert.Equal(t, http.StatusServiceUnavailable, resp.StatusCode)
+			assert.Equal(t, tt.wantAttempts, attempts.Load())
+		})
+	}
+}
+
+func TestClient_MaxPages(t *testing.T) {
+	assert.Equal(t, 10000, (&BaseClient{}).maxPages())
+	assert.Equal(t, 50, (&BaseClient{MaxPages: 50}).maxPages())
+}
+
+func TestClient_RetryNotFound(t *testing.T) {
+	tests := []struct {
+		name          string
+		retryNotFound bool
+		notFoundCount int32
+		wantErr       bool
+		wantRequests  int32
+	}{
+		{name: "disabled by default", notFoundCount: 1, wantErr: true, wantRequests: 1},
+		{name: "succeeds after 404", retryNotFound: true, notFoundCount: 1, wantRequests: 2},
+		{name: "gives up after retries", retryNotFound: true, notFoundCount: 10, wantErr: true, wantRequests: 4},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests atomic.Int32
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if requests.Add(1) <= tt.notFoundCount {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				_, _ = w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json"}`))
+			}))
+			defer server.Close()
+
+			client := &BaseClient{
+				HTTPClient:    &http.Client{},
+				BaseURL:       server.URL,
+				RetryBackoff:  time.Millisecond,
+				RetryNotFound: tt.retryNotFound,
+			}
+
+			_, err := client.GetManifest(context.Background(), "app", "v1")
+			assertNotFoundResult(t, err, tt.wantErr, "get manifest failed")
+			assert.Equal(t, tt.wantRequests, requests.Load())
+
+			requests.Store(0)
+			_, err = client.GetBlob(context.Background(), "app", "sha256:abc")
+			assertNotFoundResult(t, err, tt.wantErr, "get blob failed")
+			assert.Equal(t, tt.wantRequests, requests.Load())
+		})
+	}
+}
+
+func assertNotFoundResult(t *testing.T, err error, wantErr bool, wantMsg string) {
+	t.Helper()
+	if wantErr {
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), wantMsg)
+		return
+	}
+	require.NoError(t, err)
+}
+
+func TestClient_RetryNotFound_ContextCanceled(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, RetryBackoff: time.Hour, RetryNotFound: true}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+
+	_, err := client.GetManifest(ctx, "app", "v1")
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+}
+
+// staleConnTransport fails the first request with io.EOF, like a keep-alive connection
+// the server already closed, and records CloseIdleConnections calls
+type staleConnTransport struct {
+	base        http.RoundTripper
+	requests    int
+	idleClosed  int
+	closedFirst bool
+}
+
+func (s *staleConnTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	s.requests++
+	if s.requests == 1 {
+		return nil, io.EOF
+	}
+	s.closedFirst = s.idleClosed > 0
+	return s.base.RoundTrip(req)
+}
+
+func (s *staleConnTransport) CloseIdleConnections() {
+	s.idleClosed++
+}
+
+func TestClient_DoWithRetry_ClosesIdleConnectionsOnTransportError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	transport := &staleConnTransport{base: http.DefaultTransport}
+	client := &BaseClient{
+		HTTPClient:   &http.Client{Transport: transport},
+		MaxAttempts:  2,
+		RetryBackoff: time.Millisecond,
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+	assert.Equal(t, 2, transport.requests)
+	assert.Equal(t, 1, transport.idleClosed)
+	assert.True(t, transport.closedFirst, "idle connections must be closed before the retry")
+}
+
+func TestClient_DoWithRetry_KeepsIdleConnectionsOnRetryableStatus(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusServiceUnavailable)
+	}))
+	defer server.Close()
+
+	transport := &staleConnTransport{base: http.DefaultTransport, requests: 1}
+	client := &BaseClient{
+		HTTPClient:   &http.Client{Transport: transport},
+		MaxAttempts:  2,
+		RetryBackoff: time.Millisecond,
+	}
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	require.NoError(t, resp.Body.Close())
+
+	assert.Zero(t, transport.idleClosed)
+}
+
+// intervalLimiter lets one request through per interval, like rate.NewLimiter(rate.Every(interval), 1)
+type intervalLimiter struct {
+	interval time.Duration
+	next     time.Time
+}
+
+func (l *intervalLimiter) Wait(ctx context.Context) error {
+	now := time.Now()
+	if l.next.Before(now) {
+		l.next = now
+	}
+	delay := l.next.Sub(now)
+	l.next = l.next.Add(l.interval)
+	return sleepContext(ctx, delay)
+}
+
+func TestClient_RateLimiter(t *testing.T) {
+	var sent []time.Time
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		sent = append(sent, time.Now())
+		if len(sent) == 2 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	const interval = 50 * time.Millisecond
+	client := &BaseClient{
+		HTTPClient:   &http.Client{},
+		BaseURL:      server.URL,
+		MaxAttempts:  2,
+		RetryBackoff: time.Millisecond,
+		RateLimiter:  &intervalLimiter{interval: interval},
+	}
+
+	// The second request is retried; the retry waits on the limiter too
+	for range 2 {
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/v2/", nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		client.closeBody(resp.Body)
+	}
+
+	require.Len(t, sent, 3)
+	for i := 1; i < len(sent); i++ {
+		assert.GreaterOrEqual(t, sent[i].Sub(sent[i-1]), interval-5*time.Millisecond, "request %d", i)
+	}
+}
+
+func TestClient_RateLimiter_ContextCanceled(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		_, _ = w.Write([]byte(`{"name":"app","tags":["v1"]}`))
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, RateLimiter: &intervalLimiter{interval: time.Hour}}
+	_, err := client.ListTags(context.Background(), "app", nil)
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Millisecond)
+	defer cancel()
+	_, err = client.ListTags(ctx, "app", nil)
+
+	require.ErrorIs(t, err, context.DeadlineExceeded)
+	assert.Equal(t, 1, requests)
+}
+
+func TestClient_ShouldRetry(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		switch r.URL.Path {
+		case "/busy":
+			// Some registries report transient backend failures as 400 with an OCI error code
+			if requests == 1 {
+				w.WriteHeader(http.StatusBadRequest)
+				_, _ = w.Write([]byte(`{"errors":[{"code":"UNAVAILABLE","message":"backend busy"}]}`))
+				return
+			}
+			_, _ = w.Write([]byte(`{"errors":[]}`))
+		default:
+			w.WriteHeader(http.StatusServiceUnavailable)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"DENIED"}]}`))
+		}
+	}))
+	defer server.Close()
+
+	var attempts []int
+	client := &BaseClient{
+		HTTPClient:   &http.Client{},
+		MaxAttempts:  3,
+		RetryBackoff: time.Millisecond,
+		ShouldRetry: func(resp *http.Response, err error, attempt int) bool {
+			attempts = append(attempts, attempt)
+			if err != nil {
+				return false
+			}
+			var body struct {
+				Errors []struct {
+					Code string `json:"code"`
+				} `json:"errors"`
+			}
+			_ = json.NewDecoder(resp.Body).Decode(&body)
+			return len(body.Errors) > 0 && body.Errors[0].Code == "UNAVAILABLE"
+		},
+	}
+
+	tests := []struct {
+		name         string
+		path         string
+		wantStatus   int
+		wantBody     string
+		wantAttempts []int
+	}{
+		{name: "retried on error code", path: "/busy", wantStatus: http.StatusOK, wantBody: `{"errors":[]}`, wantAttempts: []int{1, 2}},
+		{name: "retryable status not retried", path: "/denied", wantStatus: http.StatusServiceUnavailable, wantBody: `{"errors":[{"code":"DENIED"}]}`, wantAttempts: []int{1}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			requests, attempts = 0, nil
+			req, err := http.NewRequest(http.MethodGet, server.URL+tt.path, nil)
+			require.NoError(t, err)
+
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer client.closeBody(resp.Body)
+
+			assert.Equal(t, tt.wantStatus, resp.StatusCode)
+			body, err := io.ReadAll(resp.Body)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantBody, string(body), "the body ShouldRetry read is replayed")
+			assert.Equal(t, tt.wantAttempts, attempts)
+			assert.Len(t, attempts, requests)
+		})
+	}
+
+	t.Run("transport error not retried", func(t *testing.T) {
+		attempts = nil
+		transport := &staleConnTransport{base: http.DefaultTransport}
+		client.HTTPClient = &http.Client{Transport: transport}
+		req, err := http.NewRequest(http.MethodGet, server.URL+"/busy", nil)
+		require.NoError(t, err)
+
+		_, err = client.Do(req)
+		require.ErrorIs(t, err, io.EOF)
+		assert.Equal(t, 1, transport.requests)
+	})
+}
+
+func TestClient_AllowedHosts(t *testing.T) {
+	var disallowedRequests atomic.Int32
+	disallowed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		disallowedRequests.Add(1)
+	}))
+	defer disallowed.Close()
+
+	var allowedRequests atomic.Int32
+	allowed := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		allowedRequests.Add(1)
+		if r.URL.Path == "/redirect" {
+			http.Redirect(w, r, disallowed.URL+"/blob", http.StatusTemporaryRedirect)
+		}
+	}))
+	defer allowed.Close()
+
+	allowedURL, err := url.Parse(allowed.URL)
+	require.NoError(t, err)
+	client := &BaseClient{
+		HTTPClient:   &http.Client{},
+		MaxAttempts:  3,
+		RetryBackoff: time.Millisecond,
+		AllowedHosts: []string{strings.ToUpper(allowedURL.Host)},
+	}
+
+	t.Run("allowed host", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, allowed.URL+"/v2/", nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		_ = resp.Body.Close()
+		assert.Equal(t, http.StatusOK, resp.StatusCode)
+	})
+
+	t.Run("disallowed host", func(t *testing.T) {
+		req, err := http.NewRequest(http.MethodGet, disallowed.URL+"/v2/", nil)
+		require.NoError(t, err)
+		_, err = client.Do(req)
+		require.ErrorIs(t, err, ErrHostNotAllowed)
+	})
+
+	t.Run("redirect to disallowed host", func(t *testing.T) {
+		before := allowedRequests.Load()
+		req, err := http.NewRequest(http.MethodGet, allowed.URL+"/redirect", nil)
+		require.NoError(t, err)
+		_, err = client.Do(req)
+		require.ErrorIs(t, err, ErrHostNotAllowed)
+		assert.Equal(t, int32(1), allowedRequests.Load()-before, "refused redirects are not retried")
+	})
+
+	assert.Zero(t, disallowedRequests.Load())
+}
@@ -0,0 +1,200 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"iter"
+	"slices"
+)
+
+// CopyOptions configures a CopyImage call
+type CopyOptions struct {
+	Verify        bool // Check every fetched blob against the digest of its descriptor before pushing it
+	PipelineDepth int  // Blobs fetched ahead of the one being pushed (0 = fetch and push one at a time)
+}
+
+// CopyImage copies the manifest srcReference of srcRepository to dstRepository on dst under
+// dstReference, a tag or the source digest, with the config and layers it references and,
+// for an index, its child manifests and their blobs. dst may be c to copy within the
+// registry. Blobs dst already has are not fetched again. Manifests are pushed byte for byte,
+// so their digests are preserved. With opts.Verify set, every fetched blob is checked
+// against the digest of its descriptor before it is pushed, and the copy stops with
+// ErrDigestMismatch on the first corrupt one. Returns the digest of the pushed manifest.
+func (c *BaseClient) CopyImage(ctx context.Context, srcRepository, srcReference string, dst *BaseClient, dstRepository, dstReference string, opts CopyOptions) (string, error) {
+	c.logDebug("Copying image",
+		"operation", "CopyImage",
+		"source_repository", srcRepository,
+		"source_reference", srcReference,
+		"destination_repository", dstRepository,
+		"destination_reference", dstReference,
+		"verify", opts.Verify,
+		"pipeline_depth", opts.PipelineDepth,
+	)
+
+	manifest, err := c.GetManifest(ctx, srcRepository, srcReference)
+	if err != nil {
+		return "", err
+	}
+
+	cp := &imageCopy{
+		src:           c,
+		dst:           dst,
+		srcRepository: srcRepository,
+		dstRepository: dstRepository,
+		opts:          opts,
+		copied:        map[string]bool{},
+	}
+	digest, err := cp.copyManifest(ctx, manifest, dstReference)
+	if err != nil {
+		return "", err
+	}
+
+	c.logDebug("Image copied",
+		"operation", "CopyImage",
+		"destination_repository", dstRepository,
+		"digest", digest,
+		"blobs", len(cp.copied),
+	)
+	return digest, nil
+}
+
+// imageCopy is the state of a CopyImage call
+type imageCopy struct {
+	src, dst                     *BaseClient
+	srcRepository, dstRepository string
+	opts                         CopyOptions
+	copied                       map[string]bool // Blobs known to be in the destination
+}
+
+// copyManifest copies what manifest references, then pushes it under reference
+func (cp *imageCopy) copyManifest(ctx context.Context, manifest *ManifestResponse, reference string) (string, error) {
+	switch data := manifest.ManifestData.(type) {
+	case ManifestList:
+		for _, child := range data.Manifests {
+			childManifest, err := cp.src.getChildManifest(ctx, cp.srcRepository, child)
+			if err != nil {
+				return "", err
+			}
+			if _, err := cp.copyManifest(ctx, childManifest, child.Digest); err != nil {
+				return "", err
+			}
+		}
+	case ImageManifest:
+		digests := make([]string, 0, len(data.Layers)+1)
+		digests = append(digests, data.Config.Digest)
+		for _, layer := range data.Layers {
+			digests = append(digests, layer.Digest)
+		}
+		if err := cp.copyBlobs(ctx, digests); err != nil {
+			return "", err
+		}
+	}
+
+	pushed, err := cp.dst.PutManifest(ctx, cp.dstRepository, reference, manifest.MediaType, manifest.RawContent)
+	if err != nil {
+		return "", err
+	}
+	return pushed.Digest, nil
+}
+
+// copyBlobs copies the blobs with the given digests the destination does not have yet.
+// With PipelineDepth set, up to that many blobs are fetched ahead of the one being pushed.
+func (cp *imageCopy) copyBlobs(ctx context.Context, digests []string) error {
+	var missing []string
+	for _, digest := range digests {
+		if cp.copied[digest] || slices.Contains(missing, digest) {
+			continue
+		}
+		exists, err := cp.dst.HasBlob(ctx, cp.dstRepository, digest)
+		if err != nil {
+			return err
+		}
+		if exists {
+			cp.copied[digest] = true
+			continue
+		}
+		missing = append(missing, digest)
+	}
+
+	for blob := range cp.fetchBlobs(ctx, missing) {
+		if blob.err != nil {
+			return blob.err
+		}
+		if _, err := cp.dst.uploadBlob(ctx, cp.dstRepository, blob.content, blob.digest); err != nil {
+			return err
+		}
+		cp.copied[blob.digest] = true
+	}
+	return nil
+}
+
+// fetchedBlob is a blob fetched by the fetch stage of a copy, or the error that stopped it
+type fetchedBlob struct {
+	digest  string
+	content []byte
+	err     error
+}
+
+// fetchBlobs yields the blobs with the given digests in order, stopping after the first
+// error. Without PipelineDepth each blob is fetched when the previous one was consumed;
+// otherwise a goroutine fetches up to PipelineDepth blobs ahead of the consumer.
+func (cp *imageCopy) fetchBlobs(ctx context.Context, digests []string) iter.Seq[fetchedBlob] {
+	depth := cp.opts.PipelineDepth
+	if depth <= 0 {
+		return func(yield func(fetchedBlob) bool) {
+			for _, digest := range digests {
+				content, err := cp.fetchBlob(ctx, digest)
+				if !yield(fetchedBlob{digest: digest, content: content, err: err}) || err != nil {
+					return
+				}
+			}
+		}
+	}
+
+	return func(yield func(fetchedBlob) bool) {
+		ctx, cancel := context.WithCancel(ctx)
+		// The blob blocked on the send is the last one ahead, so the buffer holds one less
+		fetched := make(chan fetchedBlob, depth-1)
+		defer func() {
+			// Wait for the fetch stage to stop, so no request outlives the copy
+			cancel()
+			for range fetched {
+			}
+		}()
+
+		go func() {
+			defer close(fetched)
+			for _, digest := range digests {
+				content, err := cp.fetchBlob(ctx, digest)
+				select {
+				case fetched <- fetchedBlob{digest: digest, content: content, err: err}:
+				case <-ctx.Done():
+					return
+				}
+				if err != nil {
+					return
+				}
+			}
+		}()
+
+		for blob := range fetched {
+			if !yield(blob) {
+				return
+			}
+		}
+	}
+}
+
+// fetchBlob fetches a blob from the source and verifies it when asked to
+func (cp *imageCopy) fetchBlob(ctx context.Context, digest string) ([]byte, error) {
+	blob, err := cp.src.GetBlob(ctx, cp.srcRepository, digest)
+	if err != nil {
+		return nil, err
+	}
+	if cp.opts.Verify {
+		if err := VerifyDigest(blob.Content, digest); err != nil {
+			return nil, fmt.Errorf("copy image failed: blob %s of %s: %w", digest, cp.srcRepository, err)
+		}
+	}
+	return blob.Content, nil
+}
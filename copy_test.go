@@ -0,0 +1,137 @@
+package registryclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCopyImage(t *testing.T) {
+	src := newFakeRegistry(t)
+	amd64 := src.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("shared"), []byte("amd64")})
+	arm64 := src.addImage(t, ConfigBlob{Architecture: "arm64", OS: "linux"}, [][]byte{[]byte("shared"), []byte("arm64")})
+	index := src.addIndex(t, map[string]Platform{
+		amd64: {OS: "linux", Architecture: "amd64"},
+		arm64: {OS: "linux", Architecture: "arm64"},
+	}, "latest")
+
+	dst := newFakeRegistry(t)
+	existing := dst.addBlob([]byte("shared"))
+
+	digest, err := src.client().CopyImage(context.Background(), "app", "latest", dst.client(), "mirror", "v1", CopyOptions{Verify: true})
+	require.NoError(t, err)
+	assert.Equal(t, index, digest)
+
+	assert.Equal(t, src.manifests[index], dst.manifests["v1"], "manifests are pushed byte for byte")
+	assert.Equal(t, src.manifests[amd64], dst.manifests[amd64])
+	assert.Equal(t, src.manifests[arm64], dst.manifests[arm64])
+	assert.Equal(t, src.blobs, dst.blobs)
+	assert.Zero(t, src.requestCount(http.MethodGet, "/blobs/"+existing), "blobs the destination has are not fetched")
+	assert.Equal(t, 1, dst.requestCount(http.MethodHead, "/blobs/"+existing), "a blob shared by children is checked once")
+}
+
+func TestCopyImage_Verify(t *testing.T) {
+	tests := []struct {
+		name   string
+		verify bool
+	}{
+		{name: "verified", verify: true},
+		{name: "not verified", verify: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newFakeRegistry(t)
+			layer := []byte("layer")
+			src.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{layer}, "latest")
+			src.blobs[sha256Digest(layer)] = []byte("corrupt")
+			dst := newFakeRegistry(t)
+
+			_, err := src.client().CopyImage(context.Background(), "app", "latest", dst.client(), "mirror", "latest", CopyOptions{Verify: tt.verify})
+
+			require.Error(t, err)
+			assert.Empty(t, dst.manifests, "no manifest is pushed after a failed blob")
+			if tt.verify {
+				require.ErrorIs(t, err, ErrDigestMismatch)
+				assert.Contains(t, err.Error(), sha256Digest(layer))
+				assert.NotContains(t, dst.blobs, sha256Digest([]byte("corrupt")))
+				return
+			}
+			// Without verification the corrupt content is only caught by the destination
+			require.NotErrorIs(t, err, ErrDigestMismatch)
+			assert.Contains(t, err.Error(), "commit upload failed")
+		})
+	}
+}
+
+func TestCopyImage_PipelineDepth(t *testing.T) {
+	tests := []struct {
+		name        string
+		depth       int
+		wantOverlap bool
+	}{
+		{name: "sequential", depth: 0, wantOverlap: false},
+		{name: "pipelined", depth: 1, wantOverlap: true},
+		{name: "deep pipeline", depth: 4, wantOverlap: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := newFakeRegistry(t)
+			src.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("one"), []byte("two")}, "latest")
+			dst := newFakeRegistry(t)
+
+			// The first push waits for the second blob fetch, which only comes while it is
+			// in progress when fetching runs ahead of pushing
+			var fetches atomic.Int32
+			secondFetch := make(chan struct{})
+			srcHandler := src.server.Config.Handler
+			src.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodGet && strings.Contains(r.URL.Path, "/blobs/") && fetches.Add(1) == 2 {
+					close(secondFetch)
+				}
+				srcHandler.ServeHTTP(w, r)
+			})
+			var pushes atomic.Int32
+			var overlapped atomic.Bool
+			dstHandler := dst.server.Config.Handler
+			dst.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodPost && pushes.Add(1) == 1 {
+					select {
+					case <-secondFetch:
+						overlapped.Store(true)
+					case <-time.After(200 * time.Millisecond):
+					}
+				}
+				dstHandler.ServeHTTP(w, r)
+			})
+
+			_, err := src.client().CopyImage(context.Background(), "app", "latest", dst.client(), "mirror", "latest", CopyOptions{Verify: true, PipelineDepth: tt.depth})
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantOverlap, overlapped.Load())
+			assert.Equal(t, src.blobs, dst.blobs)
+			assert.Equal(t, int32(3), fetches.Load(), "every blob is fetched once")
+		})
+	}
+}
+
+func TestCopyImage_PipelineDepthError(t *testing.T) {
+	src := newFakeRegistry(t)
+	layer := []byte("one")
+	src.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{layer, []byte("two"), []byte("three")}, "latest")
+	src.blobs[sha256Digest(layer)] = []byte("corrupt")
+	dst := newFakeRegistry(t)
+
+	_, err := src.client().CopyImage(context.Background(), "app", "latest", dst.client(), "mirror", "latest", CopyOptions{Verify: true, PipelineDepth: 2})
+
+	require.ErrorIs(t, err, ErrDigestMismatch)
+	assert.Len(t, dst.blobs, 1, "only the config preceding the corrupt layer is pushed")
+	assert.Zero(t, src.requestCount(http.MethodGet, "/blobs/"+sha256Digest([]byte("three"))), "fetching stops at the first error")
+}
@@ -0,0 +1,146 @@
+package registryclient
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash"
+	"io"
+	"strings"
+)
+
+// ErrDigestMismatch is returned when content does not hash to its expected digest
+var ErrDigestMismatch = errors.New("digest mismatch")
+
+// newDigestHasher returns a hash for the algorithm prefix of a digest (e.g. "sha256:...")
+func newDigestHasher(digest string) (hash.Hash, error) {
+	algorithm, _, ok := strings.Cut(digest, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid digest: %s", digest)
+	}
+
+	switch algorithm {
+	case "sha256":
+		return sha256.New(), nil
+	case "sha512":
+		return sha512.New(), nil
+	default:
+		return nil, fmt.Errorf("unsupported digest algorithm: %s", algorithm)
+	}
+}
+
+// IsDigest reports whether reference is a sha256 or sha512 digest, i.e. the algorithm,
+// a colon and the lowercase hex encoding of a hash of that algorithm's length.
+// Anything else, including a malformed digest such as "sha256:abc", is treated as a tag.
+func IsDigest(reference string) bool {
+	h, err := newDigestHasher(reference)
+	if err != nil {
+		return false
+	}
+	_, encoded, _ := strings.Cut(reference, ":")
+	return len(encoded) == 2*h.Size() && strings.Trim(encoded, "0123456789abcdef") == ""
+}
+
+// formatDigest renders a finished hash as "<algorithm>:<hex>" using the algorithm of digest
+func formatDigest(digest string, h hash.Hash) string {
+	algorithm, _, _ := strings.Cut(digest, ":")
+	return algorithm + ":" + hex.EncodeToString(h.Sum(nil))
+}
+
+// VerifyDigest checks that content hashes to the expected digest.
+// Returns an error wrapping ErrDigestMismatch when the content does not match.
+func VerifyDigest(content []byte, digest string) error {
+	h, err := newDigestHasher(digest)
+	if err != nil {
+		return err
+	}
+	h.Write(content)
+
+	if actual := formatDigest(digest, h); actual != digest {
+		return fmt.Errorf("%w: expected %s, got %s", ErrDigestMismatch, digest, actual)
+	}
+	return nil
+}
+
+// canonicalDigest returns the sha256 digest of content, as registries compute it by default
+func canonicalDigest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+// ComputeDescriptorDigest returns the sha256 digest and byte length of raw, the two values
+// a descriptor needs to reference it. Registries digest the exact bytes pushed, so raw must
+// be the serialized manifest as it will be uploaded, not a re-encoding of it.
+func ComputeDescriptorDigest(raw []byte) (digest string, size int64) {
+	return canonicalDigest(raw), int64(len(raw))
+}
+
+// NewManifestReference returns the index entry for the manifest raw, with its digest and
+// size computed by ComputeDescriptorDigest
+func NewManifestReference(raw []byte, mediaType string, platform Platform) ManifestReference {
+	digest, size := ComputeDescriptorDigest(raw)
+	return ManifestReference{MediaType: mediaType, Digest: digest, Size: size, Platform: platform}
+}
+
+// computeDigest computes the digest of a manifest with DigestFunc, or canonicalDigest when unset
+func (c *BaseClient) computeDigest(content []byte) string {
+	if c.DigestFunc != nil {
+		return c.DigestFunc(content)
+	}
+	return canonicalDigest(content)
+}
+
+// DigestVerifyingReader hashes the content read through it and reports ErrDigestMismatch
+// from the final Read (instead of io.EOF) and from Close when the content does not match
+// the expected digest. Content that was not read to the end is not verified.
+type DigestVerifyingReader struct {
+	r      io.ReadCloser
+	digest string
+	hasher hash.Hash
+	done   bool  // whether the underlying reader returned io.EOF
+	err    error // verification result once done
+}
+
+// NewDigestVerifyingReader wraps r to verify it against digest.
+// Fails when the digest algorithm is not supported.
+func NewDigestVerifyingReader(r io.ReadCloser, digest string) (*DigestVerifyingReader, error) {
+	hasher, err := newDigestHasher(digest)
+	if err != nil {
+		return nil, err
+	}
+	return &DigestVerifyingReader{r: r, digest: digest, hasher: hasher}, nil
+}
+
+// Read reads from the underlying reader, verifying the digest when it reaches io.EOF
+func (v *DigestVerifyingReader) Read(p []byte) (int, error) {
+	n, err := v.r.Read(p)
+	v.hasher.Write(p[:n])
+	if err == io.EOF {
+		if verifyErr := v.verify(); verifyErr != nil {
+			return n, verifyErr
+		}
+	}
+	return n, err
+}
+
+// Close closes the underlying reader and returns the verification error, if any
+func (v *DigestVerifyingReader) Close() error {
+	closeErr := v.r.Close()
+	if v.done && v.err != nil {
+		return v.err
+	}
+	return closeErr
+}
+
+// verify compares the hash of everything read with the expected digest, once
+func (v *DigestVerifyingReader) verify() error {
+	if !v.done {
+		v.done = true
+		if actual := formatDigest(v.digest, v.hasher); actual != v.digest {
+			v.err = fmt.Errorf("%w: expected %s, got %s", ErrDigestMismatch, v.digest, actual)
+		}
+	}
+	return v.err
+}
@@ -0,0 +1,224 @@
+package registryclient
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path"
+	"strings"
+	"testing"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func sha256Digest(content []byte) string {
+	sum := sha256.Sum256(content)
+	return "sha256:" + hex.EncodeToString(sum[:])
+}
+
+func TestVerifyDigest(t *testing.T) {
+	content := []byte("layer content")
+	sum512 := sha512.Sum512(content)
+
+	tests := []struct {
+		name         string
+		content      []byte
+		digest       string
+		wantErr      bool
+		wantMismatch bool
+	}{
+		{name: "sha256 match", content: content, digest: sha256Digest(content)},
+		{name: "sha512 match", content: content, digest: "sha512:" + hex.EncodeToString(sum512[:])},
+		{name: "corrupt blob", content: []byte("corrupted content"), digest: sha256Digest(content), wantErr: true, wantMismatch: true},
+		{name: "missing algorithm", content: content, digest: "abc123", wantErr: true},
+		{name: "unsupported algorithm", content: content, digest: "md5:abc123", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := VerifyDigest(tt.content, tt.digest)
+			if !tt.wantErr {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Equal(t, tt.wantMismatch, errors.Is(err, ErrDigestMismatch))
+		})
+	}
+}
+
+func TestIsDigest(t *testing.T) {
+	content := []byte("layer content")
+	sha256Hex := sha256Digest(content)[len("sha256:"):]
+	sum512 := sha512.Sum512(content)
+
+	tests := []struct {
+		name      string
+		reference string
+		want      bool
+	}{
+		{name: "sha256", reference: "sha256:" + sha256Hex, want: true},
+		{name: "sha512", reference: "sha512:" + hex.EncodeToString(sum512[:]), want: true},
+		{name: "tag", reference: "v1.2.3"},
+		{name: "empty", reference: ""},
+		{name: "too short", reference: "sha256:notlongenough"},
+		{name: "sha512 length mismatch", reference: "sha512:" + sha256Hex},
+		{name: "uppercase hex", reference: "sha256:" + strings.ToUpper(sha256Hex)},
+		{name: "non-hex", reference: "sha256:" + strings.Repeat("z", 64)},
+		{name: "unsupported algorithm", reference: "md5:" + sha256Hex[:32]},
+		{name: "missing algorithm", reference: sha256Hex},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.want, IsDigest(tt.reference))
+		})
+	}
+}
+
+func TestClient_ComputeDigest(t *testing.T) {
+	content := []byte(`{"schemaVersion":2}` + "\n")
+	trimmed := func(b []byte) string { return sha256Digest(bytes.TrimSpace(b)) }
+
+	assert.Equal(t, sha256Digest(content), (&BaseClient{}).computeDigest(content))
+	assert.Equal(t, sha256Digest([]byte(`{"schemaVersion":2}`)), (&BaseClient{DigestFunc: trimmed}).computeDigest(content))
+}
+
+func TestComputeDescriptorDigest(t *testing.T) {
+	tests := []struct {
+		file   string
+		digest string
+		size   int64
+	}{
+		{"oci-image-index.json", "sha256:a69ca6c919fb9912ceee31d3eb0582b67a17413b44346fa28e1193e471bf2ac0", 446},
+		{"oci-image-manifest.json", "sha256:322cc753fbe8fa7d2290b25e9defd41574e9795bd21fe6da1ab790119d91c891", 258},
+		{"docker-manifest-list.json", "sha256:e50b883cd973206006815c6405aea279d469639b120db61d384738352a5676bb", 306},
+		{"windows-image-index.json", "sha256:4b06defea4fca2eaead04db309c227b24b5e8f7bc559e89e0c9a83466debf35c", 767},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			raw, err := os.ReadFile("testdata/manifests/" + tt.file)
+			require.NoError(t, err)
+
+			digest, size := ComputeDescriptorDigest(raw)
+			assert.Equal(t, tt.digest, digest)
+			assert.Equal(t, tt.size, size)
+			assert.NoError(t, VerifyDigest(raw, digest))
+		})
+	}
+
+	digest, size := ComputeDescriptorDigest(nil)
+	assert.Equal(t, "sha256:e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855", digest)
+	assert.Zero(t, size)
+}
+
+func TestNewManifestReference(t *testing.T) {
+	registry := newFakeRegistry(t)
+	amd64 := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("amd64")})
+	arm64 := registry.addImage(t, ConfigBlob{Architecture: "arm64", OS: "linux"}, [][]byte{[]byte("arm64")})
+
+	manifests := []ManifestReference{
+		NewManifestReference(registry.manifests[amd64], ociManifestMediaType, Platform{OS: "linux", Architecture: "amd64"}),
+		NewManifestReference(registry.manifests[arm64], ociManifestMediaType, Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}),
+	}
+	assert.Equal(t, amd64, manifests[0].Digest)
+	assert.Equal(t, int64(len(registry.manifests[amd64])), manifests[0].Size)
+
+	raw, err := json.Marshal(map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     ociIndexMediaType,
+		"manifests":     manifests,
+	})
+	require.NoError(t, err)
+	client := registry.client()
+	pushed, err := client.PutManifest(context.Background(), "app", "latest", ociIndexMediaType, raw)
+	require.NoError(t, err)
+
+	digest, _ := ComputeDescriptorDigest(raw)
+	assert.Equal(t, digest, pushed.Digest, "the local digest matches the one the registry computed")
+
+	for _, entry := range manifests {
+		child, err := client.GetManifest(context.Background(), "app", entry.Digest)
+		require.NoError(t, err)
+		assert.Equal(t, entry.Size, int64(len(child.RawContent)))
+	}
+}
+
+func TestClient_DigestFunc_Fallback(t *testing.T) {
+	// A registry that reports no Docker-Content-Digest and digests manifests without the trailing newline
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`)
+	wantDigest := sha256Digest(manifest)
+
+	var deleted []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch {
+		case r.URL.Path == "/v2/app/tags/list":
+			_, _ = w.Write([]byte(`{"name":"app","tags":["v1"]}`))
+		case r.Method == http.MethodDelete:
+			deleted = append(deleted, path.Base(r.URL.Path))
+			w.WriteHeader(http.StatusAccepted)
+		default:
+			w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+			_, _ = w.Write(append(manifest, '\n'))
+		}
+	}))
+	defer server.Close()
+
+	client := &BaseClient{
+		HTTPClient: &http.Client{},
+		BaseURL:    server.URL,
+		DigestFunc: func(b []byte) string { return sha256Digest(bytes.TrimSpace(b)) },
+	}
+
+	got, err := client.PruneTags(context.Background(), "app", 0, ".*")
+	require.NoError(t, err)
+	assert.Equal(t, []string{wantDigest}, got)
+	assert.Equal(t, []string{wantDigest}, deleted)
+}
+
+func TestDigestVerifyingReader(t *testing.T) {
+	content := []byte("streamed layer content")
+
+	t.Run("match", func(t *testing.T) {
+		r, err := NewDigestVerifyingReader(io.NopCloser(bytes.NewReader(content)), sha256Digest(content))
+		require.NoError(t, err)
+
+		got, err := io.ReadAll(r)
+		require.NoError(t, err)
+		assert.Equal(t, content, got)
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("mismatch", func(t *testing.T) {
+		r, err := NewDigestVerifyingReader(io.NopCloser(bytes.NewReader([]byte("corrupted"))), sha256Digest(content))
+		require.NoError(t, err)
+
+		_, err = io.ReadAll(r)
+		require.ErrorIs(t, err, ErrDigestMismatch)
+		require.ErrorIs(t, r.Close(), ErrDigestMismatch)
+	})
+
+	t.Run("partial read is not verified", func(t *testing.T) {
+		r, err := NewDigestVerifyingReader(io.NopCloser(bytes.NewReader([]byte("corrupted"))), sha256Digest(content))
+		require.NoError(t, err)
+
+		_, err = r.Read(make([]byte, 3))
+		require.NoError(t, err)
+		require.NoError(t, r.Close())
+	})
+
+	t.Run("unsupported algorithm", func(t *testing.T) {
+		_, err := NewDigestVerifyingReader(io.NopCloser(bytes.NewReader(content)), "md5:abc")
+		require.Error(t, err)
+	})
+}
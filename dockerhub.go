@@ -0,0 +1,84 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+)
+
+// DockerHubClient extends BaseClient with the Docker Hub web API (hub.docker.com),
+// which exposes tag metadata such as last update times that the registry API lacks.
+type DockerHubClient struct {
+	*BaseClient
+	HubURL string // Docker Hub API base URL
+}
+
+func NewDockerHubClient() *DockerHubClient {
+	return &DockerHubClient{
+		BaseClient: &BaseClient{
+			HTTPClient: &http.Client{},
+			BaseURL:    "https://registry-1.docker.io",
+		},
+		HubURL: "https://hub.docker.com",
+	}
+}
+
+// dockerHubMaxPageSize is the largest page_size the Docker Hub API accepts
+const dockerHubMaxPageSize = 100
+
+// ListTagsWithDates lists all tags of a repository ordered by most recently updated first.
+// Official images must be addressed with their "library/" namespace (e.g. "library/nginx").
+func (dc *DockerHubClient) ListTagsWithDates(ctx context.Context, repository string) ([]TagInfo, error) {
+	pageSize := dc.pageSize(dockerHubMaxPageSize, dockerHubMaxPageSize)
+	apiURL := fmt.Sprintf("%s/v2/repositories/%s/tags/?ordering=last_updated&page_size=%d", dc.HubURL, repository, pageSize)
+
+	var tags []TagInfo
+	for pageCount := 1; apiURL != ""; pageCount++ {
+		if err := dc.checkPageLimit("ListTagsWithDates", pageCount); err != nil {
+			return nil, err
+		}
+
+		page, err := dc.getTagsPage(ctx, repository, apiURL)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, page.Results...)
+		apiURL = page.Next
+	}
+
+	return tags, nil
+}
+
+func (dc *DockerHubClient) getTagsPage(ctx context.Context, repository, apiURL string) (*dockerHubTagsPage, error) {
+	dc.logDebug("Docker Hub API request", "operation", "ListTagsWithDates", "method", http.MethodGet, "repository", repository, "url", apiURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+
+	// Use doWithoutAuth: the hub API is separate from the registry and
+	// must not receive the registry credentials
+	resp, err := dc.doWithoutAuth(req)
+	if err != nil {
+		return nil, err
+	}
+	defer dc.closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("list docker hub tags failed: %s - %s", resp.Status, string(body))
+	}
+
+	var page dockerHubTagsPage
+	if err := json.NewDecoder(resp.Body).Decode(&page); err != nil {
+		return nil, err
+	}
+
+	dc.logDebug("Docker Hub API response", "operation", "ListTagsWithDates", "repository", repository, "tag_count", len(page.Results), "has_more", page.Next != "")
+	return &page, nil
+}
@@ -0,0 +1,149 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewDockerHubClient(t *testing.T) {
+	client := NewDockerHubClient()
+
+	require.NotNil(t, client)
+	assert.Equal(t, "https://registry-1.docker.io", client.BaseURL)
+	assert.Equal(t, "https://hub.docker.com", client.HubURL)
+}
+
+func TestDockerHubClient_ListTagsWithDates(t *testing.T) {
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/v2/repositories/library/nginx/tags/", r.URL.Path)
+		assert.Equal(t, "last_updated", r.URL.Query().Get("ordering"))
+		assert.Empty(t, r.Header.Get("Authorization"), "registry auth must not leak to the hub API")
+
+		if r.URL.Query().Get("page") == "2" {
+			_, _ = w.Write([]byte(`{"next":null,"results":[{"name":"1.25","digest":"sha256:bbb","last_updated":"2024-01-01T00:00:00Z"}]}`))
+			return
+		}
+		next := fmt.Sprintf("%s/v2/repositories/library/nginx/tags/?ordering=last_updated&page=2", server.URL)
+		fmt.Fprintf(w, `{"next":%q,"results":[{"name":"latest","digest":"sha256:aaa","last_updated":"2024-03-02T10:00:00.123456Z"}]}`, next)
+	}))
+	defer server.Close()
+
+	client := NewDockerHubClient()
+	client.HubURL = server.URL
+	client.Auth = BasicAuth{Username: "user", Password: "pass"}
+
+	tags, err := client.ListTagsWithDates(context.Background(), "library/nginx")
+	require.NoError(t, err)
+	require.Len(t, tags, 2)
+
+	assert.Equal(t, "latest", tags[0].Name)
+	assert.Equal(t, "sha256:aaa", tags[0].Digest)
+	assert.Equal(t, time.Date(2024, 3, 2, 10, 0, 0, 123456000, time.UTC), tags[0].LastUpdated)
+	assert.Equal(t, "1.25", tags[1].Name)
+}
+
+func TestDockerHubClient_ListTagsWithDates_AllowedHosts(t *testing.T) {
+	var requests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests.Add(1)
+	}))
+	defer server.Close()
+
+	client := NewDockerHubClient()
+	client.HubURL = server.URL
+	client.AllowedHosts = []string{"registry-1.docker.io"}
+
+	_, err := client.ListTagsWithDates(context.Background(), "library/nginx")
+	require.ErrorIs(t, err, ErrHostNotAllowed)
+	assert.Zero(t, requests.Load())
+}
+
+func TestDockerHubClient_ListTagsWithDates_PaginationLoop(t *testing.T) {
+	var requests int
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		fmt.Fprintf(w, `{"next":%q,"results":[{"name":"latest"}]}`, server.URL+r.URL.String())
+	}))
+	defer server.Close()
+
+	client := NewDockerHubClient()
+	client.HubURL = server.URL
+	client.MaxPages = 3
+
+	tags, err := client.ListTagsWithDates(context.Background(), "library/nginx")
+	require.ErrorIs(t, err, ErrTooManyPages)
+	assert.Nil(t, tags)
+	assert.Equal(t, 3, requests)
+}
+
+func TestDockerHubClient_ListTagsWithDates_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		wantErr string
+	}{
+		{name: "not found", status: http.StatusNotFound, body: `{"message":"object not found"}`, wantErr: "list docker hub tags failed"},
+		{name: "invalid JSON", status: http.StatusOK, body: `{invalid`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewDockerHubClient()
+			client.HubURL = server.URL
+
+			tags, err := client.ListTagsWithDates(context.Background(), "library/nginx")
+			require.Error(t, err)
+			assert.Nil(t, tags)
+			if tt.wantErr != "" {
+				assert.Contains(t, err.Error(), tt.wantErr)
+			}
+		})
+	}
+}
+
+func TestDockerHubClient_ListTagsWithDates_InvalidURL(t *testing.T) {
+	client := NewDockerHubClient()
+	client.HubURL = "://invalid"
+
+	tags, err := client.ListTagsWithDates(context.Background(), "library/nginx")
+	require.Error(t, err)
+	assert.Nil(t, tags)
+}
+
+func TestDockerHubClient_ListTagsWithDates_DefaultPageSize(t *testing.T) {
+	var pageSize string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		pageSize = r.URL.Query().Get("page_size")
+		_, _ = w.Write([]byte(`{"next":null,"results":[]}`))
+	}))
+	defer server.Close()
+
+	client := NewDockerHubClient()
+	client.HubURL = server.URL
+
+	_, err := client.ListTagsWithDates(context.Background(), "library/nginx")
+	require.NoError(t, err)
+	assert.Equal(t, "100", pageSize)
+
+	client.DefaultPageSize = 25
+	_, err = client.ListTagsWithDates(context.Background(), "library/nginx")
+	require.NoError(t, err)
+	assert.Equal(t, "25", pageSize)
+}
@@ -0,0 +1,317 @@
+package registryclient
+
+import (
+	"bytes"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+	"github.com/stretchr/testify/require"
+)
+
+// fakeRegistry is an in-memory registry serving manifests, blobs and tag lists for tests
+type fakeRegistry struct {
+	mu        sync.Mutex
+	manifests map[string][]byte // keyed by tag or digest
+	blobs     map[string][]byte // keyed by digest
+	uploads   map[string][]byte // in-progress uploads keyed by session ID
+	requests  []string          // "METHOD path" of every request received
+	server    *httptest.Server
+}
+
+func newFakeRegistry(t *testing.T) *fakeRegistry {
+	t.Helper()
+	r := &fakeRegistry{manifests: map[string][]byte{}, blobs: map[string][]byte{}, uploads: map[string][]byte{}}
+	r.server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+func (r *fakeRegistry) client() *BaseClient {
+	return &BaseClient{HTTPClient: &http.Client{}, BaseURL: r.server.URL}
+}
+
+// addBlob stores content and returns its digest
+func (r *fakeRegistry) addBlob(content []byte) string {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	digest := sha256Digest(content)
+	r.blobs[digest] = content
+	return digest
+}
+
+// addManifest stores the JSON encoding of manifest under its digest and each tag
+func (r *fakeRegistry) addManifest(t *testing.T, manifest any, tags ...string) string {
+	t.Helper()
+	body, err := json.Marshal(manifest)
+	require.NoError(t, err)
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	digest := sha256Digest(body)
+	r.manifests[digest] = body
+	for _, tag := range tags {
+		r.manifests[tag] = body
+	}
+	return digest
+}
+
+func (r *fakeRegistry) requestCount(method, pathSuffix string) int {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	count := 0
+	for _, req := range r.requests {
+		if strings.HasPrefix(req, method+" ") && strings.HasSuffix(req, pathSuffix) {
+			count++
+		}
+	}
+	return count
+}
+
+// trackInFlight slows down manifest requests and returns the peak number of them
+// the registry was serving at once
+func (r *fakeRegistry) trackInFlight() *atomic.Int32 {
+	var inFlight, peak atomic.Int32
+	handler := r.server.Config.Handler
+	r.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, req *http.Request) {
+		if strings.Contains(req.URL.Path, "/manifests/") {
+			n := inFlight.Add(1)
+			defer inFlight.Add(-1)
+			for p := peak.Load(); n > p && !peak.CompareAndSwap(p, n); p = peak.Load() {
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		handler.ServeHTTP(w, req)
+	})
+	return &peak
+}
+
+func (r *fakeRegistry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.requests = append(r.requests, req.Method+" "+req.URL.Path)
+
+	path := req.URL.Path
+	switch {
+	case strings.Contains(path, "/blobs/uploads/"):
+		r.serveUpload(w, req)
+	case strings.HasSuffix(path, "/tags/list"):
+		r.serveTags(w, strings.TrimSuffix(strings.TrimPrefix(path, "/v2/"), "/tags/list"))
+	case strings.Contains(path, "/referrers/"):
+		r.serveReferrers(w, path[strings.LastIndex(path, "/referrers/")+len("/referrers/"):])
+	case strings.Contains(path, "/manifests/"):
+		r.serveManifest(w, req, path[strings.LastIndex(path, "/manifests/")+len("/manifests/"):])
+	case strings.Contains(path, "/blobs/"):
+		body, ok := r.blobs[path[strings.LastIndex(path, "/blobs/")+len("/blobs/"):]]
+		r.serveContent(w, req, body, ok, "application/octet-stream")
+	default:
+		w.WriteHeader(http.StatusNotFound)
+	}
+}
+
+func (r *fakeRegistry) serveManifest(w http.ResponseWriter, req *http.Request, reference string) {
+	body, ok := r.manifests[reference]
+	switch req.Method {
+	case http.MethodDelete:
+		r.deleteManifest(w, reference, ok)
+		return
+	case http.MethodPut:
+		r.putManifest(w, req, reference)
+		return
+	}
+	var m Manifest
+	if ok {
+		_ = json.Unmarshal(body, &m)
+	}
+	r.serveContent(w, req, body, ok, m.MediaType)
+}
+
+// putManifest stores a pushed manifest under its digest and, for a tag, under the tag
+func (r *fakeRegistry) putManifest(w http.ResponseWriter, req *http.Request, reference string) {
+	body, _ := io.ReadAll(req.Body)
+	digest := sha256Digest(body)
+	if strings.Contains(reference, ":") && reference != digest {
+		w.WriteHeader(http.StatusBadRequest)
+		_, _ = w.Write([]byte(`{"errors":[{"code":"DIGEST_INVALID"}]}`))
+		return
+	}
+	r.manifests[digest] = body
+	r.manifests[reference] = body
+	w.Header().Set("Docker-Content-Digest", digest)
+	w.WriteHeader(http.StatusCreated)
+}
+
+// serveReferrers lists the manifests whose subject is digest as an OCI index
+func (r *fakeRegistry) serveReferrers(w http.ResponseWriter, digest string) {
+	referrers := []Descriptor{}
+	for ref, body := range r.manifests {
+		var m artifactManifest
+		if !strings.Contains(ref, ":") || json.Unmarshal(body, &m) != nil || m.Subject == nil || m.Subject.Digest != digest {
+			continue
+		}
+		referrers = append(referrers, Descriptor{MediaType: m.MediaType, Digest: ref, Size: int64(len(body)), ArtifactType: m.ArtifactType})
+	}
+	w.Header().Set("Content-Type", ociIndexMediaType)
+	_ = json.NewEncoder(w).Encode(map[string]any{"schemaVersion": 2, "mediaType": ociIndexMediaType, "manifests": referrers})
+}
+
+// deleteManifest removes a manifest and its tags; like a real registry, only digests are accepted
+func (r *fakeRegistry) deleteManifest(w http.ResponseWriter, reference string, ok bool) {
+	switch {
+	case !strings.Contains(reference, ":"):
+		w.WriteHeader(http.StatusBadRequest)
+	case !ok:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		body := r.manifests[reference]
+		for ref, b := range r.manifests {
+			if bytes.Equal(b, body) {
+				delete(r.manifests, ref)
+			}
+		}
+		w.WriteHeader(http.StatusAccepted)
+	}
+}
+
+func (r *fakeRegistry) serveTags(w http.ResponseWriter, repository string) {
+	tags := []string{}
+	for ref := range r.manifests {
+		if !strings.Contains(ref, ":") {
+			tags = append(tags, ref)
+		}
+	}
+	slices.Sort(tags)
+	_ = json.NewEncoder(w).Encode(map[string]any{"name": repository, "tags": tags})
+}
+
+func (r *fakeRegistry) serveContent(w http.ResponseWriter, req *http.Request, body []byte, ok bool, mediaType string) {
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+	w.Header().Set("Content-Type", mediaType)
+	w.Header().Set("Docker-Content-Digest", sha256Digest(body))
+	w.Header().Set("Content-Length", strconv.Itoa(len(body)))
+	if req.Method != http.MethodHead {
+		_, _ = w.Write(body)
+	}
+}
+
+// addImage stores a config blob, layer blobs and an image manifest referencing them
+func (r *fakeRegistry) addImage(t *testing.T, config ConfigBlob, layers [][]byte, tags ...string) string {
+	t.Helper()
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+
+	manifestLayers := make([]map[string]any, 0, len(layers))
+	for _, layer := range layers {
+		manifestLayers = append(manifestLayers, map[string]any{
+			"mediaType": "application/vnd.oci.image.layer.v1.tar+gzip",
+			"digest":    r.addBlob(layer),
+			"size":      len(layer),
+		})
+	}
+
+	return r.addManifest(t, map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config": map[string]any{
+			"mediaType": "application/vnd.oci.image.config.v1+json",
+			"digest":    r.addBlob(configJSON),
+			"size":      len(configJSON),
+		},
+		"layers": manifestLayers,
+	}, tags...)
+}
+
+// addIndex stores an image index referencing the given platform manifests
+func (r *fakeRegistry) addIndex(t *testing.T, children map[string]Platform, tags ...string) string {
+	t.Helper()
+	digests := make([]string, 0, len(children))
+	for digest := range children {
+		digests = append(digests, digest)
+	}
+	slices.Sort(digests)
+
+	manifests := make([]map[string]any, 0, len(children))
+	for _, digest := range digests {
+		manifests = append(manifests, map[string]any{
+			"mediaType": "application/vnd.oci.image.manifest.v1+json",
+			"digest":    digest,
+			"size":      len(r.manifests[digest]),
+			"platform":  children[digest],
+		})
+	}
+
+	return r.addManifest(t, map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.index.v1+json",
+		"manifests":     manifests,
+	}, tags...)
+}
+
+// serveUpload implements the blob upload session endpoints (POST, PATCH, GET, PUT, DELETE)
+func (r *fakeRegistry) serveUpload(w http.ResponseWriter, req *http.Request) {
+	prefix := req.URL.Path[:strings.Index(req.URL.Path, "/blobs/uploads/")+len("/blobs/uploads/")]
+	id := strings.TrimPrefix(req.URL.Path, prefix)
+
+	if req.Method == http.MethodPost {
+		id = fmt.Sprintf("session-%d", len(r.uploads)+1)
+		r.uploads[id] = nil
+		r.writeUploadStatus(w, prefix, id, http.StatusAccepted)
+		return
+	}
+
+	data, ok := r.uploads[id]
+	if !ok {
+		w.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	switch req.Method {
+	case http.MethodPatch:
+		if start, _, _ := strings.Cut(req.Header.Get("Content-Range"), "-"); start != strconv.Itoa(len(data)) {
+			w.WriteHeader(http.StatusRequestedRangeNotSatisfiable)
+			return
+		}
+		chunk, _ := io.ReadAll(req.Body)
+		r.uploads[id] = append(data, chunk...)
+		r.writeUploadStatus(w, prefix, id, http.StatusAccepted)
+	case http.MethodGet:
+		r.writeUploadStatus(w, prefix, id, http.StatusNoContent)
+	case http.MethodPut:
+		chunk, _ := io.ReadAll(req.Body)
+		data = append(data, chunk...)
+		digest := req.URL.Query().Get("digest")
+		if sha256Digest(data) != digest {
+			w.WriteHeader(http.StatusBadRequest)
+			_, _ = w.Write([]byte(`{"errors":[{"code":"DIGEST_INVALID"}]}`))
+			return
+		}
+		delete(r.uploads, id)
+		r.blobs[digest] = data
+		w.Header().Set("Docker-Content-Digest", digest)
+		w.WriteHeader(http.StatusCreated)
+	case http.MethodDelete:
+		delete(r.uploads, id)
+		w.WriteHeader(http.StatusNoContent)
+	default:
+		w.WriteHeader(http.StatusMethodNotAllowed)
+	}
+}
+
+func (r *fakeRegistry) writeUploadStatus(w http.ResponseWriter, prefix, id string, status int) {
+	w.Header().Set("Location", prefix+id+"?_state=opaque")
+	w.Header().Set("Docker-Upload-UUID", id)
+	w.Header().Set("Range", fmt.Sprintf("0-%d", max(len(r.uploads[id])-1, 0)))
+	w.WriteHeader(status)
+}
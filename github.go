@@ -3,12 +3,17 @@ package registryclient
 import (
 	"context"
 	"encoding/base64"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
 	"slices"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
 
 	json "github.com/eznix86/registry-client/jsoncompat"
 )
@@ -17,19 +22,68 @@ import (
 type GitHubClientType string
 
 const (
-	GitHubUser GitHubClientType = "user"
-	GitHubOrg  GitHubClientType = "org"
+	GitHubUser      GitHubClientType = "user"       // Packages of the token holder (/user)
+	GitHubOrg       GitHubClientType = "org"        // Packages of Organization (/orgs/{org})
+	GitHubNamedUser GitHubClientType = "named-user" // Packages of Username, another user than the token holder (/users/{username})
 )
 
+// PackageState filters GitHub package versions by state
+type PackageState string
+
+const (
+	PackageStateActive  PackageState = "active"
+	PackageStateDeleted PackageState = "deleted"
+)
+
+// PackageVisibility filters GitHub packages by visibility
+type PackageVisibility string
+
+const (
+	PackageVisibilityPublic   PackageVisibility = "public"
+	PackageVisibilityPrivate  PackageVisibility = "private"
+	PackageVisibilityInternal PackageVisibility = "internal" // Organization packages only
+)
+
+// validate checks that v is empty (any visibility) or a value the Packages API accepts
+func (v PackageVisibility) validate() error {
+	switch v {
+	case "", PackageVisibilityPublic, PackageVisibilityPrivate, PackageVisibilityInternal:
+		return nil
+	default:
+		return fmt.Errorf("invalid package visibility: %q", v)
+	}
+}
+
 type packagesAPI interface {
-	getUserPackages(ctx context.Context, pagination *PaginationParams) (*GitHubPackagesResponse, error)
-	getOrgPackages(ctx context.Context, org string, pagination *PaginationParams) (*GitHubPackagesResponse, error)
+	getUserPackages(ctx context.Context, visibility PackageVisibility, pagination *PaginationParams) (*GitHubPackagesResponse, error)
+	getOrgPackages(ctx context.Context, org string, visibility PackageVisibility, pagination *PaginationParams) (*GitHubPackagesResponse, error)
+	getNamedUserPackages(ctx context.Context, username string, visibility PackageVisibility, pagination *PaginationParams) (*GitHubPackagesResponse, error)
 }
 
 type githubPackagesAPI struct {
 	baseClient *BaseClient
 	apiToken   string
 	baseURL    string
+	apiVersion *string // GitHubClient.APIVersion, read on every request
+}
+
+// DefaultGitHubAPIVersion is the GitHub REST API version sent when GitHubClient.APIVersion is empty
+const DefaultGitHubAPIVersion = "2022-11-28"
+
+// githubAPIVersion returns version, or DefaultGitHubAPIVersion when it is empty
+func githubAPIVersion(version string) string {
+	if version == "" {
+		return DefaultGitHubAPIVersion
+	}
+	return version
+}
+
+// version returns the API version of the owning GitHubClient
+func (api *githubPackagesAPI) version() string {
+	if api.apiVersion == nil {
+		return DefaultGitHubAPIVersion
+	}
+	return githubAPIVersion(*api.apiVersion)
 }
 
 type GitHubClient struct {
@@ -38,9 +92,20 @@ type GitHubClient struct {
 	Username     string // GitHub username for user client
 	Organization string // GitHub organization for org client
 	APIToken     string
-	api          packagesAPI
+	PruneTagged  bool   // When true, PruneVersions also deletes versions that still have tags
+	APIVersion   string // X-GitHub-Api-Version sent to the GitHub API (defaults to DefaultGitHubAPIVersion)
+
+	// DeleteConcurrency is the number of package versions PruneVersions deletes at once
+	// (0 or 1 deletes one at a time). GitHub rate limits are honored either way.
+	DeleteConcurrency int
+
+	api       packagesAPI
+	rateLimit githubRateLimit // shared by concurrent deletions so they pause together
 }
 
+// githubAPIURL is the base URL of the GitHub REST API
+const githubAPIURL = "https://api.github.com"
+
 func NewGitHubClient(username, token string) *GitHubClient {
 	encodedToken := base64.StdEncoding.EncodeToString([]byte(token))
 	client := &BaseClient{
@@ -48,17 +113,20 @@ func NewGitHubClient(username, token string) *GitHubClient {
 		BaseURL:    "https://ghcr.io",
 		Auth:       BearerAuth{Token: encodedToken},
 	}
-	return &GitHubClient{
+	gc := &GitHubClient{
 		BaseClient: client,
 		Type:       GitHubUser,
 		Username:   username,
 		APIToken:   token,
-		api: &githubPackagesAPI{
-			baseClient: client,
-			apiToken:   token,
-			baseURL:    "https://api.github.com",
-		},
+		APIVersion: DefaultGitHubAPIVersion,
 	}
+	gc.api = &githubPackagesAPI{
+		baseClient: client,
+		apiToken:   token,
+		baseURL:    githubAPIURL,
+		apiVersion: &gc.APIVersion,
+	}
+	return gc
 }
 
 func NewGitHubOrgClient(org, token string) *GitHubClient {
@@ -68,62 +136,183 @@ func NewGitHubOrgClient(org, token string) *GitHubClient {
 		BaseURL:    "https://ghcr.io",
 		Auth:       BearerAuth{Token: encodedToken},
 	}
-	return &GitHubClient{
+	gc := &GitHubClient{
 		BaseClient:   client,
 		Type:         GitHubOrg,
 		Organization: org,
 		APIToken:     token,
-		api: &githubPackagesAPI{
-			baseClient: client,
-			apiToken:   token,
-			baseURL:    "https://api.github.com",
-		},
+		APIVersion:   DefaultGitHubAPIVersion,
+	}
+	gc.api = &githubPackagesAPI{
+		baseClient: client,
+		apiToken:   token,
+		baseURL:    githubAPIURL,
+		apiVersion: &gc.APIVersion,
 	}
+	return gc
 }
 
-func (gc *GitHubClient) GetCatalog(ctx context.Context, pagination *PaginationParams) (*CatalogResponse, error) {
-	var packagesResp *GitHubPackagesResponse
-	var err error
+// NewGitHubAutoClient returns a client for owner, which may be a user or an organization.
+// The owner type is looked up with the GitHub API (GET /users/{owner}). An organization
+// gets the client NewGitHubOrgClient would return. A user gets the client NewGitHubClient
+// would return when it is the token holder (GET /user), whose packages include private
+// ones; any other user gets a GitHubNamedUser client reading /users/{owner}.
+func NewGitHubAutoClient(ctx context.Context, owner, token string) (*GitHubClient, error) {
+	return newGitHubAutoClient(ctx, githubAPIURL, owner, token)
+}
 
-	if gc.Type == GitHubOrg {
-		packagesResp, err = gc.api.getOrgPackages(ctx, gc.Organization, pagination)
-	} else {
-		packagesResp, err = gc.api.getUserPackages(ctx, pagination)
+func newGitHubAutoClient(ctx context.Context, apiURL, owner, token string) (*GitHubClient, error) {
+	gc := NewGitHubClient(owner, token)
+	gc.api.(*githubPackagesAPI).baseURL = apiURL
+
+	account, err := gc.getGitHubAccount(ctx, apiURL, "/users/"+url.PathEscape(owner))
+	if err != nil {
+		return nil, fmt.Errorf("get github owner failed: %w", err)
 	}
 
+	switch account.Type {
+	case "User":
+		holder, err := gc.isTokenHolder(ctx, apiURL, owner)
+		if err != nil {
+			return nil, err
+		}
+		if !holder {
+			gc.Type = GitHubNamedUser
+		}
+		return gc, nil
+	case "Organization":
+		org := NewGitHubOrgClient(owner, token)
+		org.api.(*githubPackagesAPI).baseURL = apiURL
+		return org, nil
+	default:
+		return nil, fmt.Errorf("unsupported github owner type for %s: %q", owner, account.Type)
+	}
+}
+
+// isTokenHolder reports whether user is the account the API token authenticates.
+// A token GET /user refuses (none, or an app installation token) holds no user.
+func (gc *GitHubClient) isTokenHolder(ctx context.Context, apiURL, user string) (bool, error) {
+	account, err := gc.getGitHubAccount(ctx, apiURL, "/user")
+	var apiErr *GitHubAPIError
+	if errors.As(err, &apiErr) && (apiErr.Status == http.StatusUnauthorized || apiErr.Status == http.StatusForbidden) {
+		return false, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("get github authenticated user failed: %w", err)
+	}
+	return strings.EqualFold(account.Login, user), nil
+}
+
+// githubAccount is what the client reads of a GitHub user or organization
+type githubAccount struct {
+	Login string `json:"login"`
+	Type  string `json:"type"` // "User" or "Organization"
+}
+
+// getGitHubAccount fetches the account at path of the GitHub API, e.g. /users/{owner}
+func (gc *GitHubClient) getGitHubAccount(ctx context.Context, apiURL, path string) (*githubAccount, error) {
+	accountURL := apiURL + path
+
+	gc.logDebug("GitHub API request", "operation", "NewGitHubAutoClient", "method", http.MethodGet, "url", accountURL)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, accountURL, nil)
 	if err != nil {
 		return nil, err
 	}
+	setGitHubHeaders(req, gc.APIToken, gc.APIVersion)
 
-	// Prefix package names with username/org for ghcr.io compatibility
-	prefix := gc.Username
+	resp, err := gc.doWithoutAuth(req)
+	if err != nil {
+		return nil, err
+	}
+	defer gc.closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, newGitHubAPIError(resp)
+	}
+
+	var account githubAccount
+	if err := json.NewDecoder(resp.Body).Decode(&account); err != nil {
+		return nil, err
+	}
+
+	gc.logDebug("GitHub API response", "operation", "NewGitHubAutoClient", "url", accountURL, "login", account.Login, "type", account.Type)
+	return &account, nil
+}
+
+// owner returns the user or organization owning the packages of the client
+func (gc *GitHubClient) owner() string {
 	if gc.Type == GitHubOrg {
-		prefix = gc.Organization
+		return gc.Organization
+	}
+	return gc.Username
+}
+
+func (gc *GitHubClient) GetCatalog(ctx context.Context, pagination *PaginationParams) (*CatalogResponse, error) {
+	packagesResp, err := gc.ListPackages(ctx, "", pagination)
+	if err != nil {
+		return nil, err
 	}
 
+	// Prefix package names with username/org for ghcr.io compatibility
+	prefix := gc.owner()
+
 	repositories := make([]string, len(packagesResp.Packages))
+	var versionCounts map[string]int
 	for i, pkg := range packagesResp.Packages {
 		repositories[i] = prefix + "/" + pkg.Name
+		if pkg.VersionCount > 0 {
+			if versionCounts == nil {
+				versionCounts = make(map[string]int, len(packagesResp.Packages))
+			}
+			versionCounts[repositories[i]] = pkg.VersionCount
+		}
 	}
 
 	return &CatalogResponse{
 		Repositories:      repositories,
 		PaginatedResponse: packagesResp.PaginatedResponse,
+		VersionCounts:     versionCounts,
 	}, nil
 }
 
+// ListPackages lists the container packages of the user or organization.
+// A non-empty visibility lists only packages with that visibility.
+// Pagination uses Last as the page number.
+func (gc *GitHubClient) ListPackages(ctx context.Context, visibility PackageVisibility, pagination *PaginationParams) (*GitHubPackagesResponse, error) {
+	if err := visibility.validate(); err != nil {
+		return nil, err
+	}
+
+	var packagesResp *GitHubPackagesResponse
+	var err error
+	switch gc.Type {
+	case GitHubOrg:
+		packagesResp, err = gc.api.getOrgPackages(ctx, gc.Organization, visibility, pagination)
+	case GitHubNamedUser:
+		packagesResp, err = gc.api.getNamedUserPackages(ctx, gc.Username, visibility, pagination)
+	default:
+		packagesResp, err = gc.api.getUserPackages(ctx, visibility, pagination)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if visibility != "" {
+		// The API filters already; this guards against packages it reports with another visibility
+		packagesResp.Packages = slices.DeleteFunc(packagesResp.Packages, func(pkg GitHubPackage) bool {
+			return pkg.Visibility != string(visibility)
+		})
+	}
+	return packagesResp, nil
+}
+
 // DeleteManifest deletes a manifest by finding its package version and deleting it.
 // reference can be either a tag name (e.g., "latest", "v1.2.3") or a digest (e.g., "sha256:abc123...").
 // This overrides the standard registry DeleteManifest which doesn't work on GitHub Container Registry.
 // The acceptHeaders parameter is ignored for GitHub Container Registry.
 func (gc *GitHubClient) DeleteManifest(ctx context.Context, repository, reference string, acceptHeaders ...string) error {
-	// For GitHub packages, extract package name after the first '/'
-	// e.g., "eznix86/textbee/api" -> "textbee/api"
-	idx := strings.Index(repository, "/")
-	packageName := repository
-	if idx != -1 {
-		packageName = repository[idx+1:]
-	}
+	packageName := packageNameFromRepository(repository)
 
 	gc.logDebug("GitHub delete manifest",
 		"operation", "DeleteManifest",
@@ -151,7 +340,7 @@ func (gc *GitHubClient) DeleteManifest(ctx context.Context, repository, referenc
 	return nil
 }
 
-func buildGitHubPackagesRequest(ctx context.Context, apiURL, token string, pagination *PaginationParams) (*http.Request, error) {
+func buildGitHubPackagesRequest(ctx context.Context, apiURL, token, apiVersion string, visibility PackageVisibility, pagination *PaginationParams) (*http.Request, error) {
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
 	if err != nil {
 		return nil, err
@@ -159,89 +348,70 @@ func buildGitHubPackagesRequest(ctx context.Context, apiURL, token string, pagin
 
 	q := req.URL.Query()
 	q.Add("package_type", "container")
+	if visibility != "" {
+		q.Add("visibility", string(visibility))
+	}
 
 	if pagination != nil {
 		if pagination.N > 0 {
 			q.Add("per_page", fmt.Sprintf("%d", pagination.N))
 		}
-		if pagination.Last != "" {
-			q.Add("page", pagination.Last)
+		if page := pagination.position(); page != "" {
+			q.Add("page", page)
 		}
 	}
 
 	req.URL.RawQuery = q.Encode()
-	req.Header.Set("Authorization", "Bearer "+token)
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	setGitHubHeaders(req, token, apiVersion)
 	return req, nil
 }
 
-func (api *githubPackagesAPI) getUserPackages(ctx context.Context, pagination *PaginationParams) (*GitHubPackagesResponse, error) {
-	apiURL := api.baseURL + "/user/packages"
-
-	logArgs := []any{"operation", "getUserPackages", "method", http.MethodGet, "url", apiURL}
-	if pagination != nil {
-		logArgs = append(logArgs, "page_size", pagination.N, "last", pagination.Last)
-	}
-	api.baseClient.logDebug("GitHub API request", logArgs...)
-
-	req, err := buildGitHubPackagesRequest(ctx, apiURL, api.apiToken, pagination)
-	if err != nil {
-		return nil, err
-	}
-
-	// Use http.Client.Do directly to avoid applying the registry auth (base64-encoded token)
-	// The buildGitHubPackagesRequest already set the correct Authorization header
-	resp, err := api.baseClient.HTTPClient.Do(req)
-	if err != nil {
-		return nil, err
-	}
-	defer api.baseClient.closeBody(resp.Body)
-
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("get github user packages failed: %s - %s", resp.Status, string(body))
-	}
-
-	var packages []GitHubPackage
-	if err := json.NewDecoder(resp.Body).Decode(&packages); err != nil {
-		return nil, err
-	}
-
-	paginationResp := parseGitHubLinkHeader(resp.Header.Get("Link"))
-	api.baseClient.logDebug("GitHub API response", "operation", "getUserPackages", "package_count", len(packages), "has_more", paginationResp.HasMore)
+// setGitHubHeaders sets the headers of a GitHub API request
+func setGitHubHeaders(req *http.Request, token, apiVersion string) {
+	req.Header.Set("Authorization", "Bearer "+token)
+	req.Header.Set("Accept", "application/vnd.github+json")
+	req.Header.Set("X-GitHub-Api-Version", githubAPIVersion(apiVersion))
+}
 
-	return &GitHubPackagesResponse{
-		Packages:          packages,
-		PaginatedResponse: paginationResp,
-	}, nil
+func (api *githubPackagesAPI) getUserPackages(ctx context.Context, visibility PackageVisibility, pagination *PaginationParams) (*GitHubPackagesResponse, error) {
+	return api.listPackages(ctx, "getUserPackages", "user", api.baseURL+"/user/packages", visibility, pagination)
 }
 
-func (api *githubPackagesAPI) getOrgPackages(ctx context.Context, org string, pagination *PaginationParams) (*GitHubPackagesResponse, error) {
+func (api *githubPackagesAPI) getOrgPackages(ctx context.Context, org string, visibility PackageVisibility, pagination *PaginationParams) (*GitHubPackagesResponse, error) {
 	apiURL := fmt.Sprintf("%s/orgs/%s/packages", api.baseURL, org)
+	return api.listPackages(ctx, "getOrgPackages", "org", apiURL, visibility, pagination, "organization", org)
+}
 
-	logArgs := []any{"operation", "getOrgPackages", "method", http.MethodGet, "organization", org, "url", apiURL}
+func (api *githubPackagesAPI) getNamedUserPackages(ctx context.Context, username string, visibility PackageVisibility, pagination *PaginationParams) (*GitHubPackagesResponse, error) {
+	apiURL := fmt.Sprintf("%s/users/%s/packages", api.baseURL, url.PathEscape(username))
+	return api.listPackages(ctx, "getNamedUserPackages", "user", apiURL, visibility, pagination, "username", username)
+}
+
+// listPackages fetches a page of container packages from apiURL, a packages endpoint of
+// the GitHub API. owner names the kind of owner in errors; ownerArgs are logged.
+func (api *githubPackagesAPI) listPackages(ctx context.Context, operation, owner, apiURL string, visibility PackageVisibility, pagination *PaginationParams, ownerArgs ...any) (*GitHubPackagesResponse, error) {
+	logArgs := append([]any{"operation", operation, "method", http.MethodGet}, ownerArgs...)
+	logArgs = append(logArgs, "url", apiURL, "visibility", visibility)
 	if pagination != nil {
-		logArgs = append(logArgs, "page_size", pagination.N, "last", pagination.Last)
+		logArgs = append(logArgs, "page_size", pagination.N, "last", pagination.position())
 	}
 	api.baseClient.logDebug("GitHub API request", logArgs...)
 
-	req, err := buildGitHubPackagesRequest(ctx, apiURL, api.apiToken, pagination)
+	req, err := buildGitHubPackagesRequest(ctx, apiURL, api.apiToken, api.version(), visibility, pagination)
 	if err != nil {
 		return nil, err
 	}
 
-	// Use http.Client.Do directly to avoid applying the registry auth (base64-encoded token)
+	// Use doWithoutAuth to avoid applying the registry auth (base64-encoded token)
 	// The buildGitHubPackagesRequest already set the correct Authorization header
-	resp, err := api.baseClient.HTTPClient.Do(req)
+	resp, err := api.baseClient.doWithoutAuth(req)
 	if err != nil {
 		return nil, err
 	}
 	defer api.baseClient.closeBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("get github org packages failed: %s - %s", resp.Status, string(body))
+		return nil, fmt.Errorf("get github %s packages failed: %w", owner, newGitHubAPIError(resp))
 	}
 
 	var packages []GitHubPackage
@@ -250,7 +420,8 @@ func (api *githubPackagesAPI) getOrgPackages(ctx context.Context, org string, pa
 	}
 
 	paginationResp := parseGitHubLinkHeader(resp.Header.Get("Link"))
-	api.baseClient.logDebug("GitHub API response", "operation", "getOrgPackages", "organization", org, "package_count", len(packages), "has_more", paginationResp.HasMore)
+	responseArgs := append([]any{"operation", operation}, ownerArgs...)
+	api.baseClient.logDebug("GitHub API response", append(responseArgs, "package_count", len(packages), "has_more", paginationResp.HasMore)...)
 
 	return &GitHubPackagesResponse{
 		Packages:          packages,
@@ -258,24 +429,34 @@ func (api *githubPackagesAPI) getOrgPackages(ctx context.Context, org string, pa
 	}, nil
 }
 
-func buildPackageVersionsURL(baseURL string, clientType GitHubClientType, org, packageName string, pagination *PaginationParams) string {
-	escapedPkg := url.PathEscape(packageName)
-	var path string
-	if clientType == GitHubOrg {
-		path = fmt.Sprintf("/orgs/%s/packages/container/%s/versions", org, escapedPkg)
-	} else {
-		path = fmt.Sprintf("/user/packages/container/%s/versions", escapedPkg)
+// githubOwnerPath returns the GitHub API path of the owner of the packages of a client
+func githubOwnerPath(clientType GitHubClientType, owner string) string {
+	switch clientType {
+	case GitHubOrg:
+		return "/orgs/" + owner
+	case GitHubNamedUser:
+		return "/users/" + url.PathEscape(owner)
+	default:
+		return "/user"
 	}
+}
+
+func buildPackageVersionsURL(baseURL string, clientType GitHubClientType, owner, packageName string, state PackageState, pagination *PaginationParams) string {
+	escapedPkg := url.PathEscape(packageName)
+	path := fmt.Sprintf("%s/packages/container/%s/versions", githubOwnerPath(clientType, owner), escapedPkg)
 
 	// Build query string
 	queryParams := url.Values{}
-	queryParams.Add("state", "active")
+	if state == "" {
+		state = PackageStateActive
+	}
+	queryParams.Add("state", string(state))
 	if pagination != nil {
 		if pagination.N > 0 {
 			queryParams.Add("per_page", fmt.Sprintf("%d", pagination.N))
 		}
-		if pagination.Last != "" {
-			queryParams.Add("page", pagination.Last)
+		if page := pagination.position(); page != "" {
+			queryParams.Add("page", page)
 		}
 	}
 
@@ -288,26 +469,115 @@ func buildPackageVersionsURL(baseURL string, clientType GitHubClientType, org, p
 	return fullURL
 }
 
-func buildPackageVersionURL(baseURL string, clientType GitHubClientType, org, packageName string, versionID int) string {
+func buildPackageURL(baseURL string, clientType GitHubClientType, owner, packageName string) string {
 	escapedPkg := url.PathEscape(packageName)
-	var path string
-	if clientType == GitHubOrg {
-		path = fmt.Sprintf("/orgs/%s/packages/container/%s/versions/%d", org, escapedPkg, versionID)
-	} else {
-		path = fmt.Sprintf("/user/packages/container/%s/versions/%d", escapedPkg, versionID)
-	}
+	return fmt.Sprintf("%s%s/packages/container/%s", baseURL, githubOwnerPath(clientType, owner), escapedPkg)
+}
+
+func buildPackageVersionURL(baseURL string, clientType GitHubClientType, owner, packageName string, versionID int) string {
+	escapedPkg := url.PathEscape(packageName)
+	path := fmt.Sprintf("%s/packages/container/%s/versions/%d", githubOwnerPath(clientType, owner), escapedPkg, versionID)
 
 	// Build complete URL string directly
 	return baseURL + path
 }
 
-func (gc *GitHubClient) listPackageVersions(ctx context.Context, packageName string, pagination *PaginationParams) ([]GitHubPackageVersion, error) {
+// GitHubAPIError is the error body returned by the GitHub API, e.g.
+// {"message":"Bad credentials","documentation_url":"https://docs.github.com/rest"}.
+// GitHubClient methods wrap it in the returned error; use errors.As to inspect it.
+type GitHubAPIError struct {
+	Status           int                   // HTTP status code
+	Message          string                `json:"message"`
+	DocumentationURL string                `json:"documentation_url"`
+	Errors           []GitHubAPIFieldError `json:"errors,omitempty"` // Per-field validation errors, if any
+
+	status string // Status line, e.g. "403 Forbidden"
+	body   string // Raw response body
+}
+
+// GitHubAPIFieldError is an entry of the errors array of a GitHub API error
+type GitHubAPIFieldError struct {
+	Resource string `json:"resource"`
+	Field    string `json:"field"`
+	Code     string `json:"code"`
+	Message  string `json:"message"`
+}
+
+// UnmarshalJSON also accepts the plain strings some endpoints use as errors entries
+func (e *GitHubAPIFieldError) UnmarshalJSON(data []byte) error {
+	if len(data) > 0 && data[0] == '"' {
+		return json.Unmarshal(data, &e.Message)
+	}
+	type fieldError GitHubAPIFieldError
+	return json.Unmarshal(data, (*fieldError)(e))
+}
+
+func (e *GitHubAPIError) Error() string {
+	return fmt.Sprintf("%s - %s", e.status, e.body)
+}
+
+// newGitHubAPIError reads an unsuccessful GitHub API response.
+// Bodies that are not a GitHub error document leave Message empty.
+func newGitHubAPIError(resp *http.Response) *GitHubAPIError {
+	body, _ := io.ReadAll(resp.Body)
+	apiErr := &GitHubAPIError{}
+	_ = json.Unmarshal(body, apiErr)
+	apiErr.Status = resp.StatusCode
+	apiErr.status = resp.Status
+	apiErr.body = string(body)
+	return apiErr
+}
+
+// ErrPackageNotFound is returned when a GitHub package does not exist or is not visible to the token
+var ErrPackageNotFound = errors.New("package not found")
+
+// GetPackage returns the metadata of a single container package, such as its
+// visibility, owner and version count. Multi-segment names (e.g. "textbee/api") are escaped.
+func (gc *GitHubClient) GetPackage(ctx context.Context, packageName string) (*GitHubPackage, error) {
 	baseURL := gc.api.(*githubPackagesAPI).baseURL
-	apiURL := buildPackageVersionsURL(baseURL, gc.Type, gc.Organization, packageName, pagination)
+	apiURL := buildPackageURL(baseURL, gc.Type, gc.owner(), packageName)
+
+	gc.logDebug("GitHub API request", "operation", "GetPackage", "method", http.MethodGet, "package", packageName, "url", apiURL)
 
-	logArgs := []any{"operation", "listPackageVersions", "method", http.MethodGet, "package", packageName, "url", apiURL}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	setGitHubHeaders(req, gc.APIToken, gc.APIVersion)
+
+	// Use doWithoutAuth to avoid applying the registry auth (base64-encoded token)
+	resp, err := gc.doWithoutAuth(req)
+	if err != nil {
+		return nil, err
+	}
+	defer gc.closeBody(resp.Body)
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, fmt.Errorf("%w: %s", ErrPackageNotFound, packageName)
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("get package failed: %w", newGitHubAPIError(resp))
+	}
+
+	var pkg GitHubPackage
+	if err := json.NewDecoder(resp.Body).Decode(&pkg); err != nil {
+		return nil, err
+	}
+
+	gc.logDebug("GitHub API response", "operation", "GetPackage", "package", packageName, "version_count", pkg.VersionCount)
+	return &pkg, nil
+}
+
+// ListPackageVersions lists the versions of a container package in the given state.
+// An empty state lists active versions; use PackageStateDeleted to find versions that can be restored.
+// Pagination uses Last as the page number.
+func (gc *GitHubClient) ListPackageVersions(ctx context.Context, packageName string, state PackageState, pagination *PaginationParams) ([]GitHubPackageVersion, error) {
+	baseURL := gc.api.(*githubPackagesAPI).baseURL
+	apiURL := buildPackageVersionsURL(baseURL, gc.Type, gc.owner(), packageName, state, pagination)
+
+	logArgs := []any{"operation", "ListPackageVersions", "method", http.MethodGet, "package", packageName, "url", apiURL}
 	if pagination != nil {
-		logArgs = append(logArgs, "page_size", pagination.N, "page", pagination.Last)
+		logArgs = append(logArgs, "page_size", pagination.N, "page", pagination.position())
 	}
 	gc.logDebug("GitHub API request", logArgs...)
 
@@ -315,21 +585,18 @@ func (gc *GitHubClient) listPackageVersions(ctx context.Context, packageName str
 	if err != nil {
 		return nil, err
 	}
-	req.Header.Set("Authorization", "Bearer "+gc.APIToken)
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+	setGitHubHeaders(req, gc.APIToken, gc.APIVersion)
 
-	// Use http.Client.Do directly to avoid applying the registry auth (base64-encoded token)
+	// Use doWithoutAuth to avoid applying the registry auth (base64-encoded token)
 	// The Authorization header was already set with the correct raw token
-	resp, err := gc.HTTPClient.Do(req)
+	resp, err := gc.doWithoutAuth(req)
 	if err != nil {
 		return nil, err
 	}
 	defer gc.closeBody(resp.Body)
 
 	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("list package versions failed: %s - %s", resp.Status, string(body))
+		return nil, fmt.Errorf("list package versions failed: %w", newGitHubAPIError(resp))
 	}
 
 	var versions []GitHubPackageVersion
@@ -337,87 +604,338 @@ func (gc *GitHubClient) listPackageVersions(ctx context.Context, packageName str
 		return nil, err
 	}
 
-	gc.logDebug("GitHub API response", "operation", "listPackageVersions", "package", packageName, "version_count", len(versions))
+	gc.logDebug("GitHub API response", "operation", "ListPackageVersions", "package", packageName, "version_count", len(versions))
 	return versions, nil
 }
 
-//nolint:funlen // complex pagination and search logic
 func (gc *GitHubClient) findPackageVersionID(ctx context.Context, packageName, reference string) (int, error) {
-	isDigest := strings.HasPrefix(reference, "sha256:")
-	page := 1
+	version, err := gc.findPackageVersion(ctx, packageName, reference)
+	if err != nil {
+		return 0, err
+	}
+	return version.ID, nil
+}
 
-	for {
-		versions, err := gc.listPackageVersions(ctx, packageName, &PaginationParams{N: 100, Last: fmt.Sprintf("%d", page)})
-		if err != nil {
-			return 0, err
+// findPackageVersion finds the active version of a package by digest (its name) or by tag
+func (gc *GitHubClient) findPackageVersion(ctx context.Context, packageName, reference string) (*GitHubPackageVersion, error) {
+	isDigest := IsDigest(reference)
+
+	var found *GitHubPackageVersion
+	err := gc.forEachPackageVersion(ctx, "findPackageVersion", packageName, func(v GitHubPackageVersion) bool {
+		if (isDigest && v.Name == reference) || (!isDigest && slices.Contains(v.Metadata.Container.Tags, reference)) {
+			found = &v
+			return false
 		}
+		return true
+	})
+	if err != nil {
+		return nil, err
+	}
+	if found == nil {
+		return nil, fmt.Errorf("package version not found for reference: %s", reference)
+	}
 
-		if len(versions) == 0 {
-			break
+	if isDigest {
+		gc.logDebug("Found package version by digest", "package", packageName, "reference", reference, "version_id", found.ID)
+	} else {
+		gc.logDebug("Found package version by tag", "package", packageName, "reference", reference, "version_id", found.ID)
+	}
+	return found, nil
+}
+
+// TagsForDigest returns the tags of the package version with the given digest,
+// the reverse of resolving a tag. Untagged versions return an empty slice.
+func (gc *GitHubClient) TagsForDigest(ctx context.Context, repository, digest string) ([]string, error) {
+	if !IsDigest(digest) {
+		return nil, fmt.Errorf("invalid digest: %s", digest)
+	}
+
+	version, err := gc.findPackageVersion(ctx, packageNameFromRepository(repository), digest)
+	if err != nil {
+		return nil, err
+	}
+	if version.Metadata.Container.Tags == nil {
+		return []string{}, nil
+	}
+	return version.Metadata.Container.Tags, nil
+}
+
+// githubMaxPageSize is the largest per_page the GitHub API accepts, used when walking all versions of a package
+const githubMaxPageSize = 100
+
+// forEachPackageVersion pages through the active versions of a package and calls fn
+// for each one until fn returns false. operation names the caller in MaxPages errors.
+func (gc *GitHubClient) forEachPackageVersion(ctx context.Context, operation, packageName string, fn func(GitHubPackageVersion) bool) error {
+	pageSize := gc.pageSize(githubMaxPageSize, githubMaxPageSize)
+	for page := 1; ; page++ {
+		if err := gc.checkPageLimit(operation, page); err != nil {
+			return err
+		}
+
+		pagination := &PaginationParams{N: pageSize, Last: strconv.Itoa(page)}
+		versions, err := gc.ListPackageVersions(ctx, packageName, PackageStateActive, pagination)
+		if err != nil {
+			return err
 		}
 
 		for _, v := range versions {
-			if isDigest {
-				if v.Name == reference {
-					gc.logDebug("Found package version by digest", "package", packageName, "reference", reference, "version_id", v.ID)
-					return v.ID, nil
-				}
-			} else {
-				if slices.Contains(v.Metadata.Container.Tags, reference) {
-					gc.logDebug("Found package version by tag", "package", packageName, "reference", reference, "version_id", v.ID)
-					return v.ID, nil
-				}
+			if !fn(v) {
+				return nil
 			}
 		}
 
-		if len(versions) < 100 {
+		if len(versions) < pageSize {
+			return nil
+		}
+	}
+}
+
+// CountUntaggedVersions counts the active versions of a package that have no tags,
+// i.e. the versions an untagged cleanup would delete. Nothing is deleted.
+func (gc *GitHubClient) CountUntaggedVersions(ctx context.Context, repository string) (int, error) {
+	packageName := packageNameFromRepository(repository)
+
+	count := 0
+	err := gc.forEachPackageVersion(ctx, "CountUntaggedVersions", packageName, func(v GitHubPackageVersion) bool {
+		if len(v.Metadata.Container.Tags) == 0 {
+			count++
+		}
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	gc.logDebug("Counted untagged package versions", "operation", "CountUntaggedVersions", "repository", repository, "package", packageName, "untagged_count", count)
+	return count, nil
+}
+
+// PruneVersions keeps the keep most recently created versions of a package and deletes
+// the rest. Versions that still have tags are never deleted unless PruneTagged is set.
+// With DisableDelete, nothing is deleted and the returned count is what would be deleted.
+// Versions are deleted DeleteConcurrency at a time, oldest last. After the first failure no
+// further deletion starts; the versions deleted so far are counted in deleted and the
+// failures of the deletions that were in flight are joined in err.
+func (gc *GitHubClient) PruneVersions(ctx context.Context, repository string, keep int) (deleted int, err error) {
+	if keep < 0 {
+		return 0, fmt.Errorf("keep must not be negative: %d", keep)
+	}
+	packageName := packageNameFromRepository(repository)
+
+	var versions []GitHubPackageVersion
+	err = gc.forEachPackageVersion(ctx, "PruneVersions", packageName, func(v GitHubPackageVersion) bool {
+		versions = append(versions, v)
+		return true
+	})
+	if err != nil {
+		return 0, err
+	}
+
+	// Newest first; created_at is RFC 3339 in UTC, so it sorts lexically
+	slices.SortStableFunc(versions, func(a, b GitHubPackageVersion) int {
+		return strings.Compare(b.CreatedAt, a.CreatedAt)
+	})
+
+	var ids []int
+	for _, v := range versions[min(keep, len(versions)):] {
+		if len(v.Metadata.Container.Tags) > 0 && !gc.PruneTagged {
+			gc.logDebug("Keeping tagged package version", "operation", "PruneVersions", "package", packageName, "version_id", v.ID, "tags", v.Metadata.Container.Tags)
+			continue
+		}
+		ids = append(ids, v.ID)
+	}
+
+	deleted, err = gc.deletePackageVersions(ctx, packageName, ids)
+	if err != nil {
+		return deleted, err
+	}
+
+	gc.logDebug("Pruned package versions", "operation", "PruneVersions", "repository", repository, "package", packageName, "kept", keep, "deleted", deleted)
+	return deleted, nil
+}
+
+// packageNameFromRepository strips the user or organization prefix from a repository,
+// e.g. "eznix86/textbee/api" -> "textbee/api"
+func packageNameFromRepository(repository string) string {
+	if _, packageName, ok := strings.Cut(repository, "/"); ok {
+		return packageName
+	}
+	return repository
+}
+
+// deletePackageVersions deletes versions, DeleteConcurrency at a time, in order. No
+// deletion starts after one failed. It returns the number deleted and the joined failures.
+func (gc *GitHubClient) deletePackageVersions(ctx context.Context, packageName string, versionIDs []int) (int, error) {
+	sem := make(chan struct{}, max(gc.DeleteConcurrency, 1))
+	var deleted atomic.Int64
+	var failed atomic.Bool
+	var mu sync.Mutex
+	var errs []error
+	var wg sync.WaitGroup
+	var cancelled error
+
+	for _, id := range versionIDs {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+		}
+		if cancelled = ctx.Err(); cancelled != nil || failed.Load() {
 			break
 		}
-		page++
+
+		wg.Go(func() {
+			defer func() { <-sem }()
+			if err := gc.deletePackageVersion(ctx, packageName, id); err != nil {
+				failed.Store(true)
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("version %d: %w", id, err))
+				mu.Unlock()
+				return
+			}
+			deleted.Add(1)
+		})
 	}
+	wg.Wait()
 
-	return 0, fmt.Errorf("package version not found for reference: %s", reference)
+	if len(errs) == 0 && cancelled != nil {
+		errs = append(errs, cancelled)
+	}
+	return int(deleted.Load()), errors.Join(errs...)
 }
 
+// githubRateLimitRetries is the number of times a deletion is retried after GitHub
+// reported a rate limit
+const githubRateLimitRetries = 5
+
+// githubServerErrorRetries is the number of times a deletion is retried with backoff
+// after a 5xx from GitHub. Deleting is idempotent, so retrying is safe.
+const githubServerErrorRetries = 3
+
+// githubRateLimit holds back GitHub API requests until a rate limit resets
+type githubRateLimit struct {
+	mu    sync.Mutex
+	until time.Time
+}
+
+// pause holds back requests for d, unless they are already held back for longer
+func (l *githubRateLimit) pause(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if until := time.Now().Add(d); until.After(l.until) {
+		l.until = until
+	}
+}
+
+// wait blocks until the rate limit has reset or ctx is done
+func (l *githubRateLimit) wait(ctx context.Context) error {
+	l.mu.Lock()
+	d := time.Until(l.until)
+	l.mu.Unlock()
+	if d <= 0 {
+		return nil
+	}
+	return sleepContext(ctx, d)
+}
+
+// githubRateLimitWait reports whether resp is a GitHub rate limit response (403 or 429
+// with Retry-After, or with X-RateLimit-Remaining at 0) and how long to wait: Retry-After
+// for secondary rate limits, otherwise until X-RateLimit-Reset (a Unix time)
+func githubRateLimitWait(resp *http.Response) (time.Duration, bool) {
+	if resp.StatusCode != http.StatusForbidden && resp.StatusCode != http.StatusTooManyRequests {
+		return 0, false
+	}
+	if resp.Header.Get("Retry-After") != "" {
+		return parseRetryAfter(resp), true
+	}
+	if resp.Header.Get("X-RateLimit-Remaining") != "0" {
+		return 0, false
+	}
+	reset, err := strconv.ParseInt(resp.Header.Get("X-RateLimit-Reset"), 10, 64)
+	if err != nil {
+		return time.Minute, true
+	}
+	return max(time.Until(time.Unix(reset, 0)), 0), true
+}
+
+// deletePackageVersion deletes a package version. Rate limit responses pause every deletion
+// of the client until the limit resets, then the deletion is retried.
 func (gc *GitHubClient) deletePackageVersion(ctx context.Context, packageName string, versionID int) error {
 	baseURL := gc.api.(*githubPackagesAPI).baseURL
-	apiURL := buildPackageVersionURL(baseURL, gc.Type, gc.Organization, packageName, versionID)
+	apiURL := buildPackageVersionURL(baseURL, gc.Type, gc.owner(), packageName, versionID)
 
 	if gc.DisableDelete {
 		gc.logInfo("DELETE DISABLED (dry-run mode)", "operation", "deletePackageVersion", "package", packageName, "version_id", versionID, "url", apiURL)
 		return nil
 	}
 
-	gc.logDebug("GitHub API request", "operation", "deletePackageVersion", "method", http.MethodDelete, "package", packageName, "version_id", versionID, "url", apiURL)
+	rateLimited, serverErrors := 0, 0
+	for {
+		if err := gc.rateLimit.wait(ctx); err != nil {
+			return err
+		}
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, apiURL, nil)
-	if err != nil {
-		return err
-	}
+		gc.logDebug("GitHub API request", "operation", "deletePackageVersion", "method", http.MethodDelete, "package", packageName, "version_id", versionID, "url", apiURL)
 
-	req.Header.Set("Authorization", "Bearer "+gc.APIToken)
-	req.Header.Set("Accept", "application/vnd.github+json")
-	req.Header.Set("X-GitHub-Api-Version", "2022-11-28")
+		req, err := http.NewRequestWithContext(ctx, http.MethodDelete, apiURL, nil)
+		if err != nil {
+			return err
+		}
 
-	// Use http.Client.Do directly to avoid applying the registry auth (base64-encoded token)
-	// The Authorization header was already set with the correct raw token
-	resp, err := gc.HTTPClient.Do(req)
-	if err != nil {
-		return err
+		setGitHubHeaders(req, gc.APIToken, gc.APIVersion)
+
+		// Use doWithoutAuth to avoid applying the registry auth (base64-encoded token)
+		// The Authorization header was already set with the correct raw token
+		resp, err := gc.doWithoutAuth(req)
+		if err != nil {
+			return err
+		}
+
+		wait, limited := githubRateLimitWait(resp)
+		if limited && rateLimited < githubRateLimitRetries {
+			rateLimited++
+			gc.closeBody(resp.Body)
+			gc.logWarn("GitHub API rate limited, pausing deletions", "operation", "deletePackageVersion", "package", packageName, "version_id", versionID, "wait", wait.String())
+			gc.rateLimit.pause(wait)
+			continue
+		}
+
+		if resp.StatusCode >= http.StatusInternalServerError && serverErrors < githubServerErrorRetries {
+			serverErrors++
+			gc.closeBody(resp.Body)
+			backoff := calculateBackoff(serverErrors, gc.backoff())
+			gc.logWarn("Retrying GitHub package version deletion", "operation", "deletePackageVersion", "package", packageName, "version_id", versionID, "status", resp.StatusCode, "attempt", serverErrors, "backoff", backoff.String())
+			if err := sleepContext(ctx, backoff); err != nil {
+				return err
+			}
+			continue
+		}
+
+		// The request that failed with a 5xx may still have deleted the version
+		if resp.StatusCode == http.StatusNotFound && serverErrors > 0 {
+			gc.closeBody(resp.Body)
+			gc.logDebug("GitHub API response", "operation", "deletePackageVersion", "package", packageName, "version_id", versionID, "status", "already deleted")
+			return nil
+		}
+		return gc.deletePackageVersionResult(resp, packageName, versionID, limited)
 	}
+}
+
+// deletePackageVersionResult maps the response of a package version deletion to an error
+func (gc *GitHubClient) deletePackageVersionResult(resp *http.Response, packageName string, versionID int, limited bool) error {
 	defer gc.closeBody(resp.Body)
 
-	switch resp.StatusCode {
-	case http.StatusNoContent:
+	switch {
+	case resp.StatusCode == http.StatusNoContent:
 		gc.logDebug("GitHub API response", "operation", "deletePackageVersion", "package", packageName, "version_id", versionID, "status", "success")
 		return nil
-	case http.StatusForbidden:
-		return fmt.Errorf("cannot delete package version: insufficient permissions or package has >5,000 downloads")
-	case http.StatusNotFound:
+	case limited:
+		return fmt.Errorf("delete package version failed: rate limited: %w", newGitHubAPIError(resp))
+	case resp.StatusCode == http.StatusForbidden:
+		return fmt.Errorf("cannot delete package version: insufficient permissions or package has >5,000 downloads: %w", newGitHubAPIError(resp))
+	case resp.StatusCode == http.StatusNotFound:
 		return fmt.Errorf("package version not found")
 	default:
-		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("delete package version failed: %s - %s", resp.Status, string(body))
+		return fmt.Errorf("delete package version failed: %w", newGitHubAPIError(resp))
 	}
 }
 
@@ -450,7 +968,7 @@ func parseGitHubLinkHeader(linkHeader string) PaginatedResponse {
 	}
 
 	for link := range strings.SplitSeq(linkHeader, ",") {
-		if !strings.Contains(link, `rel="next"`) {
+		if next, _ := linkRel(link, "next"); !next {
 			continue
 		}
 
@@ -464,6 +982,7 @@ func parseGitHubLinkHeader(linkHeader string) PaginatedResponse {
 			HasMore: true,
 			Last:    nextPage,
 			N:       pageSize,
+			Cursor:  nextPage,
 		}
 	}
 
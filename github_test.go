@@ -6,7 +6,13 @@ import (
 	"io"
 	"net/http"
 	"net/http/httptest"
+	"path"
+	"strconv"
+	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	json "github.com/eznix86/registry-client/jsoncompat"
 	"github.com/stretchr/testify/assert"
@@ -18,7 +24,7 @@ type mockPackagesAPI struct {
 	client    *BaseClient
 }
 
-func (m *mockPackagesAPI) getUserPackages(ctx context.Context, pagination *PaginationParams) (*GitHubPackagesResponse, error) {
+func (m *mockPackagesAPI) getUserPackages(ctx context.Context, visibility PackageVisibility, pagination *PaginationParams) (*GitHubPackagesResponse, error) {
 	apiURL := m.serverURL + "/user/packages"
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
@@ -28,6 +34,9 @@ func (m *mockPackagesAPI) getUserPackages(ctx context.Context, pagination *Pagin
 
 	q := req.URL.Query()
 	q.Add("package_type", "container")
+	if visibility != "" {
+		q.Add("visibility", string(visibility))
+	}
 
 	if pagination != nil {
 		if pagination.N > 0 {
@@ -66,7 +75,12 @@ func (m *mockPackagesAPI) getUserPackages(ctx context.Context, pagination *Pagin
 	}, nil
 }
 
-func (m *mockPackagesAPI) getOrgPackages(ctx context.Context, org string, pagination *PaginationParams) (*GitHubPackagesResponse, error) {
+func (m *mockPackagesAPI) getNamedUserPackages(ctx context.Context, username string, visibility PackageVisibility, pagination *PaginationParams) (*GitHubPackagesResponse, error) {
+	api := &githubPackagesAPI{baseClient: m.client, baseURL: m.serverURL}
+	return api.getNamedUserPackages(ctx, username, visibility, pagination)
+}
+
+func (m *mockPackagesAPI) getOrgPackages(ctx context.Context, org string, visibility PackageVisibility, pagination *PaginationParams) (*GitHubPackagesResponse, error) {
 	apiURL := fmt.Sprintf("%s/orgs/%s/packages", m.serverURL, org)
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, apiURL, nil)
@@ -76,6 +90,9 @@ func (m *mockPackagesAPI) getOrgPackages(ctx context.Context, org string, pagina
 
 	q := req.URL.Query()
 	q.Add("package_type", "container")
+	if visibility != "" {
+		q.Add("visibility", string(visibility))
+	}
 
 	if pagination != nil {
 		if pagination.N > 0 {
@@ -135,6 +152,76 @@ func TestNewGitHubOrgClient(t *testing.T) {
 	assert.Equal(t, "test-token", client.APIToken)
 }
 
+//nolint:funlen // table-driven test with test server
+func TestNewGitHubAutoClient(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       int
+		body         string
+		holderStatus int    // Status of GET /user (0 = 200)
+		holder       string // Body of GET /user
+		wantType     GitHubClientType
+		wantPackages string
+		wantError    string
+	}{
+		{name: "token holder", status: http.StatusOK, body: `{"login":"octo","type":"User"}`, holder: `{"login":"Octo"}`, wantType: GitHubUser, wantPackages: "/user/packages"},
+		{name: "other user", status: http.StatusOK, body: `{"login":"octo","type":"User"}`, holder: `{"login":"someone"}`, wantType: GitHubNamedUser, wantPackages: "/users/octo/packages"},
+		{name: "token without user", status: http.StatusOK, body: `{"login":"octo","type":"User"}`, holderStatus: http.StatusForbidden, holder: `{"message":"Resource not accessible by integration"}`, wantType: GitHubNamedUser, wantPackages: "/users/octo/packages"},
+		{name: "organization", status: http.StatusOK, body: `{"login":"octo","type":"Organization"}`, wantType: GitHubOrg, wantPackages: "/orgs/octo/packages"},
+		{name: "bot", status: http.StatusOK, body: `{"login":"octo","type":"Bot"}`, wantError: `unsupported github owner type for octo: "Bot"`},
+		{name: "not found", status: http.StatusNotFound, body: `{"message":"Not Found"}`, wantError: "get github owner failed: 404"},
+		{name: "authenticated user error", status: http.StatusOK, body: `{"login":"octo","type":"User"}`, holderStatus: http.StatusInternalServerError, wantError: "get github authenticated user failed: 500"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var packagesPath string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+				assert.Equal(t, DefaultGitHubAPIVersion, r.Header.Get("X-GitHub-Api-Version"))
+				switch r.URL.Path {
+				case "/users/octo":
+					w.WriteHeader(tt.status)
+					_, _ = w.Write([]byte(tt.body))
+				case "/user":
+					if tt.holderStatus != 0 {
+						w.WriteHeader(tt.holderStatus)
+					}
+					_, _ = w.Write([]byte(tt.holder))
+				default:
+					packagesPath = r.URL.Path
+					_, _ = w.Write([]byte(`[]`))
+				}
+			}))
+			defer server.Close()
+
+			client, err := newGitHubAutoClient(context.Background(), server.URL, "octo", "test-token")
+			if tt.wantError != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantError)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantType, client.Type)
+			assert.Equal(t, "https://ghcr.io", client.BaseURL)
+			assert.Equal(t, "octo", client.owner())
+
+			_, err = client.ListPackages(context.Background(), "", nil)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantPackages, packagesPath)
+		})
+	}
+}
+
+func TestGitHubNamedUserURLs(t *testing.T) {
+	assert.Equal(t, "https://api.github.com/users/octo/packages/container/app%2Fweb",
+		buildPackageURL("https://api.github.com", GitHubNamedUser, "octo", "app/web"))
+	assert.Equal(t, "https://api.github.com/users/octo/packages/container/app/versions?state=active",
+		buildPackageVersionsURL("https://api.github.com", GitHubNamedUser, "octo", "app", "", nil))
+	assert.Equal(t, "https://api.github.com/users/octo/packages/container/app/versions/42",
+		buildPackageVersionURL("https://api.github.com", GitHubNamedUser, "octo", "app", 42))
+}
+
 //nolint:funlen // table-driven test with multiple test cases
 func TestGitHubClient_GetCatalog_User(t *testing.T) {
 	tests := []struct {
@@ -350,6 +437,35 @@ func TestGitHubClient_GetCatalog_Org(t *testing.T) {
 	}
 }
 
+func TestGitHubClient_GetCatalog_Cursor(t *testing.T) {
+	var pages []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		page := r.URL.Query().Get("page")
+		pages = append(pages, page)
+		if page == "" {
+			w.Header().Set("Link", `<https://api.github.com/user/packages?package_type=container&per_page=1&page=2>; rel="next"`)
+			_ = json.NewEncoder(w).Encode([]GitHubPackage{{Name: "app"}})
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]GitHubPackage{{Name: "web"}})
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("testuser", "test-token")
+	client.api.(*githubPackagesAPI).baseURL = server.URL
+
+	first, err := client.GetCatalog(context.Background(), &PaginationParams{N: 1})
+	require.NoError(t, err)
+	assert.Equal(t, "2", first.Cursor)
+
+	second, err := client.GetCatalog(context.Background(), &PaginationParams{N: 1, Cursor: first.Cursor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"testuser/web"}, second.Repositories)
+	assert.Empty(t, second.Cursor)
+
+	assert.Equal(t, []string{"", "2"}, pages)
+}
+
 //nolint:funlen // table-driven test with many edge cases
 func TestParseGitHubLinkHeader(t *testing.T) {
 	tests := []struct {
@@ -422,6 +538,27 @@ func TestParseGitHubLinkHeader(t *testing.T) {
 			wantNextPage: "",
 			wantN:        0,
 		},
+		{
+			name:         "unquoted rel",
+			linkHeader:   `<https://api.github.com/user/packages?per_page=20&page=2>; rel=next, <https://api.github.com/user/packages?per_page=20&page=4>; rel=last`,
+			wantHasMore:  true,
+			wantNextPage: "2",
+			wantN:        20,
+		},
+		{
+			name:         "capitalized rel",
+			linkHeader:   `<https://api.github.com/user/packages?page=1>; Rel="prev", <https://api.github.com/user/packages?page=3>; Rel="next"`,
+			wantHasMore:  true,
+			wantNextPage: "3",
+			wantN:        0,
+		},
+		{
+			name:         "next in another parameter",
+			linkHeader:   `<https://api.github.com/user/packages?page=1>; rel="prev"; title="next"`,
+			wantHasMore:  false,
+			wantNextPage: "",
+			wantN:        0,
+		},
 	}
 
 	for _, tt := range tests {
@@ -469,7 +606,7 @@ func TestGithubPackagesAPI_GetUserPackages_NetworkError(t *testing.T) {
 		baseURL:    "http://example.com",
 	}
 
-	_, err := api.getUserPackages(context.Background(), nil)
+	_, err := api.getUserPackages(context.Background(), "", nil)
 	require.Error(t, err)
 }
 
@@ -559,7 +696,7 @@ func TestGithubPackagesAPI_GetUserPackages(t *testing.T) {
 				baseURL:    server.URL,
 			}
 
-			resp, err := api.getUserPackages(context.Background(), tt.pagination)
+			resp, err := api.getUserPackages(context.Background(), "", tt.pagination)
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -593,7 +730,7 @@ func TestGithubPackagesAPI_GetOrgPackages_NetworkError(t *testing.T) {
 		baseURL:    "http://example.com",
 	}
 
-	_, err := api.getOrgPackages(context.Background(), "testorg", nil)
+	_, err := api.getOrgPackages(context.Background(), "testorg", "", nil)
 	require.Error(t, err)
 }
 
@@ -688,7 +825,7 @@ func TestGithubPackagesAPI_GetOrgPackages(t *testing.T) {
 				baseURL:    server.URL,
 			}
 
-			resp, err := api.getOrgPackages(context.Background(), tt.org, tt.pagination)
+			resp, err := api.getOrgPackages(context.Background(), tt.org, "", tt.pagination)
 
 			if tt.wantErr {
 				require.Error(t, err)
@@ -753,14 +890,14 @@ func TestGitHubClient_DeleteManifest_User(t *testing.T) {
 		{
 			name:       "delete by digest success",
 			repository: "user/my-app",
-			reference:  "sha256:abc123",
+			reference:  "sha256:6ca13d52ca70c883e0f0bb101e425a89e8624de51db2d2392593af6a84118090",
 			setupServer: func(w http.ResponseWriter, r *http.Request) {
 				switch {
 				case r.Method == http.MethodGet && r.URL.Path == "/user/packages/container/my-app/versions":
 					versions := []GitHubPackageVersion{
 						{
 							ID:   12345,
-							Name: "sha256:abc123",
+							Name: "sha256:6ca13d52ca70c883e0f0bb101e425a89e8624de51db2d2392593af6a84118090",
 							Metadata: GitHubPackageMetadata{
 								Container: GitHubContainerMetadata{
 									Tags: []string{"v1.0.0"},
@@ -1000,11 +1137,656 @@ func TestGitHubClient_FindPackageVersionID_Pagination(t *testing.T) {
 	assert.Equal(t, 201, versionID)
 }
 
+func TestGitHubClient_FindPackageVersionID_PaginationLoop(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		// Ignores the page parameter and always returns the same full page
+		versions := make([]GitHubPackageVersion, 100)
+		for i := range versions {
+			versions[i] = GitHubPackageVersion{ID: i + 1, Name: fmt.Sprintf("sha256:hash%d", i)}
+		}
+		_ = json.NewEncoder(w).Encode(versions)
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("testuser", "test-token")
+	client.MaxPages = 4
+	client.api = &githubPackagesAPI{
+		baseClient: client.BaseClient,
+		apiToken:   "test-token",
+		baseURL:    server.URL,
+	}
+
+	_, err := client.findPackageVersionID(context.Background(), "my-app", "missing-tag")
+	require.ErrorIs(t, err, ErrTooManyPages)
+	assert.Equal(t, 4, requests)
+}
+
+func TestGitHubClient_TagsForDigest(t *testing.T) {
+	const (
+		taggedDigest   = "sha256:2ea11021a75aa53abe49adaef5da56fa6b05c1fe1c9d66fa5fe744ad906f9f64"
+		untaggedDigest = "sha256:6ca20016b9c82a1bd57872269b58825bbf6289aedeee361865d54969f99e1c56"
+		missingDigest  = "sha256:ffa63583dfa6706b87d284b86b0d693a161e4840aad2c5cf6b5d27c3b9621f7d"
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/user/packages/container/textbee%2Fapi/versions", r.URL.EscapedPath())
+		versions := []GitHubPackageVersion{
+			{ID: 1, Name: taggedDigest, Metadata: GitHubPackageMetadata{Container: GitHubContainerMetadata{Tags: []string{"v1", "latest"}}}},
+			{ID: 2, Name: untaggedDigest},
+		}
+		if r.URL.Query().Get("page") != "1" {
+			versions = nil
+		}
+		_ = json.NewEncoder(w).Encode(versions)
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("testuser", "test-token")
+	client.api.(*githubPackagesAPI).baseURL = server.URL
+
+	tests := []struct {
+		name     string
+		digest   string
+		wantTags []string
+		wantErr  string
+	}{
+		{name: "tagged", digest: taggedDigest, wantTags: []string{"v1", "latest"}},
+		{name: "untagged", digest: untaggedDigest, wantTags: []string{}},
+		{name: "not found", digest: missingDigest, wantErr: "package version not found for reference: " + missingDigest},
+		{name: "tag instead of digest", digest: "v1", wantErr: "invalid digest: v1"},
+		{name: "malformed digest", digest: "sha256:notlongenough", wantErr: "invalid digest: sha256:notlongenough"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tags, err := client.TagsForDigest(context.Background(), "testuser/textbee/api", tt.digest)
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantTags, tags)
+		})
+	}
+}
+
+func TestGitHubClient_ListPackageVersions_State(t *testing.T) {
+	tests := []struct {
+		name      string
+		client    *GitHubClient
+		state     PackageState
+		wantPath  string
+		wantState string
+	}{
+		{name: "default is active", client: NewGitHubClient("testuser", "test-token"), wantPath: "/user/packages/container/my-app/versions", wantState: "active"},
+		{name: "active", client: NewGitHubClient("testuser", "test-token"), state: PackageStateActive, wantPath: "/user/packages/container/my-app/versions", wantState: "active"},
+		{name: "deleted", client: NewGitHubClient("testuser", "test-token"), state: PackageStateDeleted, wantPath: "/user/packages/container/my-app/versions", wantState: "deleted"},
+		{name: "deleted org", client: NewGitHubOrgClient("myorg", "test-token"), state: PackageStateDeleted, wantPath: "/orgs/myorg/packages/container/my-app/versions", wantState: "deleted"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.wantPath, r.URL.Path)
+				assert.Equal(t, tt.wantState, r.URL.Query().Get("state"))
+				assert.Equal(t, "2", r.URL.Query().Get("page"))
+				_ = json.NewEncoder(w).Encode([]GitHubPackageVersion{{ID: 7, Name: "sha256:deleted"}})
+			}))
+			defer server.Close()
+
+			tt.client.api = &githubPackagesAPI{
+				baseClient: tt.client.BaseClient,
+				apiToken:   "test-token",
+				baseURL:    server.URL,
+			}
+
+			versions, err := tt.client.ListPackageVersions(context.Background(), "my-app", tt.state, &PaginationParams{N: 50, Last: "2"})
+			require.NoError(t, err)
+			require.Len(t, versions, 1)
+			assert.Equal(t, 7, versions[0].ID)
+		})
+	}
+}
+
+func TestGitHubClient_GetPackage(t *testing.T) {
+	tests := []struct {
+		name        string
+		client      *GitHubClient
+		packageName string
+		statusCode  int
+		wantPath    string
+		wantErr     error
+		wantErrMsg  string
+	}{
+		{name: "user", client: NewGitHubClient("testuser", "test-token"), packageName: "my-app", statusCode: http.StatusOK, wantPath: "/user/packages/container/my-app"},
+		{name: "org multi-segment", client: NewGitHubOrgClient("myorg", "test-token"), packageName: "textbee/api", statusCode: http.StatusOK, wantPath: "/orgs/myorg/packages/container/textbee%2Fapi"},
+		{name: "not found", client: NewGitHubClient("testuser", "test-token"), packageName: "missing", statusCode: http.StatusNotFound, wantPath: "/user/packages/container/missing", wantErr: ErrPackageNotFound},
+		{name: "forbidden", client: NewGitHubClient("testuser", "test-token"), packageName: "my-app", statusCode: http.StatusForbidden, wantPath: "/user/packages/container/my-app", wantErrMsg: "get package failed: 403"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, tt.wantPath, r.URL.EscapedPath())
+				assert.Equal(t, "Bearer test-token", r.Header.Get("Authorization"))
+				w.WriteHeader(tt.statusCode)
+				_, _ = w.Write([]byte(`{"id":42,"name":"my-app","package_type":"container","visibility":"private","version_count":7,"owner":{"login":"testuser","type":"User"}}`))
+			}))
+			defer server.Close()
+
+			tt.client.api = &githubPackagesAPI{
+				baseClient: tt.client.BaseClient,
+				apiToken:   "test-token",
+				baseURL:    server.URL,
+			}
+
+			pkg, err := tt.client.GetPackage(context.Background(), tt.packageName)
+			if tt.wantErr != nil || tt.wantErrMsg != "" {
+				require.Error(t, err)
+				if tt.wantErr != nil {
+					assert.ErrorIs(t, err, tt.wantErr)
+				}
+				assert.Contains(t, err.Error(), tt.wantErrMsg)
+				assert.Nil(t, pkg)
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, 42, pkg.ID)
+			assert.Equal(t, "private", pkg.Visibility)
+			assert.Equal(t, 7, pkg.VersionCount)
+			require.NotNil(t, pkg.Owner)
+			assert.Equal(t, "testuser", pkg.Owner.Login)
+		})
+	}
+}
+
+func TestGitHubClient_APIError(t *testing.T) {
+	tests := []struct {
+		name    string
+		status  int
+		body    string
+		call    func(*GitHubClient) error
+		wantErr GitHubAPIError
+	}{
+		{
+			name:   "bad credentials",
+			status: http.StatusUnauthorized,
+			body:   `{"message":"Bad credentials","documentation_url":"https://docs.github.com/rest","status":"401"}`,
+			call: func(gc *GitHubClient) error {
+				_, err := gc.GetPackage(context.Background(), "my-app")
+				return err
+			},
+			wantErr: GitHubAPIError{Status: http.StatusUnauthorized, Message: "Bad credentials", DocumentationURL: "https://docs.github.com/rest"},
+		},
+		{
+			name:   "validation failed",
+			status: http.StatusUnprocessableEntity,
+			body:   `{"message":"Validation Failed","errors":[{"resource":"Package","field":"package_type","code":"invalid"},"state is not a valid value"],"documentation_url":"https://docs.github.com/rest/packages/packages#list-package-versions-for-a-package-owned-by-the-authenticated-user"}`,
+			call: func(gc *GitHubClient) error {
+				_, err := gc.ListPackageVersions(context.Background(), "my-app", "bogus", nil)
+				return err
+			},
+			wantErr: GitHubAPIError{
+				Status:           http.StatusUnprocessableEntity,
+				Message:          "Validation Failed",
+				DocumentationURL: "https://docs.github.com/rest/packages/packages#list-package-versions-for-a-package-owned-by-the-authenticated-user",
+				Errors: []GitHubAPIFieldError{
+					{Resource: "Package", Field: "package_type", Code: "invalid"},
+					{Message: "state is not a valid value"},
+				},
+			},
+		},
+		{
+			name:   "delete forbidden",
+			status: http.StatusForbidden,
+			body:   `{"message":"You cannot delete a public package version with more than 5,000 downloads.","documentation_url":"https://docs.github.com/rest/packages/packages#delete-a-package-version-for-the-authenticated-user"}`,
+			call: func(gc *GitHubClient) error {
+				return gc.deletePackageVersion(context.Background(), "my-app", 42)
+			},
+			wantErr: GitHubAPIError{
+				Status:           http.StatusForbidden,
+				Message:          "You cannot delete a public package version with more than 5,000 downloads.",
+				DocumentationURL: "https://docs.github.com/rest/packages/packages#delete-a-package-version-for-the-authenticated-user",
+			},
+		},
+		{
+			name:   "not a GitHub error document",
+			status: http.StatusBadGateway,
+			body:   `<html>bad gateway</html>`,
+			call: func(gc *GitHubClient) error {
+				_, err := gc.GetCatalog(context.Background(), nil)
+				return err
+			},
+			wantErr: GitHubAPIError{Status: http.StatusBadGateway},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				w.Header().Set("Content-Type", "application/json; charset=utf-8")
+				w.WriteHeader(tt.status)
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			client := NewGitHubClient("testuser", "test-token")
+			client.api.(*githubPackagesAPI).baseURL = server.URL
+
+			err := tt.call(client)
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.body, "raw body is kept in the message")
+
+			var apiErr *GitHubAPIError
+			require.ErrorAs(t, err, &apiErr)
+			assert.Equal(t, tt.wantErr.Status, apiErr.Status)
+			assert.Equal(t, tt.wantErr.Message, apiErr.Message)
+			assert.Equal(t, tt.wantErr.DocumentationURL, apiErr.DocumentationURL)
+			assert.Equal(t, tt.wantErr.Errors, apiErr.Errors)
+		})
+	}
+}
+
+func TestGitHubClient_ListPackages_Visibility(t *testing.T) {
+	var requests []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "/orgs/myorg/packages", r.URL.Path)
+		requests = append(requests, r.URL.Query().Get("visibility"))
+		// The API already filters by visibility; a stray public package checks the client-side filter
+		_ = json.NewEncoder(w).Encode([]GitHubPackage{
+			{Name: "billing", Visibility: "private"},
+			{Name: "docs", Visibility: "public"},
+			{Name: "payroll", Visibility: "private"},
+		})
+	}))
+	defer server.Close()
+
+	client := NewGitHubOrgClient("myorg", "test-token")
+	client.api.(*githubPackagesAPI).baseURL = server.URL
+
+	resp, err := client.ListPackages(context.Background(), PackageVisibilityPrivate, nil)
+	require.NoError(t, err)
+	assert.Equal(t, []GitHubPackage{{Name: "billing", Visibility: "private"}, {Name: "payroll", Visibility: "private"}}, resp.Packages)
+
+	resp, err = client.ListPackages(context.Background(), "", nil)
+	require.NoError(t, err)
+	assert.Len(t, resp.Packages, 3)
+	assert.Equal(t, []string{"private", ""}, requests, "no visibility param without a filter")
+
+	_, err = client.ListPackages(context.Background(), "secret", nil)
+	require.ErrorContains(t, err, `invalid package visibility: "secret"`)
+	assert.Len(t, requests, 2)
+}
+
+func TestGitHubClient_VersionCount(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(`[
+			{"id":1,"name":"api","package_type":"container","visibility":"private","version_count":12},
+			{"id":2,"name":"web","package_type":"container","visibility":"public","version_count":3},
+			{"id":3,"name":"old","package_type":"container","visibility":"public"}
+		]`))
+	}))
+	defer server.Close()
+
+	client := NewGitHubOrgClient("myorg", "test-token")
+	client.api.(*githubPackagesAPI).baseURL = server.URL
+
+	packages, err := client.ListPackages(context.Background(), "", nil)
+	require.NoError(t, err)
+	require.Len(t, packages.Packages, 3)
+	assert.Equal(t, 12, packages.Packages[0].VersionCount)
+	assert.Equal(t, 3, packages.Packages[1].VersionCount)
+	assert.Zero(t, packages.Packages[2].VersionCount)
+
+	catalog, err := client.GetCatalog(context.Background(), nil)
+	require.NoError(t, err)
+	assert.Equal(t, map[string]int{"myorg/api": 12, "myorg/web": 3}, catalog.VersionCounts)
+}
+
+func TestGitHubClient_CountUntaggedVersions(t *testing.T) {
+	var deletes int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			deletes++
+		}
+		assert.Equal(t, "/user/packages/container/textbee%2Fapi/versions", r.URL.EscapedPath())
+
+		// Page 1: 100 versions, every third one tagged; page 2: one tagged, two untagged
+		var versions []GitHubPackageVersion
+		switch r.URL.Query().Get("page") {
+		case "1":
+			for i := range 100 {
+				v := GitHubPackageVersion{ID: i + 1, Name: fmt.Sprintf("sha256:%d", i)}
+				if i%3 == 0 {
+					v.Metadata.Container.Tags = []string{fmt.Sprintf("v%d", i)}
+				}
+				versions = append(versions, v)
+			}
+		case "2":
+			versions = []GitHubPackageVersion{
+				{ID: 101, Metadata: GitHubPackageMetadata{Container: GitHubContainerMetadata{Tags: []string{"latest"}}}},
+				{ID: 102},
+				{ID: 103, Metadata: GitHubPackageMetadata{Container: GitHubContainerMetadata{Tags: []string{}}}},
+			}
+		}
+		_ = json.NewEncoder(w).Encode(versions)
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("testuser", "test-token")
+	client.api = &githubPackagesAPI{
+		baseClient: client.BaseClient,
+		apiToken:   "test-token",
+		baseURL:    server.URL,
+	}
+
+	count, err := client.CountUntaggedVersions(context.Background(), "testuser/textbee/api")
+	require.NoError(t, err)
+	assert.Equal(t, 66+2, count)
+	assert.Zero(t, deletes)
+}
+
+func TestGitHubClient_CountUntaggedVersions_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusInternalServerError)
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("testuser", "test-token")
+	client.api = &githubPackagesAPI{
+		baseClient: client.BaseClient,
+		apiToken:   "test-token",
+		baseURL:    server.URL,
+	}
+
+	count, err := client.CountUntaggedVersions(context.Background(), "testuser/my-app")
+	require.Error(t, err)
+	assert.Zero(t, count)
+}
+
+func TestPackageNameFromRepository(t *testing.T) {
+	assert.Equal(t, "textbee/api", packageNameFromRepository("eznix86/textbee/api"))
+	assert.Equal(t, "app", packageNameFromRepository("org/app"))
+	assert.Equal(t, "app", packageNameFromRepository("app"))
+}
+
+func TestGitHubClient_PruneVersions(t *testing.T) {
+	tagged := func(tags ...string) GitHubPackageMetadata {
+		return GitHubPackageMetadata{Container: GitHubContainerMetadata{Tags: tags}}
+	}
+	// Served out of order; newest first the IDs are 5, 4, 3, 2, 1
+	versions := []GitHubPackageVersion{
+		{ID: 3, CreatedAt: "2024-03-01T00:00:00Z"},
+		{ID: 1, CreatedAt: "2024-01-01T00:00:00Z"},
+		{ID: 5, CreatedAt: "2024-05-01T00:00:00Z", Metadata: tagged("latest")},
+		{ID: 2, CreatedAt: "2024-02-01T00:00:00Z", Metadata: tagged("v1")},
+		{ID: 4, CreatedAt: "2024-04-01T00:00:00Z"},
+	}
+
+	tests := []struct {
+		name          string
+		keep          int
+		pruneTagged   bool
+		disableDelete bool
+		wantDeleted   int
+		wantDeletes   []string
+	}{
+		{name: "keep none deletes untagged", keep: 0, wantDeleted: 3, wantDeletes: []string{"4", "3", "1"}},
+		{name: "keep two", keep: 2, wantDeleted: 2, wantDeletes: []string{"3", "1"}},
+		{name: "keep all but one", keep: 4, wantDeleted: 1, wantDeletes: []string{"1"}},
+		{name: "keep exactly all", keep: 5, wantDeleted: 0},
+		{name: "keep more than exist", keep: 10, wantDeleted: 0},
+		{name: "prune tagged", keep: 2, pruneTagged: true, wantDeleted: 3, wantDeletes: []string{"3", "2", "1"}},
+		{name: "dry run", keep: 2, disableDelete: true, wantDeleted: 2},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var deletes []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if r.Method == http.MethodDelete {
+					deletes = append(deletes, path.Base(r.URL.Path))
+					w.WriteHeader(http.StatusNoContent)
+					return
+				}
+				_ = json.NewEncoder(w).Encode(versions)
+			}))
+			defer server.Close()
+
+			client := NewGitHubClient("testuser", "test-token")
+			client.PruneTagged = tt.pruneTagged
+			client.DisableDelete = tt.disableDelete
+			client.api = &githubPackagesAPI{
+				baseClient: client.BaseClient,
+				apiToken:   "test-token",
+				baseURL:    server.URL,
+			}
+
+			deleted, err := client.PruneVersions(context.Background(), "testuser/my-app", tt.keep)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDeleted, deleted)
+			assert.Equal(t, tt.wantDeletes, deletes)
+		})
+	}
+}
+
+func TestGitHubClient_PruneVersions_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodDelete {
+			if path.Base(r.URL.Path) == "1" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		_ = json.NewEncoder(w).Encode([]GitHubPackageVersion{
+			{ID: 1, CreatedAt: "2024-01-01T00:00:00Z"},
+			{ID: 2, CreatedAt: "2024-02-01T00:00:00Z"},
+		})
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("testuser", "test-token")
+	client.api = &githubPackagesAPI{
+		baseClient: client.BaseClient,
+		apiToken:   "test-token",
+		baseURL:    server.URL,
+	}
+
+	t.Run("negative keep", func(t *testing.T) {
+		_, err := client.PruneVersions(context.Background(), "testuser/my-app", -1)
+		require.Error(t, err)
+	})
+
+	t.Run("delete failure reports partial count", func(t *testing.T) {
+		deleted, err := client.PruneVersions(context.Background(), "testuser/my-app", 0)
+		require.Error(t, err)
+		assert.Equal(t, 1, deleted)
+	})
+}
+
+// untaggedVersions returns n untagged package versions with IDs 1 to n, newest last
+func untaggedVersions(n int) []GitHubPackageVersion {
+	versions := make([]GitHubPackageVersion, n)
+	for i := range versions {
+		versions[i] = GitHubPackageVersion{ID: i + 1, CreatedAt: fmt.Sprintf("2024-01-%02dT00:00:00Z", i+1)}
+	}
+	return versions
+}
+
+func TestGitHubClient_PruneVersions_RateLimit(t *testing.T) {
+	var mu sync.Mutex
+	var deleteRequests int
+	deleted := map[string]time.Time{}
+	var limitedID string
+	var reset time.Time
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			_ = json.NewEncoder(w).Encode(untaggedVersions(6))
+			return
+		}
+		mu.Lock()
+		defer mu.Unlock()
+		deleteRequests++
+		// The third deletion exhausts the primary rate limit
+		if deleteRequests == 3 {
+			limitedID = path.Base(r.URL.Path)
+			reset = time.Unix(time.Now().Unix()+1, 0)
+			w.Header().Set("X-RateLimit-Remaining", "0")
+			w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(reset.Unix(), 10))
+			w.WriteHeader(http.StatusForbidden)
+			_, _ = w.Write([]byte(`{"message":"API rate limit exceeded"}`))
+			return
+		}
+		deleted[path.Base(r.URL.Path)] = time.Now()
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("testuser", "test-token")
+	client.DeleteConcurrency = 2
+	client.api = &githubPackagesAPI{
+		baseClient: client.BaseClient,
+		apiToken:   "test-token",
+		baseURL:    server.URL,
+	}
+
+	count, err := client.PruneVersions(context.Background(), "testuser/my-app", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 6, count)
+	assert.Len(t, deleted, 6)
+	assert.Equal(t, 7, deleteRequests, "the rate limited deletion is retried once")
+	require.Contains(t, deleted, limitedID)
+	assert.False(t, deleted[limitedID].Before(reset), "retried before X-RateLimit-Reset")
+}
+
+func TestGitHubClient_PruneVersions_RateLimitExhausted(t *testing.T) {
+	var deleteRequests atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			_ = json.NewEncoder(w).Encode(untaggedVersions(3))
+			return
+		}
+		deleteRequests.Add(1)
+		// A reset in the past does not hold the retries back
+		w.Header().Set("X-RateLimit-Remaining", "0")
+		w.Header().Set("X-RateLimit-Reset", strconv.FormatInt(time.Now().Add(-time.Minute).Unix(), 10))
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("testuser", "test-token")
+	client.api = &githubPackagesAPI{
+		baseClient: client.BaseClient,
+		apiToken:   "test-token",
+		baseURL:    server.URL,
+	}
+
+	count, err := client.PruneVersions(context.Background(), "testuser/my-app", 0)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "rate limited")
+	assert.Zero(t, count)
+	assert.Equal(t, int32(githubRateLimitRetries+1), deleteRequests.Load(), "no deletion starts after a failure")
+}
+
+func TestGitHubClient_PruneVersions_Concurrency(t *testing.T) {
+	var inFlight, maxInFlight atomic.Int32
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodDelete {
+			_ = json.NewEncoder(w).Encode(untaggedVersions(12))
+			return
+		}
+		current := inFlight.Add(1)
+		defer inFlight.Add(-1)
+		for {
+			highest := maxInFlight.Load()
+			if current <= highest || maxInFlight.CompareAndSwap(highest, current) {
+				break
+			}
+		}
+		time.Sleep(20 * time.Millisecond)
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	defer server.Close()
+
+	client := NewGitHubClient("testuser", "test-token")
+	client.DeleteConcurrency = 4
+	client.api = &githubPackagesAPI{
+		baseClient: client.BaseClient,
+		apiToken:   "test-token",
+		baseURL:    server.URL,
+	}
+
+	count, err := client.PruneVersions(context.Background(), "testuser/my-app", 0)
+	require.NoError(t, err)
+	assert.Equal(t, 12, count)
+	assert.LessOrEqual(t, maxInFlight.Load(), int32(4))
+	assert.Greater(t, maxInFlight.Load(), int32(1))
+}
+
+func TestGitHubRateLimitWait(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      int
+		headers     map[string]string
+		wantLimited bool
+		wantMin     time.Duration
+		wantMax     time.Duration
+	}{
+		{name: "success", status: http.StatusNoContent},
+		{name: "permission denied", status: http.StatusForbidden},
+		{name: "quota left", status: http.StatusForbidden, headers: map[string]string{"X-RateLimit-Remaining": "10"}},
+		{
+			name: "primary rate limit", status: http.StatusForbidden, wantLimited: true,
+			headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": strconv.FormatInt(time.Now().Add(time.Hour).Unix(), 10)},
+			wantMin: 59 * time.Minute, wantMax: time.Hour,
+		},
+		{
+			name: "reset passed", status: http.StatusForbidden, wantLimited: true,
+			headers: map[string]string{"X-RateLimit-Remaining": "0", "X-RateLimit-Reset": "1"},
+		},
+		{
+			name: "missing reset", status: http.StatusForbidden, wantLimited: true,
+			headers: map[string]string{"X-RateLimit-Remaining": "0"},
+			wantMin: time.Minute, wantMax: time.Minute,
+		},
+		{
+			name: "secondary rate limit", status: http.StatusForbidden, wantLimited: true,
+			headers: map[string]string{"Retry-After": "30"},
+			wantMin: 30 * time.Second, wantMax: 30 * time.Second,
+		},
+		{
+			name: "too many requests", status: http.StatusTooManyRequests, wantLimited: true,
+			headers: map[string]string{"Retry-After": "5"},
+			wantMin: 5 * time.Second, wantMax: 5 * time.Second,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp := &http.Response{StatusCode: tt.status, Header: http.Header{}}
+			for k, v := range tt.headers {
+				resp.Header.Set(k, v)
+			}
+
+			wait, limited := githubRateLimitWait(resp)
+			assert.Equal(t, tt.wantLimited, limited)
+			assert.GreaterOrEqual(t, wait, tt.wantMin)
+			assert.LessOrEqual(t, wait, tt.wantMax)
+		})
+	}
+}
+
 func TestGitHubClient_ListPackageVersions_NetworkError(t *testing.T) {
 	client := NewGitHubClient("testuser", "test-token")
 	client.HTTPClient.Transport = &fakeRoundTripper{}
 
-	_, err := client.listPackageVersions(context.Background(), "my-app", nil)
+	_, err := client.ListPackageVersions(context.Background(), "my-app", PackageStateActive, nil)
 	require.Error(t, err)
 }
 
@@ -1038,7 +1820,7 @@ func TestGitHubClient_ListPackageVersions_StatusError(t *testing.T) {
 		baseURL:    server.URL,
 	}
 
-	_, err := client.listPackageVersions(context.Background(), "my-app", nil)
+	_, err := client.ListPackageVersions(context.Background(), "my-app", PackageStateActive, nil)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "list package versions failed")
 }
@@ -1057,7 +1839,7 @@ func TestGitHubClient_ListPackageVersions_InvalidJSON(t *testing.T) {
 		baseURL:    server.URL,
 	}
 
-	_, err := client.listPackageVersions(context.Background(), "my-app", nil)
+	_, err := client.ListPackageVersions(context.Background(), "my-app", PackageStateActive, nil)
 	require.Error(t, err)
 }
 
@@ -1080,13 +1862,16 @@ func TestGitHubClient_DeletePackageVersion_NotFound(t *testing.T) {
 }
 
 func TestGitHubClient_DeletePackageVersion_UnexpectedStatus(t *testing.T) {
+	var requests int
 	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
 		w.WriteHeader(http.StatusInternalServerError)
 		_, _ = w.Write([]byte(`{"message":"internal error"}`))
 	}))
 	defer server.Close()
 
 	client := NewGitHubClient("testuser", "test-token")
+	client.RetryBackoff = time.Millisecond
 	client.api = &githubPackagesAPI{
 		baseClient: client.BaseClient,
 		apiToken:   "test-token",
@@ -1096,6 +1881,45 @@ func TestGitHubClient_DeletePackageVersion_UnexpectedStatus(t *testing.T) {
 	err := client.deletePackageVersion(context.Background(), "my-app", 123)
 	require.Error(t, err)
 	assert.Contains(t, err.Error(), "delete package version failed")
+	assert.Equal(t, 1+githubServerErrorRetries, requests)
+}
+
+func TestGitHubClient_DeletePackageVersion_ServerErrorRetry(t *testing.T) {
+	tests := []struct {
+		name     string
+		statuses []int
+		wantErr  string
+	}{
+		{name: "deleted on retry", statuses: []int{http.StatusBadGateway, http.StatusNoContent}},
+		{name: "already deleted by the failed request", statuses: []int{http.StatusServiceUnavailable, http.StatusNotFound}},
+		{name: "not found without a server error", statuses: []int{http.StatusNotFound}, wantErr: "package version not found"},
+		{name: "permission error after retry", statuses: []int{http.StatusServiceUnavailable, http.StatusForbidden}, wantErr: "insufficient permissions"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var requests int
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodDelete, r.Method)
+				w.WriteHeader(tt.statuses[min(requests, len(tt.statuses)-1)])
+				requests++
+			}))
+			defer server.Close()
+
+			client := NewGitHubClient("testuser", "test-token")
+			client.RetryBackoff = time.Millisecond
+			client.api.(*githubPackagesAPI).baseURL = server.URL
+
+			err := client.deletePackageVersion(context.Background(), "my-app", 123)
+			assert.Equal(t, len(tt.statuses), requests)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
 }
 
 //nolint:dupl // Test patterns are similar but test different client types and paths
@@ -1220,3 +2044,86 @@ func TestGitHubClient_DeleteManifest_DisableDelete(t *testing.T) {
 	require.NoError(t, err)
 	assert.False(t, deleteCalled, "DELETE should not have been called when DisableDelete is true")
 }
+
+func TestGitHubClient_APIVersion(t *testing.T) {
+	tests := []struct {
+		name       string
+		apiVersion string
+		want       string
+	}{
+		{name: "default", apiVersion: DefaultGitHubAPIVersion, want: "2022-11-28"},
+		{name: "configured", apiVersion: "2026-03-10", want: "2026-03-10"},
+		{name: "empty falls back to default", apiVersion: "", want: "2022-11-28"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var versions []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				versions = append(versions, r.Method+" "+r.Header.Get("X-GitHub-Api-Version"))
+				switch {
+				case r.Method == http.MethodDelete:
+					w.WriteHeader(http.StatusNoContent)
+				case strings.HasSuffix(r.URL.Path, "/versions"):
+					_ = json.NewEncoder(w).Encode([]GitHubPackageVersion{{ID: 1, Name: "sha256:ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"}})
+				default:
+					_ = json.NewEncoder(w).Encode([]GitHubPackage{})
+				}
+			}))
+			defer server.Close()
+
+			client := NewGitHubClient("testuser", "test-token")
+			client.api.(*githubPackagesAPI).baseURL = server.URL
+			client.APIVersion = tt.apiVersion
+
+			_, err := client.GetCatalog(context.Background(), nil)
+			require.NoError(t, err)
+			require.NoError(t, client.DeleteManifest(context.Background(), "testuser/app", "sha256:ba7816bf8f01cfea414140de5dae2223b00361a396177a9cb410ff61f20015ad"))
+
+			assert.Equal(t, []string{"GET " + tt.want, "GET " + tt.want, "DELETE " + tt.want}, versions)
+		})
+	}
+}
+
+func TestSetGitHubHeaders(t *testing.T) {
+	req, err := http.NewRequest(http.MethodGet, "https://api.github.com/user/packages", nil)
+	require.NoError(t, err)
+
+	setGitHubHeaders(req, "test-token", "")
+
+	assert.Equal(t, "Bearer test-token", req.Header.Get("Authorization"))
+	assert.Equal(t, "application/vnd.github+json", req.Header.Get("Accept"))
+	assert.Equal(t, DefaultGitHubAPIVersion, req.Header.Get("X-GitHub-Api-Version"))
+}
+
+func TestGitHubClient_DefaultPageSize(t *testing.T) {
+	tests := []struct {
+		name            string
+		defaultPageSize int
+		wantPerPage     string
+	}{
+		{name: "default", defaultPageSize: 0, wantPerPage: "100"},
+		{name: "smaller", defaultPageSize: 30, wantPerPage: "30"},
+		{name: "capped at the API maximum", defaultPageSize: 1000, wantPerPage: "100"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var perPage []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				perPage = append(perPage, r.URL.Query().Get("per_page"))
+				_ = json.NewEncoder(w).Encode([]GitHubPackageVersion{{ID: 1, Name: "sha256:abc"}})
+			}))
+			defer server.Close()
+
+			client := NewGitHubClient("testuser", "test-token")
+			client.api.(*githubPackagesAPI).baseURL = server.URL
+			client.DefaultPageSize = tt.defaultPageSize
+
+			count, err := client.CountUntaggedVersions(context.Background(), "testuser/app")
+			require.NoError(t, err)
+			assert.Equal(t, 1, count)
+			assert.Equal(t, []string{tt.wantPerPage}, perPage)
+		})
+	}
+}
@@ -2,7 +2,11 @@ module github.com/eznix86/registry-client
 
 go 1.25.2
 
-require github.com/stretchr/testify v1.11.1
+require (
+	github.com/klauspost/compress v1.18.0
+	github.com/stretchr/testify v1.11.1
+	golang.org/x/sync v0.19.0
+)
 
 require (
 	github.com/davecgh/go-spew v1.1.1 // indirect
@@ -0,0 +1,372 @@
+package registryclient
+
+import (
+	"archive/tar"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"path"
+	"slices"
+	"strings"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// GetManifestForPlatform retrieves the image manifest for a platform.
+// When the reference is an index, the child manifest matching platform is fetched;
+// a single image manifest is returned as-is.
+func (c *BaseClient) GetManifestForPlatform(ctx context.Context, repository, reference string, platform Platform) (*ManifestResponse, error) {
+	manifest, err := c.GetManifest(ctx, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := manifest.ManifestData.(ManifestList)
+	if !ok {
+		return manifest, nil
+	}
+
+	for _, child := range list.Manifests {
+		if matchPlatform(child.Platform, platform) {
+			c.logDebug("Resolved platform manifest",
+				"operation", "GetManifestForPlatform",
+				"repository", repository,
+				"reference", reference,
+				"platform", formatPlatform(child.Platform),
+				"digest", child.Digest,
+			)
+			return c.getChildManifest(ctx, repository, child)
+		}
+	}
+
+	return nil, fmt.Errorf("no manifest found for platform %s in %s:%s", formatPlatform(platform), repository, reference)
+}
+
+// GetAllPlatformManifests fetches every child manifest of an index, concurrently, keyed by
+// os/arch[/variant]. Children without a platform and attestation manifests (platform
+// unknown/unknown) are skipped, and when two children share a key, the first one in the
+// index wins. The reference must be an index. At most 8 children are fetched at once, and
+// the first error is returned.
+func (c *BaseClient) GetAllPlatformManifests(ctx context.Context, repository, reference string) (map[string]*ManifestResponse, error) {
+	manifest, err := c.GetManifest(ctx, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	list, ok := manifest.ManifestData.(ManifestList)
+	if !ok {
+		return nil, fmt.Errorf("%s:%s is not an index", repository, reference)
+	}
+
+	keys, children := platformChildren(list)
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(defaultConcurrency)
+	manifests := make([]*ManifestResponse, len(children))
+	for i, child := range children {
+		g.Go(func() error {
+			manifest, err := c.getChildManifest(ctx, repository, child)
+			if err != nil {
+				return fmt.Errorf("platform %s: %w", keys[i], err)
+			}
+			manifests[i] = manifest
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]*ManifestResponse, len(children))
+	for i, key := range keys {
+		result[key] = manifests[i]
+	}
+
+	c.logDebug("Fetched platform manifests",
+		"operation", "GetAllPlatformManifests",
+		"repository", repository,
+		"reference", reference,
+		"platform_count", len(result),
+	)
+
+	return result, nil
+}
+
+// PlatformStrings returns the platforms of an image as os/arch[/variant] strings, e.g.
+// "linux/arm64/v8". For an index they are listed in index order, without children lacking a
+// platform, attestation manifests (unknown/unknown) or duplicates; a single image reports the
+// platform of its config.
+func (c *BaseClient) PlatformStrings(ctx context.Context, repository, reference string) ([]string, error) {
+	manifest, err := c.GetManifest(ctx, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	switch data := manifest.ManifestData.(type) {
+	case ManifestList:
+		platforms, _ := platformChildren(data)
+		return platforms, nil
+	case ImageManifest:
+		config, err := c.GetConfigBlob(ctx, repository, data.Config.Digest)
+		if err != nil {
+			return nil, err
+		}
+		return []string{platformKey(Platform{OS: config.OS, Architecture: config.Architecture, Variant: config.Variant})}, nil
+	default:
+		return nil, fmt.Errorf("%s:%s has no platforms (%s)", repository, reference, manifest.MediaType)
+	}
+}
+
+// platformChildren returns the os/arch[/variant] keys of the children of an index that run
+// on a platform, in index order, with the child each refers to. Children without a platform,
+// attestation manifests (unknown/unknown) and later children with a key already seen are skipped.
+func platformChildren(list ManifestList) ([]string, []ManifestReference) {
+	keys := []string{}
+	var children []ManifestReference
+	for _, child := range list.Manifests {
+		if child.Platform.OS == "" && child.Platform.Architecture == "" {
+			continue
+		}
+		key := platformKey(child.Platform)
+		if key == "unknown/unknown" || slices.Contains(keys, key) {
+			continue
+		}
+		keys = append(keys, key)
+		children = append(children, child)
+	}
+	return keys, children
+}
+
+// getChildManifest fetches a manifest referenced by an index descriptor
+func (c *BaseClient) getChildManifest(ctx context.Context, repository string, child ManifestReference) (*ManifestResponse, error) {
+	manifest, err := c.GetManifest(ctx, repository, child.Digest)
+	if err != nil {
+		return nil, err
+	}
+	if manifest.Digest == "" {
+		manifest.Digest = child.Digest
+	}
+	return manifest, nil
+}
+
+// matchPlatform reports whether candidate satisfies want.
+// OS and architecture must match; the variant only when want specifies one.
+// The candidate must have every OS feature and feature that want lists.
+func matchPlatform(candidate, want Platform) bool {
+	if candidate.OS != want.OS || candidate.Architecture != want.Architecture {
+		return false
+	}
+	if want.Variant != "" && candidate.Variant != want.Variant {
+		return false
+	}
+	return hasAll(candidate.OSFeatures, want.OSFeatures) && hasAll(candidate.Features, want.Features)
+}
+
+// hasAll reports whether have contains every element of want
+func hasAll(have, want []string) bool {
+	for _, w := range want {
+		if !slices.Contains(have, w) {
+			return false
+		}
+	}
+	return true
+}
+
+// platformKey renders a platform as os/arch[/variant]
+func platformKey(p Platform) string {
+	key := p.OS + "/" + p.Architecture
+	if p.Variant != "" {
+		key += "/" + p.Variant
+	}
+	return key
+}
+
+// formatPlatform renders a platform as os/arch[/variant], followed by any features
+func formatPlatform(p Platform) string {
+	s := platformKey(p)
+	if features := slices.Concat(p.OSFeatures, p.Features); len(features) > 0 {
+		s += " [" + strings.Join(features, ",") + "]"
+	}
+	return s
+}
+
+// Inspect resolves a reference to an image and returns its digest, creation time,
+// platform, size and labels. For an index, platform selects the image; when platform
+// is nil the result only describes the index and Partial is set.
+func (c *BaseClient) Inspect(ctx context.Context, repository, reference string, platform *Platform) (*ImageInspect, error) {
+	var manifest *ManifestResponse
+	var err error
+	if platform != nil {
+		manifest, err = c.GetManifestForPlatform(ctx, repository, reference, *platform)
+	} else {
+		manifest, err = c.GetManifest(ctx, repository, reference)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	image, ok := manifest.ManifestData.(ImageManifest)
+	if !ok {
+		return &ImageInspect{
+			Digest:    manifest.Digest,
+			MediaType: manifest.MediaType,
+			Partial:   true,
+		}, nil
+	}
+
+	config, err := c.GetConfigBlob(ctx, repository, image.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	var size int64
+	for _, layer := range image.Layers {
+		size += layer.Size
+	}
+
+	return &ImageInspect{
+		Digest:       manifest.Digest,
+		MediaType:    manifest.MediaType,
+		Created:      config.Created,
+		Architecture: config.Architecture,
+		OS:           config.OS,
+		Size:         size,
+		Labels:       config.Config.Labels,
+	}, nil
+}
+
+// GetLayerInfo returns the layers of an image manifest paired, in order, with the
+// diff_ids of its config. reference must resolve to an image manifest, not an index.
+func (c *BaseClient) GetLayerInfo(ctx context.Context, repository, reference string) ([]LayerInfo, error) {
+	manifest, err := c.GetManifest(ctx, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	image, ok := manifest.ManifestData.(ImageManifest)
+	if !ok {
+		return nil, fmt.Errorf("get layer info failed: %s:%s is not an image manifest (%s), resolve a platform first", repository, reference, manifest.MediaType)
+	}
+
+	config, err := c.GetConfigBlob(ctx, repository, image.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+	if len(config.Rootfs.DiffIDs) != len(image.Layers) {
+		return nil, fmt.Errorf("get layer info failed: manifest has %d layers but config %s has %d diff_ids",
+			len(image.Layers), image.Config.Digest, len(config.Rootfs.DiffIDs))
+	}
+
+	layers := make([]LayerInfo, len(image.Layers))
+	for i, layer := range image.Layers {
+		layers[i] = LayerInfo{
+			Digest:    layer.Digest,
+			Size:      layer.Size,
+			MediaType: layer.MediaType,
+			DiffID:    config.Rootfs.DiffIDs[i],
+		}
+	}
+	return layers, nil
+}
+
+// ErrFileNotFound is returned by ExtractFile when no layer of the image contains the path,
+// or when an upper layer deleted it
+var ErrFileNotFound = errors.New("file not found in image")
+
+// ExtractFile returns the contents of filePath in the filesystem of an image, as its layers
+// stack up: layers are searched from the top, and a whiteout of the path (or of one of its
+// directories) in an upper layer hides it in the lower ones. reference must resolve to an
+// image manifest, not an index. Layers below the one holding the file are not read.
+func (c *BaseClient) ExtractFile(ctx context.Context, repository, reference, filePath string) ([]byte, error) {
+	target := cleanLayerPath(filePath)
+	if target == "" {
+		return nil, fmt.Errorf("extract file failed: invalid path %q", filePath)
+	}
+
+	manifest, err := c.GetManifest(ctx, repository, reference)
+	if err != nil {
+		return nil, err
+	}
+
+	image, ok := manifest.ManifestData.(ImageManifest)
+	if !ok {
+		return nil, fmt.Errorf("extract file failed: %s:%s is not an image manifest (%s), resolve a platform first", repository, reference, manifest.MediaType)
+	}
+
+	for _, layer := range slices.Backward(image.Layers) {
+		content, found, hidden, err := c.findLayerFile(ctx, repository, layer, target)
+		if err != nil {
+			return nil, err
+		}
+		if found {
+			c.logDebug("Extracted file",
+				"operation", "ExtractFile",
+				"repository", repository,
+				"reference", reference,
+				"path", target,
+				"layer", layer.Digest,
+			)
+			return content, nil
+		}
+		if hidden {
+			break
+		}
+	}
+	return nil, fmt.Errorf("%w: %s in %s:%s", ErrFileNotFound, filePath, repository, reference)
+}
+
+// findLayerFile looks for target in a single layer. found reports that the layer holds the
+// file and content its bytes; hidden that the layer deletes target from the layers below
+// through a whiteout. The layer is always read to the end so its digest is verified.
+func (c *BaseClient) findLayerFile(ctx context.Context, repository string, layer Layer, target string) (content []byte, found, hidden bool, err error) {
+	reader, err := c.GetLayerUncompressed(ctx, repository, layer.Digest, layer.MediaType)
+	if err != nil {
+		return nil, false, false, err
+	}
+	defer c.closeBody(reader)
+
+	tr := tar.NewReader(reader)
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, false, fmt.Errorf("extract file failed: layer %s: %w", layer.Digest, err)
+		}
+
+		name := cleanLayerPath(header.Name)
+		dir, base := path.Split(name)
+		switch {
+		case name == target:
+			if !header.FileInfo().Mode().IsRegular() {
+				return nil, false, false, fmt.Errorf("extract file failed: %s in layer %s is not a regular file", target, layer.Digest)
+			}
+			if c.MaxBlobBytes > 0 && header.Size > c.MaxBlobBytes {
+				return nil, false, false, fmt.Errorf("%w: %s is %d bytes, exceeds limit of %d", ErrBlobTooLarge, target, header.Size, c.MaxBlobBytes)
+			}
+			if content, err = io.ReadAll(tr); err != nil {
+				return nil, false, false, fmt.Errorf("extract file failed: layer %s: %w", layer.Digest, err)
+			}
+			found = true
+		case base == opaqueWhiteout:
+			// Opaque whiteout: the directory hides everything below it in lower layers
+			hidden = hidden || strings.HasPrefix(target, dir)
+		case strings.HasPrefix(base, whiteoutPrefix):
+			deleted := dir + strings.TrimPrefix(base, whiteoutPrefix)
+			hidden = hidden || target == deleted || strings.HasPrefix(target, deleted+"/")
+		}
+	}
+
+	if _, err := io.Copy(io.Discard, reader); err != nil {
+		return nil, false, false, fmt.Errorf("extract file failed: layer %s: %w", layer.Digest, err)
+	}
+	return content, found, hidden, nil
+}
+
+// cleanLayerPath normalizes a path to the form of tar entry names: relative to the
+// image root, without "./" or trailing slashes
+func cleanLayerPath(p string) string {
+	return strings.TrimPrefix(path.Clean("/"+p), "/")
+}
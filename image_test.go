@@ -0,0 +1,414 @@
+package registryclient
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestGetManifestForPlatform(t *testing.T) {
+	registry := newFakeRegistry(t)
+	amd64 := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("amd64 layer")})
+	armv7 := registry.addImage(t, ConfigBlob{Architecture: "arm", OS: "linux"}, [][]byte{[]byte("armv7 layer")})
+	armv6 := registry.addImage(t, ConfigBlob{Architecture: "arm", OS: "linux"}, [][]byte{[]byte("armv6 layer")})
+	registry.addIndex(t, map[string]Platform{
+		amd64: {OS: "linux", Architecture: "amd64"},
+		armv7: {OS: "linux", Architecture: "arm", Variant: "v7"},
+		armv6: {OS: "linux", Architecture: "arm", Variant: "v6"},
+	}, "latest")
+	single := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, nil, "single")
+
+	tests := []struct {
+		name       string
+		reference  string
+		platform   Platform
+		wantDigest string
+		wantErr    bool
+	}{
+		{name: "amd64", reference: "latest", platform: Platform{OS: "linux", Architecture: "amd64"}, wantDigest: amd64},
+		{name: "arm variant", reference: "latest", platform: Platform{OS: "linux", Architecture: "arm", Variant: "v6"}, wantDigest: armv6},
+		{name: "missing platform", reference: "latest", platform: Platform{OS: "windows", Architecture: "amd64"}, wantErr: true},
+		{name: "single manifest returned as-is", reference: "single", platform: Platform{OS: "linux", Architecture: "arm64"}, wantDigest: single},
+		{name: "unknown reference", reference: "missing", platform: Platform{OS: "linux", Architecture: "amd64"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := registry.client().GetManifestForPlatform(context.Background(), "app", tt.reference, tt.platform)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Nil(t, resp)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantDigest, resp.Digest)
+			assert.IsType(t, ImageManifest{}, resp.ManifestData)
+		})
+	}
+}
+
+func TestGetManifestForPlatform_Features(t *testing.T) {
+	index, err := os.ReadFile("testdata/manifests/windows-image-index.json")
+	require.NoError(t, err)
+
+	registry := newFakeRegistry(t)
+	registry.manifests["windows"] = index
+	for _, digest := range []string{"sha256:windows-base", "sha256:windows-win32k", "sha256:linux-arm64-sve"} {
+		registry.manifests[digest] = []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json"}`)
+	}
+
+	tests := []struct {
+		name       string
+		platform   Platform
+		wantDigest string
+		wantErr    bool
+	}{
+		{name: "no features picks first match", platform: Platform{OS: "windows", Architecture: "amd64"}, wantDigest: "sha256:windows-base"},
+		{name: "os feature", platform: Platform{OS: "windows", Architecture: "amd64", OSFeatures: []string{"win32k"}}, wantDigest: "sha256:windows-win32k"},
+		{name: "missing os feature", platform: Platform{OS: "windows", Architecture: "amd64", OSFeatures: []string{"other"}}, wantErr: true},
+		{name: "feature", platform: Platform{OS: "linux", Architecture: "arm64", Features: []string{"sve"}}, wantDigest: "sha256:linux-arm64-sve"},
+		{name: "missing feature", platform: Platform{OS: "linux", Architecture: "arm64", Features: []string{"sve", "sme"}}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			resp, err := registry.client().GetManifestForPlatform(context.Background(), "app", "windows", tt.platform)
+
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), formatPlatform(tt.platform))
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, resp)
+			assert.Equal(t, 1, registry.requestCount(http.MethodGet, "/manifests/"+tt.wantDigest))
+		})
+	}
+}
+
+func TestGetAllPlatformManifests(t *testing.T) {
+	registry := newFakeRegistry(t)
+	amd64 := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("amd64 layer")})
+	armv7 := registry.addImage(t, ConfigBlob{Architecture: "arm", OS: "linux"}, [][]byte{[]byte("armv7 layer")})
+	attestation := registry.addImage(t, ConfigBlob{}, nil)
+	registry.addIndex(t, map[string]Platform{
+		amd64:       {OS: "linux", Architecture: "amd64"},
+		armv7:       {OS: "linux", Architecture: "arm", Variant: "v7"},
+		attestation: {OS: "unknown", Architecture: "unknown"},
+	}, "latest")
+	registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, nil, "single")
+
+	t.Run("index", func(t *testing.T) {
+		manifests, err := registry.client().GetAllPlatformManifests(context.Background(), "app", "latest")
+		require.NoError(t, err)
+
+		require.Len(t, manifests, 2)
+		assert.Equal(t, amd64, manifests["linux/amd64"].Digest)
+		assert.Equal(t, armv7, manifests["linux/arm/v7"].Digest)
+		assert.IsType(t, ImageManifest{}, manifests["linux/arm/v7"].ManifestData)
+	})
+
+	t.Run("not an index", func(t *testing.T) {
+		_, err := registry.client().GetAllPlatformManifests(context.Background(), "app", "single")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "is not an index")
+	})
+
+	t.Run("missing child", func(t *testing.T) {
+		delete(registry.manifests, armv7)
+		_, err := registry.client().GetAllPlatformManifests(context.Background(), "app", "latest")
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "platform linux/arm/v7")
+	})
+}
+
+func TestGetAllPlatformManifests_Concurrency(t *testing.T) {
+	registry := newFakeRegistry(t)
+	missing := fmt.Sprintf("sha256:%064d", 0) // sorted first, failing while most children wait
+	children := map[string]Platform{missing: {OS: "linux", Architecture: "missing"}}
+	for i := range 3 * defaultConcurrency {
+		arch := fmt.Sprintf("arch%d", i)
+		children[registry.addImage(t, ConfigBlob{Architecture: arch, OS: "linux"}, nil)] = Platform{OS: "linux", Architecture: arch}
+	}
+	registry.addIndex(t, children, "latest")
+	peak := registry.trackInFlight()
+
+	_, err := registry.client().GetAllPlatformManifests(context.Background(), "app", "latest")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "platform linux/missing")
+	require.NotErrorIs(t, err, context.Canceled, "the first error is returned, not the cancellations it caused")
+	assert.LessOrEqual(t, peak.Load(), int32(defaultConcurrency))
+}
+
+func TestPlatformStrings(t *testing.T) {
+	registry := newFakeRegistry(t)
+	amd64 := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("amd64 layer")})
+	arm64 := registry.addImage(t, ConfigBlob{Architecture: "arm64", OS: "linux", Variant: "v8"}, [][]byte{[]byte("arm64 layer")})
+	attestation := registry.addImage(t, ConfigBlob{}, nil)
+	child := func(digest string, platform Platform) map[string]any {
+		return map[string]any{"mediaType": ociManifestMediaType, "digest": digest, "size": len(registry.manifests[digest]), "platform": platform}
+	}
+	registry.addManifest(t, map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     ociIndexMediaType,
+		"manifests": []any{
+			child(amd64, Platform{OS: "linux", Architecture: "amd64"}),
+			child(arm64, Platform{OS: "linux", Architecture: "arm64", Variant: "v8"}),
+			child(attestation, Platform{OS: "unknown", Architecture: "unknown"}),
+			child(amd64, Platform{OS: "linux", Architecture: "amd64", OSFeatures: []string{"sse4"}}),
+			map[string]any{"mediaType": ociManifestMediaType, "digest": attestation, "size": len(registry.manifests[attestation])}, // no platform
+		},
+	}, "latest")
+	registry.addImage(t, ConfigBlob{Architecture: "arm64", OS: "linux", Variant: "v8"}, nil, "single")
+
+	t.Run("index", func(t *testing.T) {
+		platforms, err := registry.client().PlatformStrings(context.Background(), "app", "latest")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"linux/amd64", "linux/arm64/v8"}, platforms)
+	})
+
+	t.Run("single image", func(t *testing.T) {
+		platforms, err := registry.client().PlatformStrings(context.Background(), "app", "single")
+		require.NoError(t, err)
+		assert.Equal(t, []string{"linux/arm64/v8"}, platforms)
+	})
+
+	t.Run("missing", func(t *testing.T) {
+		_, err := registry.client().PlatformStrings(context.Background(), "app", "missing")
+		require.Error(t, err)
+	})
+}
+
+func TestParseManifest_PlatformFeatures(t *testing.T) {
+	index, err := os.ReadFile("testdata/manifests/windows-image-index.json")
+	require.NoError(t, err)
+
+	m, err := ParseManifest(index)
+	require.NoError(t, err)
+
+	list, ok := m.ManifestData.(ManifestList)
+	require.True(t, ok)
+	require.Len(t, list.Manifests, 3)
+	assert.Empty(t, list.Manifests[0].Platform.OSFeatures)
+	assert.Equal(t, []string{"win32k"}, list.Manifests[1].Platform.OSFeatures)
+	assert.Equal(t, []string{"sve"}, list.Manifests[2].Platform.Features)
+	assert.Equal(t, "windows/amd64 [win32k]", formatPlatform(list.Manifests[1].Platform))
+}
+
+func TestInspect(t *testing.T) {
+	registry := newFakeRegistry(t)
+	config := ConfigBlob{
+		Architecture: "arm64",
+		OS:           "linux",
+		Created:      "2024-05-01T12:00:00Z",
+		Config:       ContainerConfig{Labels: map[string]string{"org.opencontainers.image.version": "1.2.3"}},
+	}
+	arm64 := registry.addImage(t, config, [][]byte{[]byte("layer one"), []byte("layer two!")}, "arm64-only")
+	indexDigest := registry.addIndex(t, map[string]Platform{arm64: {OS: "linux", Architecture: "arm64"}}, "latest")
+
+	client := registry.client()
+
+	t.Run("index with platform", func(t *testing.T) {
+		inspect, err := client.Inspect(context.Background(), "app", "latest", &Platform{OS: "linux", Architecture: "arm64"})
+
+		require.NoError(t, err)
+		assert.False(t, inspect.Partial)
+		assert.Equal(t, arm64, inspect.Digest)
+		assert.Equal(t, "application/vnd.oci.image.manifest.v1+json", inspect.MediaType)
+		assert.Equal(t, "2024-05-01T12:00:00Z", inspect.Created)
+		assert.Equal(t, "arm64", inspect.Architecture)
+		assert.Equal(t, "linux", inspect.OS)
+		assert.Equal(t, int64(19), inspect.Size)
+		assert.Equal(t, "1.2.3", inspect.Labels["org.opencontainers.image.version"])
+	})
+
+	t.Run("index without platform is partial", func(t *testing.T) {
+		inspect, err := client.Inspect(context.Background(), "app", "latest", nil)
+
+		require.NoError(t, err)
+		assert.True(t, inspect.Partial)
+		assert.Equal(t, indexDigest, inspect.Digest)
+		assert.Equal(t, "application/vnd.oci.image.index.v1+json", inspect.MediaType)
+		assert.Empty(t, inspect.Architecture)
+	})
+
+	t.Run("single image without platform", func(t *testing.T) {
+		inspect, err := client.Inspect(context.Background(), "app", "arm64-only", nil)
+
+		require.NoError(t, err)
+		assert.False(t, inspect.Partial)
+		assert.Equal(t, arm64, inspect.Digest)
+		assert.Equal(t, "arm64", inspect.Architecture)
+	})
+
+	t.Run("unknown reference", func(t *testing.T) {
+		inspect, err := client.Inspect(context.Background(), "app", "missing", nil)
+		require.Error(t, err)
+		assert.Nil(t, inspect)
+	})
+}
+
+func TestInspect_ConfigErrors(t *testing.T) {
+	registry := newFakeRegistry(t)
+	registry.addManifest(t, map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config":        map[string]any{"digest": "sha256:missing"},
+	}, "missing-config")
+	registry.addManifest(t, map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config":        map[string]any{"digest": registry.addBlob([]byte("{invalid"))},
+	}, "invalid-config")
+
+	for _, reference := range []string{"missing-config", "invalid-config"} {
+		t.Run(reference, func(t *testing.T) {
+			inspect, err := registry.client().Inspect(context.Background(), "app", reference, nil)
+			require.Error(t, err)
+			assert.Nil(t, inspect)
+		})
+	}
+}
+
+func TestGetLayerInfo(t *testing.T) {
+	registry := newFakeRegistry(t)
+
+	// Compressed layers and the diff_ids of their uncompressed content, as a build would produce
+	var layers [][]byte
+	var diffIDs []string
+	for _, content := range []string{"layer one tar", "layer two tar"} {
+		var compressed bytes.Buffer
+		gz := gzip.NewWriter(&compressed)
+		_, err := gz.Write([]byte(content))
+		require.NoError(t, err)
+		require.NoError(t, gz.Close())
+		layers = append(layers, compressed.Bytes())
+		diffIDs = append(diffIDs, sha256Digest([]byte(content)))
+	}
+	config := ConfigBlob{Architecture: "amd64", OS: "linux", Rootfs: RootFS{Type: "layers", DiffIDs: diffIDs}}
+	imageDigest := registry.addImage(t, config, layers, "v1")
+	registry.addIndex(t, map[string]Platform{imageDigest: {OS: "linux", Architecture: "amd64"}}, "multi")
+	registry.addImage(t, ConfigBlob{Rootfs: RootFS{Type: "layers", DiffIDs: diffIDs[:1]}}, layers, "mismatch")
+
+	client := registry.client()
+
+	t.Run("aligned", func(t *testing.T) {
+		info, err := client.GetLayerInfo(context.Background(), "app", "v1")
+		require.NoError(t, err)
+		require.Len(t, info, 2)
+
+		for i, layer := range info {
+			assert.Equal(t, sha256Digest(layers[i]), layer.Digest)
+			assert.Equal(t, int64(len(layers[i])), layer.Size)
+			assert.Equal(t, "application/vnd.oci.image.layer.v1.tar+gzip", layer.MediaType)
+
+			// The diff_id is the digest of the decompressed blob
+			blob, err := client.GetBlob(context.Background(), "app", layer.Digest)
+			require.NoError(t, err)
+			gz, err := gzip.NewReader(bytes.NewReader(blob.Content))
+			require.NoError(t, err)
+			uncompressed, err := io.ReadAll(gz)
+			require.NoError(t, err)
+			assert.Equal(t, sha256Digest(uncompressed), layer.DiffID)
+		}
+	})
+
+	t.Run("count mismatch", func(t *testing.T) {
+		_, err := client.GetLayerInfo(context.Background(), "app", "mismatch")
+		require.ErrorContains(t, err, "manifest has 2 layers but config")
+		assert.ErrorContains(t, err, "has 1 diff_ids")
+	})
+
+	t.Run("index", func(t *testing.T) {
+		_, err := client.GetLayerInfo(context.Background(), "app", "multi")
+		require.ErrorContains(t, err, "is not an image manifest")
+	})
+}
+
+func TestExtractFile(t *testing.T) {
+	registry := newFakeRegistry(t)
+	base := gzipBytes(t, tarLayer(t,
+		"etc/", "",
+		"etc/app.conf", "port=80\n",
+		"etc/hosts", "127.0.0.1 localhost\n",
+		"var/lib/app/data", "base data",
+		"opt/app/plugin", "base plugin",
+	))
+	top := gzipBytes(t, tarLayer(t,
+		"./etc/app.conf", "port=8080\n",
+		"etc/.wh.hosts", "",
+		"var/lib/.wh.app", "",
+		"opt/app/.wh..wh..opq", "",
+		"opt/app/other", "top other",
+	))
+	registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{base, top}, "v1")
+	registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{base}, "base")
+
+	tests := []struct {
+		name      string
+		reference string
+		path      string
+		want      string
+		wantIs    error
+		wantErr   string
+	}{
+		{name: "overwritten by top layer", reference: "v1", path: "etc/app.conf", want: "port=8080\n"},
+		{name: "absolute path", reference: "v1", path: "/etc/app.conf", want: "port=8080\n"},
+		{name: "lower layer only", reference: "base", path: "etc/app.conf", want: "port=80\n"},
+		{name: "added by top layer", reference: "v1", path: "/opt/app/other", want: "top other"},
+		{name: "file whiteout", reference: "v1", path: "/etc/hosts", wantIs: ErrFileNotFound},
+		{name: "directory whiteout", reference: "v1", path: "/var/lib/app/data", wantIs: ErrFileNotFound},
+		{name: "opaque whiteout", reference: "v1", path: "/opt/app/plugin", wantIs: ErrFileNotFound},
+		{name: "missing file", reference: "v1", path: "/etc/passwd", wantIs: ErrFileNotFound},
+		{name: "directory", reference: "v1", path: "/etc", wantErr: "is not a regular file"},
+		{name: "empty path", reference: "v1", path: "/", wantErr: "invalid path"},
+		{name: "missing image", reference: "v2", path: "/etc/app.conf", wantErr: "404"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			content, err := registry.client().ExtractFile(context.Background(), "app", tt.reference, tt.path)
+			if tt.wantIs != nil || tt.wantErr != "" {
+				require.Error(t, err)
+				if tt.wantIs != nil {
+					assert.ErrorIs(t, err, tt.wantIs)
+				}
+				assert.Contains(t, err.Error(), tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, string(content))
+		})
+	}
+}
+
+func TestExtractFile_StopsAtTopmostLayer(t *testing.T) {
+	registry := newFakeRegistry(t)
+	base := gzipBytes(t, tarLayer(t, "etc/app.conf", "port=80\n"))
+	top := gzipBytes(t, tarLayer(t, "etc/app.conf", "port=8080\n"))
+	registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{base, top}, "v1")
+
+	content, err := registry.client().ExtractFile(context.Background(), "app", "v1", "etc/app.conf")
+	require.NoError(t, err)
+	assert.Equal(t, "port=8080\n", string(content))
+	assert.Equal(t, 0, registry.requestCount(http.MethodGet, sha256Digest(base)))
+}
+
+func TestExtractFile_Index(t *testing.T) {
+	registry := newFakeRegistry(t)
+	amd64 := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{gzipBytes(t, tarLayer(t, "a", "b"))})
+	registry.addIndex(t, map[string]Platform{amd64: {OS: "linux", Architecture: "amd64"}}, "latest")
+
+	_, err := registry.client().ExtractFile(context.Background(), "app", "latest", "a")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "is not an image manifest")
+}
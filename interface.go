@@ -21,7 +21,8 @@ type RegistryClient interface {
 	HasManifest(ctx context.Context, repository, reference string, acceptHeaders ...string) (bool, error)
 
 	// GetBlob fetches a blob by digest.
-	GetBlob(ctx context.Context, repository, digest string) (*BlobResponse, error)
+	// Optional acceptHeaders are sent as Accept headers.
+	GetBlob(ctx context.Context, repository, digest string, acceptHeaders ...string) (*BlobResponse, error)
 
 	// HasBlob checks if a blob exists.
 	HasBlob(ctx context.Context, repository, digest string) (bool, error)
@@ -40,4 +41,5 @@ type RegistryClient interface {
 var (
 	_ RegistryClient = (*BaseClient)(nil)
 	_ RegistryClient = (*GitHubClient)(nil)
+	_ RegistryClient = (*DockerHubClient)(nil)
 )
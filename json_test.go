@@ -0,0 +1,31 @@
+package registryclient
+
+import (
+	"go/parser"
+	"go/token"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// TestNoDirectJSONImports ensures every JSON encode and decode goes through jsoncompat,
+// so the jsonv2 experiment switches the whole package
+func TestNoDirectJSONImports(t *testing.T) {
+	files, err := filepath.Glob("*.go")
+	require.NoError(t, err)
+	require.NotEmpty(t, files)
+
+	for _, file := range files {
+		parsed, err := parser.ParseFile(token.NewFileSet(), file, nil, parser.ImportsOnly)
+		require.NoError(t, err)
+		for _, spec := range parsed.Imports {
+			path, err := strconv.Unquote(spec.Path.Value)
+			require.NoError(t, err)
+			assert.NotContains(t, []string{"encoding/json", "encoding/json/v2", "encoding/json/jsontext"}, path,
+				"%s must import github.com/eznix86/registry-client/jsoncompat instead", file)
+		}
+	}
+}
@@ -3,7 +3,9 @@
 package json
 
 import (
+	"bytes"
 	stdjson "encoding/json"
+	"errors"
 	"io"
 )
 
@@ -27,6 +29,41 @@ func Unmarshal(data []byte, v any) error {
 	return stdjson.Unmarshal(data, v)
 }
 
+// UnmarshalStrict is Unmarshal that fails on object members v has no field for.
+// Like Unmarshal, members match fields case-insensitively here, while the jsonv2 build
+// matches them exactly: a member differing from its field only in case is accepted here
+// and rejected there.
+func UnmarshalStrict(data []byte, v any) error {
+	dec := stdjson.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(v); err != nil {
+		return err
+	}
+	// Like Unmarshal, reject anything after the value
+	_, err := dec.Token()
+	switch {
+	case errors.Is(err, io.EOF):
+		return nil
+	case err != nil:
+		return err
+	default:
+		return errors.New("invalid character after top-level value")
+	}
+}
+
 func Marshal(v any) ([]byte, error) {
 	return stdjson.Marshal(v)
 }
+
+// Streaming token decoding
+
+type (
+	Token        = stdjson.Token
+	Delim        = stdjson.Delim
+	TokenDecoder = stdjson.Decoder
+)
+
+// NewTokenDecoder returns a decoder reading r incrementally with Token, More and Decode
+func NewTokenDecoder(r io.Reader) *TokenDecoder {
+	return stdjson.NewDecoder(r)
+}
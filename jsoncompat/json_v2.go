@@ -3,8 +3,11 @@
 package json
 
 import (
+	"encoding/json/jsontext"
 	jsonv2 "encoding/json/v2"
+	"fmt"
 	"io"
+	"strconv"
 )
 
 // JSON v2 compatibility layer
@@ -52,6 +55,68 @@ func Unmarshal(data []byte, v any) error {
 	return jsonv2.Unmarshal(data, v)
 }
 
+// UnmarshalStrict is Unmarshal that fails on object members v has no field for.
+// Members match fields exactly, while the default build matches them case-insensitively
+// like encoding/json: a member differing from its field only in case is rejected here.
+func UnmarshalStrict(data []byte, v any) error {
+	return jsonv2.Unmarshal(data, v, jsonv2.RejectUnknownMembers(true))
+}
+
 func Marshal(v any) ([]byte, error) {
 	return jsonv2.Marshal(v)
 }
+
+// Streaming token decoding
+
+// Token holds a Delim, string, float64, bool or nil, like a v1 json.Token
+type Token any
+
+// Delim is one of the JSON delimiters [ ] { }
+type Delim rune
+
+func (d Delim) String() string {
+	return string(d)
+}
+
+// TokenDecoder wraps a jsontext.Decoder to provide the v1 Token, More and Decode methods
+type TokenDecoder struct {
+	dec *jsontext.Decoder
+}
+
+// NewTokenDecoder returns a decoder reading r incrementally with Token, More and Decode
+func NewTokenDecoder(r io.Reader) *TokenDecoder {
+	return &TokenDecoder{dec: jsontext.NewDecoder(r)}
+}
+
+func (d *TokenDecoder) Token() (Token, error) {
+	tok, err := d.dec.ReadToken()
+	if err != nil {
+		return nil, err
+	}
+	switch kind := tok.Kind(); kind {
+	case '{', '}', '[', ']':
+		return Delim(kind), nil
+	case '"':
+		return tok.String(), nil
+	case '0':
+		// Parse the raw number like v1; Token.Float changed signature after Go 1.25
+		return strconv.ParseFloat(tok.String(), 64)
+	case 't', 'f':
+		return tok.Bool(), nil
+	case 'n':
+		return nil, nil
+	default:
+		return nil, fmt.Errorf("unexpected JSON token kind %v", kind)
+	}
+}
+
+// More reports whether there is another element in the current array or object.
+// PeekKind returns the zero Kind on a read error or at the end of input.
+func (d *TokenDecoder) More() bool {
+	kind := d.dec.PeekKind()
+	return kind != ']' && kind != '}' && kind != 0
+}
+
+func (d *TokenDecoder) Decode(v any) error {
+	return jsonv2.UnmarshalDecode(d.dec, v)
+}
@@ -0,0 +1,53 @@
+//go:build goexperiment.jsonv2
+
+package registryclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// encoding/json/v2 rejects duplicate object keys, which encoding/json accepts: parsing
+// goes through jsoncompat when this fails only under the jsonv2 experiment
+func TestParseManifest_JSONv2(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","mediaType":"application/vnd.oci.image.index.v1+json","layers":[]}`)
+
+	_, err := ParseManifest(manifest)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "duplicate")
+}
+
+// CatalogStream reads the catalog through jsoncompat.TokenDecoder, which is backed by
+// jsontext under the jsonv2 experiment
+func TestCatalogStream_JSONv2(t *testing.T) {
+	tests := []struct {
+		name      string
+		body      string
+		wantRepos []string
+	}{
+		{name: "repositories", body: `{"meta":{"count":2,"complete":true},"repositories":["app","team/api"]}`, wantRepos: []string{"app", "team/api"}},
+		{name: "null repositories", body: `{"repositories":null}`, wantRepos: nil},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+
+			var repos []string
+			for repo, err := range client.CatalogStream(context.Background(), nil) {
+				require.NoError(t, err)
+				repos = append(repos, repo)
+			}
+			assert.Equal(t, tt.wantRepos, repos)
+		})
+	}
+}
@@ -0,0 +1,30 @@
+package registryclient
+
+import (
+	"log/slog"
+	"os"
+)
+
+// NoopLogger is a Logger that discards all messages.
+// A BaseClient with a nil Logger behaves as if NoopLogger was set.
+type NoopLogger struct{}
+
+func (NoopLogger) Debug(msg string, args ...any) {}
+func (NoopLogger) Info(msg string, args ...any)  {}
+func (NoopLogger) Warn(msg string, args ...any)  {}
+func (NoopLogger) Error(msg string, args ...any) {}
+
+// NewSlogLogger adapts a *slog.Logger to the Logger interface.
+// A nil logger falls back to slog.Default().
+func NewSlogLogger(logger *slog.Logger) Logger {
+	if logger == nil {
+		logger = slog.Default()
+	}
+	return logger
+}
+
+// DefaultLogger returns a Logger writing JSON records at debug level to stderr.
+func DefaultLogger() Logger {
+	handler := slog.NewJSONHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelDebug})
+	return slog.New(handler)
+}
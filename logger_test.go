@@ -0,0 +1,64 @@
+package registryclient
+
+import (
+	"bytes"
+	"log/slog"
+	"testing"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewSlogLogger(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug})
+	client := &BaseClient{Logger: NewSlogLogger(slog.New(handler))}
+
+	client.logWarn("Retrying registry request", "attempt", 2, "url", "https://registry.example.com/v2/")
+
+	var record map[string]any
+	require.NoError(t, json.Unmarshal(buf.Bytes(), &record))
+	assert.Equal(t, "WARN", record["level"])
+	assert.Equal(t, "Retrying registry request", record["msg"])
+	assert.InDelta(t, 2, record["attempt"], 0)
+	assert.Equal(t, "https://registry.example.com/v2/", record["url"])
+}
+
+func TestNewSlogLogger_Nil(t *testing.T) {
+	logger := NewSlogLogger(nil)
+	assert.Equal(t, slog.Default(), logger)
+}
+
+func TestDefaultLogger(t *testing.T) {
+	logger := DefaultLogger()
+	require.NotNil(t, logger)
+	assert.IsType(t, &slog.Logger{}, logger)
+}
+
+func TestNoopLogger(t *testing.T) {
+	var logger Logger = NoopLogger{}
+	assert.NotPanics(t, func() {
+		logger.Debug("debug", "key", "value")
+		logger.Info("info", "key", "value")
+		logger.Warn("warn", "key", "value")
+		logger.Error("error", "key", "value")
+	})
+}
+
+func TestClient_Logger_NilFallsBackToNoop(t *testing.T) {
+	client := &BaseClient{}
+	assert.Equal(t, NoopLogger{}, client.logger())
+
+	mock := &mockLogger{}
+	client.Logger = mock
+	assert.Same(t, mock, client.logger())
+
+	client.Logger = NoopLogger{}
+	assert.NotPanics(t, func() {
+		client.logDebug("debug")
+		client.logInfo("info")
+		client.logWarn("warn")
+		client.logError("error")
+	})
+}
@@ -0,0 +1,30 @@
+package registryclient
+
+// mediaTypePairs maps Docker v2 media types to their OCI equivalents
+var mediaTypePairs = []struct {
+	docker string
+	oci    string
+}{
+	{docker: "application/vnd.docker.distribution.manifest.v2+json", oci: "application/vnd.oci.image.manifest.v1+json"},
+	{docker: "application/vnd.docker.distribution.manifest.list.v2+json", oci: "application/vnd.oci.image.index.v1+json"},
+	{docker: "application/vnd.docker.container.image.v1+json", oci: "application/vnd.oci.image.config.v1+json"},
+	{docker: "application/vnd.docker.image.rootfs.diff.tar", oci: "application/vnd.oci.image.layer.v1.tar"},
+	{docker: "application/vnd.docker.image.rootfs.diff.tar.gzip", oci: "application/vnd.oci.image.layer.v1.tar+gzip"},
+	{docker: "application/vnd.docker.image.rootfs.diff.tar.zstd", oci: "application/vnd.oci.image.layer.v1.tar+zstd"},
+	{docker: "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip", oci: "application/vnd.oci.image.layer.nondistributable.v1.tar+gzip"},
+}
+
+// ConvertMediaType translates a manifest, index, config or layer media type between
+// the Docker v2 and OCI families: to OCI when toOCI is true, to Docker otherwise.
+// Media types without an equivalent, or already in the target family, are returned unchanged.
+func ConvertMediaType(mt string, toOCI bool) string {
+	for _, pair := range mediaTypePairs {
+		if toOCI && mt == pair.docker {
+			return pair.oci
+		}
+		if !toOCI && mt == pair.oci {
+			return pair.docker
+		}
+	}
+	return mt
+}
@@ -0,0 +1,39 @@
+package registryclient
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestConvertMediaType(t *testing.T) {
+	tests := []struct {
+		name   string
+		docker string
+		oci    string
+	}{
+		{name: "manifest", docker: "application/vnd.docker.distribution.manifest.v2+json", oci: "application/vnd.oci.image.manifest.v1+json"},
+		{name: "index", docker: "application/vnd.docker.distribution.manifest.list.v2+json", oci: "application/vnd.oci.image.index.v1+json"},
+		{name: "config", docker: "application/vnd.docker.container.image.v1+json", oci: "application/vnd.oci.image.config.v1+json"},
+		{name: "uncompressed layer", docker: "application/vnd.docker.image.rootfs.diff.tar", oci: "application/vnd.oci.image.layer.v1.tar"},
+		{name: "gzip layer", docker: "application/vnd.docker.image.rootfs.diff.tar.gzip", oci: "application/vnd.oci.image.layer.v1.tar+gzip"},
+		{name: "zstd layer", docker: "application/vnd.docker.image.rootfs.diff.tar.zstd", oci: "application/vnd.oci.image.layer.v1.tar+zstd"},
+		{name: "foreign layer", docker: "application/vnd.docker.image.rootfs.foreign.diff.tar.gzip", oci: "application/vnd.oci.image.layer.nondistributable.v1.tar+gzip"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			assert.Equal(t, tt.oci, ConvertMediaType(tt.docker, true))
+			assert.Equal(t, tt.docker, ConvertMediaType(tt.oci, false))
+			assert.Equal(t, tt.oci, ConvertMediaType(tt.oci, true), "already OCI")
+			assert.Equal(t, tt.docker, ConvertMediaType(tt.docker, false), "already Docker")
+		})
+	}
+
+	t.Run("unknown media types are unchanged", func(t *testing.T) {
+		for _, mt := range []string{"", "application/json", "application/vnd.cncf.helm.config.v1+json"} {
+			assert.Equal(t, mt, ConvertMediaType(mt, true))
+			assert.Equal(t, mt, ConvertMediaType(mt, false))
+		}
+	})
+}
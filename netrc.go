@@ -0,0 +1,139 @@
+package registryclient
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// NewClientFromNetrc returns a client for the registry host machine (e.g. "ghcr.io" or
+// "registry.local:5000") using BasicAuth with the login and password of its netrc entry.
+// The file is read from $NETRC, or ~/.netrc when unset. The "default" entry is used when
+// no machine entry matches. The base URL is derived from machine as in NewClientForReference.
+func NewClientFromNetrc(machine string, opts ...Option) (*BaseClient, error) {
+	path := os.Getenv("NETRC")
+	if path == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return nil, fmt.Errorf("netrc: %w", err)
+		}
+		path = filepath.Join(home, ".netrc")
+	}
+
+	auth, err := readNetrc(path, machine)
+	if err != nil {
+		return nil, err
+	}
+
+	client := &BaseClient{
+		HTTPClient: &http.Client{},
+		BaseURL:    registryBaseURL(machine),
+		Auth:       auth,
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+	return client, nil
+}
+
+// netrcEntry is a machine (or default) entry of a netrc file
+type netrcEntry struct {
+	machine   string
+	isDefault bool
+	login     string
+	password  string
+}
+
+// readNetrc returns the credentials for machine from the netrc file at path
+func readNetrc(path, machine string) (BasicAuth, error) {
+	f, err := os.Open(path)
+	if err != nil {
+		return BasicAuth{}, fmt.Errorf("netrc: %w", err)
+	}
+	defer func() { _ = f.Close() }()
+
+	entries, err := parseNetrc(bufio.NewScanner(f))
+	if err != nil {
+		return BasicAuth{}, fmt.Errorf("netrc %s: %w", path, err)
+	}
+
+	var fallback *netrcEntry
+	for i, entry := range entries {
+		if entry.machine == machine {
+			return BasicAuth{Username: entry.login, Password: entry.password}, nil
+		}
+		if entry.isDefault && fallback == nil {
+			fallback = &entries[i]
+		}
+	}
+	if fallback != nil {
+		return BasicAuth{Username: fallback.login, Password: fallback.password}, nil
+	}
+	return BasicAuth{}, fmt.Errorf("netrc %s: no entry for machine %s", path, machine)
+}
+
+// parseNetrc reads the machine and default entries of a netrc file.
+// account values are ignored and macdef bodies (up to the next blank line) are skipped.
+func parseNetrc(scanner *bufio.Scanner) ([]netrcEntry, error) {
+	var entries []netrcEntry
+	var inMacdef bool
+	var key string
+
+	for scanner.Scan() {
+		line := scanner.Text()
+		if inMacdef {
+			inMacdef = strings.TrimSpace(line) != ""
+			continue
+		}
+		if strings.HasPrefix(strings.TrimSpace(line), "#") {
+			continue
+		}
+
+		for _, field := range strings.Fields(line) {
+			if key != "" {
+				if len(entries) == 0 {
+					return nil, fmt.Errorf("%s %q before any machine", key, field)
+				}
+				entry := &entries[len(entries)-1]
+				switch key {
+				case "machine":
+					entry.machine = field
+				case "login":
+					entry.login = field
+				case "password":
+					entry.password = field
+				}
+				key = ""
+				continue
+			}
+
+			switch field {
+			case "machine":
+				entries = append(entries, netrcEntry{})
+				key = field
+			case "default":
+				entries = append(entries, netrcEntry{isDefault: true})
+			case "login", "password", "account":
+				key = field
+			case "macdef":
+				// The macro name ends the line and its body runs until a blank line
+				inMacdef = true
+			default:
+				return nil, fmt.Errorf("unexpected token %q", field)
+			}
+			if inMacdef {
+				break
+			}
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if key != "" {
+		return nil, fmt.Errorf("missing value for %s", key)
+	}
+	return entries, nil
+}
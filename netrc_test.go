@@ -0,0 +1,98 @@
+package registryclient
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func writeNetrc(t *testing.T, content string) string {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "netrc")
+	require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	return path
+}
+
+func TestNewClientFromNetrc(t *testing.T) {
+	path := writeNetrc(t, `# CI credentials
+machine github.com login git password ignored
+machine ghcr.io
+	login ci-bot
+	password s3cret
+
+macdef init
+machine fake login in password macro
+
+machine registry.local:5000 login local password pw account team
+default login anonymous password guest
+`)
+	t.Setenv("NETRC", path)
+
+	tests := []struct {
+		name        string
+		machine     string
+		wantBaseURL string
+		wantAuth    BasicAuth
+	}{
+		{name: "multi-line entry", machine: "ghcr.io", wantBaseURL: "https://ghcr.io", wantAuth: BasicAuth{Username: "ci-bot", Password: "s3cret"}},
+		{name: "entry with port", machine: "registry.local:5000", wantBaseURL: "https://registry.local:5000", wantAuth: BasicAuth{Username: "local", Password: "pw"}},
+		{name: "default entry", machine: "quay.io", wantBaseURL: "https://quay.io", wantAuth: BasicAuth{Username: "anonymous", Password: "guest"}},
+		{name: "macdef body is skipped", machine: "fake", wantBaseURL: "https://fake", wantAuth: BasicAuth{Username: "anonymous", Password: "guest"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, err := NewClientFromNetrc(tt.machine)
+			require.NoError(t, err)
+
+			assert.Equal(t, tt.wantBaseURL, client.BaseURL)
+			assert.Equal(t, tt.wantAuth, client.Auth)
+			assert.NotNil(t, client.HTTPClient)
+		})
+	}
+}
+
+func TestNewClientFromNetrc_HomeDirectory(t *testing.T) {
+	home := t.TempDir()
+	require.NoError(t, os.WriteFile(filepath.Join(home, ".netrc"), []byte("machine ghcr.io login user password token\n"), 0o600))
+	t.Setenv("NETRC", "")
+	t.Setenv("HOME", home)
+
+	client, err := NewClientFromNetrc("ghcr.io", WithLogger(NoopLogger{}))
+	require.NoError(t, err)
+	assert.Equal(t, BasicAuth{Username: "user", Password: "token"}, client.Auth)
+	assert.Equal(t, NoopLogger{}, client.Logger)
+}
+
+func TestNewClientFromNetrc_Errors(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantErr string
+	}{
+		{name: "no entry", content: "machine ghcr.io login user password token\n", wantErr: "no entry for machine docker.io"},
+		{name: "login before machine", content: "login user\n", wantErr: "before any machine"},
+		{name: "missing value", content: "machine docker.io login", wantErr: "missing value for login"},
+		{name: "unknown token", content: "machine docker.io user me\n", wantErr: `unexpected token "user"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Setenv("NETRC", writeNetrc(t, tt.content))
+
+			client, err := NewClientFromNetrc("docker.io")
+			require.Error(t, err)
+			assert.Nil(t, client)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("missing file", func(t *testing.T) {
+		t.Setenv("NETRC", filepath.Join(t.TempDir(), "missing"))
+		_, err := NewClientFromNetrc("docker.io")
+		require.ErrorIs(t, err, os.ErrNotExist)
+	})
+}
@@ -0,0 +1,193 @@
+package registryclient
+
+import (
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strings"
+	"time"
+)
+
+// Option configures a BaseClient created by one of the constructors
+type Option func(*BaseClient)
+
+// WithAuth sets the authentication applied to registry requests
+func WithAuth(auth Auth) Option {
+	return func(c *BaseClient) {
+		c.Auth = auth
+	}
+}
+
+// WithHTTPClient sets the HTTP client used for requests
+func WithHTTPClient(httpClient *http.Client) Option {
+	return func(c *BaseClient) {
+		c.HTTPClient = httpClient
+	}
+}
+
+// WithLogger sets the logger
+func WithLogger(logger Logger) Option {
+	return func(c *BaseClient) {
+		c.Logger = logger
+	}
+}
+
+// WithHTTP2 explicitly enables or disables HTTP/2 on the client's transport.
+// Enabling it lets concurrent requests (e.g. GetBlobParallel) share one multiplexed
+// connection; registries that do not negotiate h2 over TLS fall back to HTTP/1.1.
+// Disabling it forces HTTP/1.1, which opens one connection per in-flight request.
+// The transport is cloned, so a shared HTTPClient or http.DefaultTransport is not modified.
+func WithHTTP2(enabled bool) Option {
+	return func(c *BaseClient) {
+		c.wrapTransport(func(transport *http.Transport) {
+			protocols := new(http.Protocols)
+			protocols.SetHTTP1(true)
+			protocols.SetHTTP2(enabled)
+			transport.Protocols = protocols
+			transport.ForceAttemptHTTP2 = enabled
+			if !enabled && transport.TLSClientConfig != nil {
+				// Stop advertising h2 via ALPN, or an h2 server would expect HTTP/2 framing
+				transport.TLSClientConfig.NextProtos = slices.DeleteFunc(slices.Clone(transport.TLSClientConfig.NextProtos),
+					func(proto string) bool { return proto == "h2" })
+			}
+		})
+	}
+}
+
+// WithDialTimeout bounds how long establishing a TCP connection (including DNS resolution)
+// may take, so an unreachable registry fails fast instead of using up the request timeout.
+// Apply it after WithHTTPClient, since it wraps the client's transport.
+func WithDialTimeout(timeout time.Duration) Option {
+	return func(c *BaseClient) {
+		c.wrapTransport(func(transport *http.Transport) {
+			dialer := &net.Dialer{Timeout: timeout, KeepAlive: 30 * time.Second}
+			transport.DialContext = dialer.DialContext
+		})
+	}
+}
+
+// WithTLSHandshakeTimeout bounds how long the TLS handshake may take once connected.
+// Apply it after WithHTTPClient, since it wraps the client's transport.
+func WithTLSHandshakeTimeout(timeout time.Duration) Option {
+	return func(c *BaseClient) {
+		c.wrapTransport(func(transport *http.Transport) {
+			transport.TLSHandshakeTimeout = timeout
+		})
+	}
+}
+
+// WithProxy sends requests through proxyURL instead of the proxy from the environment.
+// Hosts listed in NO_PROXY (or no_proxy) still go direct, as with ProxyFromEnvironment.
+// Apply it after WithHTTPClient, since it wraps the client's transport.
+func WithProxy(proxyURL *url.URL) Option {
+	return func(c *BaseClient) {
+		bypass := strings.Split(os.Getenv("NO_PROXY"), ",")
+		if len(bypass) == 1 && bypass[0] == "" {
+			bypass = strings.Split(os.Getenv("no_proxy"), ",")
+		}
+		c.wrapProxy(func(*http.Request) (*url.URL, error) { return proxyURL, nil }, bypass)
+	}
+}
+
+// WithProxyBypass sends requests to the listed hosts directly instead of through the proxy
+// set by WithProxy or the environment. Entries follow NO_PROXY semantics: "*" matches every
+// host, "example.com" and ".example.com" match the domain and its subdomains, an IP or CIDR
+// matches addresses, and an optional ":port" restricts the entry to that port.
+// Apply it after WithProxy and WithHTTPClient.
+func WithProxyBypass(hosts []string) Option {
+	return func(c *BaseClient) {
+		c.wrapProxy(nil, hosts)
+	}
+}
+
+// wrapProxy clones the client's transport and installs proxy (the current transport proxy
+// when nil), skipping it for requests whose host matches bypass
+func (c *BaseClient) wrapProxy(proxy func(*http.Request) (*url.URL, error), bypass []string) {
+	c.wrapTransport(func(transport *http.Transport) {
+		if proxy == nil {
+			proxy = transport.Proxy
+		}
+		transport.Proxy = func(req *http.Request) (*url.URL, error) {
+			if proxy == nil || proxyBypassed(req.URL, bypass) {
+				return nil, nil
+			}
+			return proxy(req)
+		}
+	})
+}
+
+// wrapTransport replaces the client with a copy whose transport is a clone modified by
+// configure, so a shared HTTPClient or http.DefaultTransport is not modified. A custom
+// RoundTripper cannot be configured, so it is left untouched and a warning is logged.
+func (c *BaseClient) wrapTransport(configure func(*http.Transport)) {
+	httpClient := &http.Client{}
+	if c.HTTPClient != nil {
+		*httpClient = *c.HTTPClient
+	}
+	transport, ok := cloneTransport(httpClient.Transport)
+	if !ok {
+		c.logWarn("Transport option ignored: the HTTP client uses a custom RoundTripper",
+			"operation", "wrapTransport",
+			"transport", fmt.Sprintf("%T", httpClient.Transport),
+		)
+		return
+	}
+	configure(transport)
+	httpClient.Transport = transport
+	c.HTTPClient = httpClient
+}
+
+// proxyBypassed reports whether u matches one of the NO_PROXY style entries
+func proxyBypassed(u *url.URL, entries []string) bool {
+	host := strings.ToLower(u.Hostname())
+	port := u.Port()
+	if port == "" {
+		port = map[string]string{"http": "80", "https": "443"}[u.Scheme]
+	}
+
+	for _, entry := range entries {
+		entry = strings.ToLower(strings.TrimSpace(entry))
+		if entry == "" {
+			continue
+		}
+		if entry == "*" {
+			return true
+		}
+		if _, cidr, err := net.ParseCIDR(entry); err == nil {
+			if ip := net.ParseIP(host); ip != nil && cidr.Contains(ip) {
+				return true
+			}
+			continue
+		}
+
+		entryHost, entryPort := entry, ""
+		if h, p, err := net.SplitHostPort(entry); err == nil {
+			entryHost, entryPort = h, p
+		}
+		if entryPort != "" && entryPort != port {
+			continue
+		}
+		entryHost = strings.TrimPrefix(strings.Trim(entryHost, "[]"), "*")
+		domain := strings.TrimPrefix(entryHost, ".")
+		if host == domain || strings.HasSuffix(host, "."+domain) {
+			return true
+		}
+	}
+	return false
+}
+
+// cloneTransport clones rt when it is an *http.Transport, or http.DefaultTransport when rt
+// is nil. It reports false for any other RoundTripper.
+func cloneTransport(rt http.RoundTripper) (*http.Transport, bool) {
+	if rt == nil {
+		rt = http.DefaultTransport
+	}
+	transport, ok := rt.(*http.Transport)
+	if !ok {
+		return nil, false
+	}
+	return transport.Clone(), true
+}
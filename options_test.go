@@ -0,0 +1,291 @@
+package registryclient
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestWithHTTP2(t *testing.T) {
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte(r.Proto))
+	}))
+	server.EnableHTTP2 = true
+	server.StartTLS()
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		enabled   bool
+		wantProto string
+	}{
+		{name: "enabled", enabled: true, wantProto: "HTTP/2.0"},
+		{name: "disabled", enabled: false, wantProto: "HTTP/1.1"},
+	}
+
+	shared := server.Client()
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &BaseClient{HTTPClient: shared, BaseURL: server.URL}
+			WithHTTP2(tt.enabled)(client)
+
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+			require.NoError(t, err)
+			resp, err := client.Do(req)
+			require.NoError(t, err)
+			defer client.closeBody(resp.Body)
+
+			assert.Equal(t, tt.wantProto, resp.Proto)
+		})
+	}
+
+	assert.Contains(t, shared.Transport.(*http.Transport).TLSClientConfig.NextProtos, "h2",
+		"disabling HTTP/2 must not modify the shared transport")
+}
+
+func TestWithHTTP2_FallsBackToHTTP1(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: server.Client(), BaseURL: server.URL}
+	WithHTTP2(true)(client)
+
+	req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer client.closeBody(resp.Body)
+
+	assert.Equal(t, "HTTP/1.1", resp.Proto)
+}
+
+func TestWithHTTP2_DoesNotModifySharedClient(t *testing.T) {
+	shared := &http.Client{Timeout: time.Second}
+	client := &BaseClient{HTTPClient: shared}
+
+	WithHTTP2(false)(client)
+
+	assert.Nil(t, shared.Transport)
+	assert.NotSame(t, shared, client.HTTPClient)
+	assert.Equal(t, time.Second, client.HTTPClient.Timeout)
+
+	transport, ok := client.HTTPClient.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.False(t, transport.Protocols.HTTP2())
+	assert.NotSame(t, http.DefaultTransport, transport)
+}
+
+func TestWithHTTP2_NilHTTPClient(t *testing.T) {
+	client := &BaseClient{}
+	WithHTTP2(true)(client)
+
+	require.NotNil(t, client.HTTPClient)
+	transport, ok := client.HTTPClient.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.True(t, transport.Protocols.HTTP2())
+}
+
+func TestWithProxyBypass(t *testing.T) {
+	var proxied []string
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		proxied = append(proxied, r.URL.Host)
+		_, _ = w.Write([]byte("proxy"))
+	}))
+	defer proxy.Close()
+
+	registry := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		_, _ = w.Write([]byte("direct"))
+	}))
+	defer registry.Close()
+	registryURL, err := url.Parse(registry.URL)
+	require.NoError(t, err)
+
+	proxyURL, err := url.Parse(proxy.URL)
+	require.NoError(t, err)
+
+	t.Setenv("NO_PROXY", "")
+	t.Setenv("no_proxy", "")
+	client := &BaseClient{HTTPClient: &http.Client{}}
+	WithProxy(proxyURL)(client)
+	WithProxyBypass([]string{"localhost"})(client)
+
+	get := func(target string) string {
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodGet, target, nil)
+		require.NoError(t, err)
+		resp, err := client.Do(req)
+		require.NoError(t, err)
+		defer client.closeBody(resp.Body)
+		body, err := io.ReadAll(resp.Body)
+		require.NoError(t, err)
+		return string(body)
+	}
+
+	assert.Equal(t, "direct", get("http://localhost:"+registryURL.Port()+"/v2/"))
+	assert.Equal(t, "proxy", get("http://registry.example.com/v2/"))
+	assert.Equal(t, []string{"registry.example.com"}, proxied, "the bypassed request must not traverse the proxy")
+}
+
+func TestWithProxy_NoProxyEnv(t *testing.T) {
+	proxyURL, err := url.Parse("http://proxy.example.com:3128")
+	require.NoError(t, err)
+
+	t.Setenv("NO_PROXY", "")
+	t.Setenv("no_proxy", "internal.example.com, 10.0.0.0/8")
+	client := &BaseClient{}
+	WithProxy(proxyURL)(client)
+
+	proxy := client.HTTPClient.Transport.(*http.Transport).Proxy
+	for target, want := range map[string]*url.URL{
+		"https://registry.internal.example.com/v2/": nil,
+		"https://10.1.2.3:5000/v2/":                 nil,
+		"https://ghcr.io/v2/":                       proxyURL,
+	} {
+		req, err := http.NewRequest(http.MethodGet, target, nil)
+		require.NoError(t, err)
+		got, err := proxy(req)
+		require.NoError(t, err)
+		assert.Equal(t, want, got, target)
+	}
+}
+
+func TestProxyBypassed(t *testing.T) {
+	tests := []struct {
+		name    string
+		target  string
+		entries []string
+		want    bool
+	}{
+		{name: "wildcard", target: "https://ghcr.io", entries: []string{"*"}, want: true},
+		{name: "exact host", target: "https://registry.local:5000", entries: []string{"registry.local"}, want: true},
+		{name: "subdomain", target: "https://a.corp.example", entries: []string{"corp.example"}, want: true},
+		{name: "leading dot", target: "https://a.corp.example", entries: []string{".corp.example"}, want: true},
+		{name: "leading wildcard", target: "https://a.corp.example", entries: []string{"*.corp.example"}, want: true},
+		{name: "suffix is not a domain", target: "https://notcorp.example", entries: []string{"corp.example"}, want: false},
+		{name: "matching port", target: "https://registry.local:5000", entries: []string{"registry.local:5000"}, want: true},
+		{name: "other port", target: "https://registry.local", entries: []string{"registry.local:5000"}, want: false},
+		{name: "default port", target: "https://registry.local", entries: []string{"registry.local:443"}, want: true},
+		{name: "cidr", target: "http://192.168.1.20:5000", entries: []string{"192.168.0.0/16"}, want: true},
+		{name: "ipv6", target: "http://[::1]:5000", entries: []string{"::1"}, want: true},
+		{name: "case insensitive", target: "https://Registry.Local", entries: []string{" REGISTRY.local "}, want: true},
+		{name: "no match", target: "https://ghcr.io", entries: []string{"", "docker.io"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			u, err := url.Parse(tt.target)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, proxyBypassed(u, tt.entries))
+		})
+	}
+}
+
+func TestWithDialTimeout(t *testing.T) {
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: "http://10.255.255.1", MaxAttempts: 1}
+	WithDialTimeout(100 * time.Millisecond)(client)
+
+	start := time.Now()
+	_, err := client.ListTags(context.Background(), "app", nil)
+
+	var netErr net.Error
+	require.ErrorAs(t, err, &netErr)
+	if errors.Is(err, syscall.ECONNRESET) || errors.Is(err, syscall.ECONNREFUSED) || errors.Is(err, syscall.ENETUNREACH) {
+		t.Skipf("the network answers for unroutable addresses: %v", err)
+	}
+	assert.True(t, netErr.Timeout(), "an unroutable address must fail at the dial timeout: %v", err)
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestWithTLSHandshakeTimeout(t *testing.T) {
+	// Accept connections but never answer the TLS handshake
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	defer func() { _ = listener.Close() }()
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			go func() { _, _ = io.Copy(io.Discard, conn) }() // hold the connection until the client gives up
+		}
+	}()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: "https://" + listener.Addr().String(), MaxAttempts: 1}
+	WithTLSHandshakeTimeout(100 * time.Millisecond)(client)
+
+	start := time.Now()
+	_, err = client.ListTags(context.Background(), "app", nil)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "TLS handshake timeout")
+	assert.Less(t, time.Since(start), 2*time.Second)
+}
+
+func TestWithTimeouts_DoNotModifySharedClient(t *testing.T) {
+	shared := &http.Client{Timeout: time.Minute}
+	client := &BaseClient{HTTPClient: shared}
+
+	WithTLSHandshakeTimeout(3 * time.Second)(client)
+	WithDialTimeout(time.Second)(client)
+
+	transport, ok := client.HTTPClient.Transport.(*http.Transport)
+	require.True(t, ok)
+	assert.Equal(t, 3*time.Second, transport.TLSHandshakeTimeout)
+	assert.NotNil(t, transport.DialContext)
+	assert.Equal(t, time.Minute, client.HTTPClient.Timeout)
+	assert.Nil(t, shared.Transport, "the shared client must not be modified")
+}
+
+type roundTripperFunc func(*http.Request) (*http.Response, error)
+
+func (f roundTripperFunc) RoundTrip(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestTransportOptions_CustomRoundTripper(t *testing.T) {
+	tests := []struct {
+		name   string
+		option Option
+	}{
+		{name: "WithHTTP2", option: WithHTTP2(true)},
+		{name: "WithDialTimeout", option: WithDialTimeout(time.Second)},
+		{name: "WithTLSHandshakeTimeout", option: WithTLSHandshakeTimeout(time.Second)},
+		{name: "WithProxy", option: WithProxy(&url.URL{Scheme: "http", Host: "proxy.example.com:3128"})},
+		{name: "WithProxyBypass", option: WithProxyBypass([]string{"example.com"})},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var called bool
+			custom := roundTripperFunc(func(req *http.Request) (*http.Response, error) {
+				called = true
+				return &http.Response{StatusCode: http.StatusOK, Body: http.NoBody, Request: req}, nil
+			})
+			httpClient := &http.Client{Transport: custom}
+			logger := &mockLogger{}
+			client := &BaseClient{HTTPClient: httpClient, Logger: logger}
+
+			tt.option(client)
+
+			assert.Same(t, httpClient, client.HTTPClient, "the client is left untouched")
+			require.Len(t, logger.warnCalls, 1)
+			assert.Contains(t, logger.warnCalls[0].msg, "custom RoundTripper")
+
+			resp, err := client.HTTPClient.Get("http://registry.example.com/v2/")
+			require.NoError(t, err)
+			resp.Body.Close()
+			assert.True(t, called, "requests still go through the custom RoundTripper")
+		})
+	}
+}
@@ -0,0 +1,146 @@
+package registryclient
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strings"
+)
+
+// DefaultRegistry is the registry assumed for references without a registry host
+const DefaultRegistry = "docker.io"
+
+var (
+	repositoryComponentRegexp = regexp.MustCompile(`^[a-z0-9]+(?:(?:[._]|__|-+)[a-z0-9]+)*$`)
+	tagRegexp                 = regexp.MustCompile(`^\w[\w.-]{0,127}$`)
+	digestRegexp              = regexp.MustCompile(`^[a-z0-9]+(?:[.+_-][a-z0-9]+)*:[a-zA-Z0-9=_-]+$`)
+)
+
+// ParseReference splits an image reference such as "ghcr.io/org/app:v1.2@sha256:..."
+// into its registry, repository, tag and digest.
+// The registry defaults to DefaultRegistry when the first path component is not a host
+// (it must contain a "." or ":" or be "localhost"), and the tag defaults to "latest"
+// when neither a tag nor a digest is given. Docker Hub official images without a
+// namespace are normalized to "library/<name>".
+func ParseReference(s string) (registry, repository, tag, digest string, err error) {
+	if s == "" {
+		return "", "", "", "", fmt.Errorf("invalid reference: empty string")
+	}
+
+	name := s
+	if before, after, found := strings.Cut(s, "@"); found {
+		name, digest = before, after
+		if !IsDigest(digest) {
+			return "", "", "", "", fmt.Errorf("invalid reference %q: invalid digest %q", s, digest)
+		}
+	}
+
+	// A tag separator is a ':' after the last '/', otherwise it belongs to a registry port
+	if i := strings.LastIndex(name, ":"); i > strings.LastIndex(name, "/") {
+		name, tag = name[:i], name[i+1:]
+		if !tagRegexp.MatchString(tag) {
+			return "", "", "", "", fmt.Errorf("invalid reference %q: invalid tag %q", s, tag)
+		}
+	}
+
+	registry, repository = splitRegistry(name)
+	if isDockerHub(registry) && !strings.Contains(repository, "/") {
+		repository = "library/" + repository
+	}
+	if err := ValidateRepositoryName(repository); err != nil {
+		return "", "", "", "", fmt.Errorf("invalid reference %q: %w", s, err)
+	}
+
+	if tag == "" && digest == "" {
+		tag = "latest"
+	}
+
+	return registry, repository, tag, digest, nil
+}
+
+// splitRegistry separates a leading registry host from the repository path
+func splitRegistry(name string) (registry, repository string) {
+	first, rest, found := strings.Cut(name, "/")
+	if !found || (!strings.ContainsAny(first, ".:") && first != "localhost") {
+		return DefaultRegistry, name
+	}
+	return first, rest
+}
+
+// isDockerHub reports whether a registry host refers to Docker Hub
+func isDockerHub(registry string) bool {
+	switch registry {
+	case DefaultRegistry, "index.docker.io", "registry-1.docker.io":
+		return true
+	default:
+		return false
+	}
+}
+
+// maxRepositoryNameLength is the longest repository name registries are required to accept
+const maxRepositoryNameLength = 255
+
+// ErrInvalidRepositoryName is returned for repository names that violate the OCI name grammar
+var ErrInvalidRepositoryName = errors.New("invalid repository name")
+
+// ValidateRepositoryName checks a repository name (without registry host) against the OCI
+// distribution grammar: "/"-separated components of lowercase letters and digits, joined
+// within a component by ".", "_", "__" or one or more "-", and at most 255 characters.
+// Errors wrap ErrInvalidRepositoryName.
+func ValidateRepositoryName(name string) error {
+	if name == "" {
+		return fmt.Errorf("%w: name is empty", ErrInvalidRepositoryName)
+	}
+	if len(name) > maxRepositoryNameLength {
+		return fmt.Errorf("%w: %d characters exceeds limit of %d", ErrInvalidRepositoryName, len(name), maxRepositoryNameLength)
+	}
+	for component := range strings.SplitSeq(name, "/") {
+		switch {
+		case component == "":
+			return fmt.Errorf("%w %q: empty path component", ErrInvalidRepositoryName, name)
+		case strings.ToLower(component) != component:
+			return fmt.Errorf("%w %q: component %q must be lowercase", ErrInvalidRepositoryName, name, component)
+		case !repositoryComponentRegexp.MatchString(component):
+			return fmt.Errorf("%w %q: invalid component %q", ErrInvalidRepositoryName, name, component)
+		}
+	}
+	return nil
+}
+
+// NewClientForReference parses an image reference and returns a client for its registry
+// together with the repository and reference (digest if present, otherwise tag) to use.
+// docker.io maps to registry-1.docker.io; localhost registries are reached over plain HTTP.
+func NewClientForReference(ref string, opts ...Option) (client *BaseClient, repository, reference string, err error) {
+	registry, repository, tag, digest, err := ParseReference(ref)
+	if err != nil {
+		return nil, "", "", err
+	}
+
+	client = &BaseClient{
+		HTTPClient: &http.Client{},
+		BaseURL:    registryBaseURL(registry),
+	}
+	for _, opt := range opts {
+		opt(client)
+	}
+
+	reference = tag
+	if digest != "" {
+		reference = digest
+	}
+	return client, repository, reference, nil
+}
+
+// registryBaseURL returns the API base URL for a registry host
+func registryBaseURL(registry string) string {
+	host, _, _ := strings.Cut(registry, ":")
+	switch {
+	case isDockerHub(registry):
+		return "https://registry-1.docker.io"
+	case host == "localhost" || host == "127.0.0.1":
+		return "http://" + registry
+	default:
+		return "https://" + registry
+	}
+}
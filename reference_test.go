@@ -0,0 +1,195 @@
+package registryclient
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseReference(t *testing.T) {
+	const digest = "sha256:6e8b5a7c0f7d3f2a1b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a"
+
+	tests := []struct {
+		name         string
+		input        string
+		wantRegistry string
+		wantRepo     string
+		wantTag      string
+		wantDigest   string
+	}{
+		{name: "bare name", input: "nginx", wantRegistry: "docker.io", wantRepo: "library/nginx", wantTag: "latest"},
+		{name: "name with tag", input: "nginx:1.25", wantRegistry: "docker.io", wantRepo: "library/nginx", wantTag: "1.25"},
+		{name: "namespaced", input: "bitnami/redis:7.2", wantRegistry: "docker.io", wantRepo: "bitnami/redis", wantTag: "7.2"},
+		{name: "explicit docker.io", input: "docker.io/library/nginx", wantRegistry: "docker.io", wantRepo: "library/nginx", wantTag: "latest"},
+		{name: "registry-1 official image", input: "registry-1.docker.io/nginx", wantRegistry: "registry-1.docker.io", wantRepo: "library/nginx", wantTag: "latest"},
+		{name: "official image on other registry", input: "ghcr.io/nginx", wantRegistry: "ghcr.io", wantRepo: "nginx", wantTag: "latest"},
+		{name: "ghcr with tag", input: "ghcr.io/org/app:v1.2", wantRegistry: "ghcr.io", wantRepo: "org/app", wantTag: "v1.2"},
+		{name: "digest only", input: "ghcr.io/org/app@" + digest, wantRegistry: "ghcr.io", wantRepo: "org/app", wantDigest: digest},
+		{name: "tag and digest", input: "ghcr.io/org/app:v1.2@" + digest, wantRegistry: "ghcr.io", wantRepo: "org/app", wantTag: "v1.2", wantDigest: digest},
+		{name: "registry with port", input: "registry.local:5000/team/app", wantRegistry: "registry.local:5000", wantRepo: "team/app", wantTag: "latest"},
+		{name: "registry with port and tag", input: "registry.local:5000/app:dev", wantRegistry: "registry.local:5000", wantRepo: "app", wantTag: "dev"},
+		{name: "localhost", input: "localhost/app", wantRegistry: "localhost", wantRepo: "app", wantTag: "latest"},
+		{name: "localhost with port", input: "localhost:5000/app:1", wantRegistry: "localhost:5000", wantRepo: "app", wantTag: "1"},
+		{name: "deep path", input: "quay.io/a/b/c-d_e.f:tag_1", wantRegistry: "quay.io", wantRepo: "a/b/c-d_e.f", wantTag: "tag_1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, repository, tag, digest, err := ParseReference(tt.input)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantRegistry, registry)
+			assert.Equal(t, tt.wantRepo, repository)
+			assert.Equal(t, tt.wantTag, tag)
+			assert.Equal(t, tt.wantDigest, digest)
+		})
+	}
+}
+
+func TestParseReference_Invalid(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "empty", input: ""},
+		{name: "uppercase repository", input: "Nginx"},
+		{name: "empty tag", input: "nginx:"},
+		{name: "invalid tag", input: "nginx:-bad"},
+		{name: "invalid digest", input: "nginx@notadigest"},
+		{name: "empty component", input: "ghcr.io/org//app"},
+		{name: "registry only", input: "ghcr.io/"},
+		{name: "trailing separator", input: "nginx-"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			registry, repository, tag, digest, err := ParseReference(tt.input)
+
+			require.Error(t, err)
+			assert.Empty(t, registry)
+			assert.Empty(t, repository)
+			assert.Empty(t, tag)
+			assert.Empty(t, digest)
+		})
+	}
+}
+
+func TestNewClientForReference(t *testing.T) {
+	const digest = "sha256:6e8b5a7c0f7d3f2a1b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a"
+
+	tests := []struct {
+		name        string
+		input       string
+		wantBaseURL string
+		wantRepo    string
+		wantRef     string
+	}{
+		{name: "docker hub official image", input: "nginx:latest", wantBaseURL: "https://registry-1.docker.io", wantRepo: "library/nginx", wantRef: "latest"},
+		{name: "docker hub", input: "bitnami/redis:7.2", wantBaseURL: "https://registry-1.docker.io", wantRepo: "bitnami/redis", wantRef: "7.2"},
+		{name: "default tag", input: "ghcr.io/org/app", wantBaseURL: "https://ghcr.io", wantRepo: "org/app", wantRef: "latest"},
+		{name: "digest preferred over tag", input: "ghcr.io/org/app:v1@" + digest, wantBaseURL: "https://ghcr.io", wantRepo: "org/app", wantRef: digest},
+		{name: "registry with port", input: "registry.local:5000/app:dev", wantBaseURL: "https://registry.local:5000", wantRepo: "app", wantRef: "dev"},
+		{name: "localhost over http", input: "localhost:5000/app:dev", wantBaseURL: "http://localhost:5000", wantRepo: "app", wantRef: "dev"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client, repository, reference, err := NewClientForReference(tt.input)
+
+			require.NoError(t, err)
+			require.NotNil(t, client)
+			assert.NotNil(t, client.HTTPClient)
+			assert.Equal(t, tt.wantBaseURL, client.BaseURL)
+			assert.Equal(t, tt.wantRepo, repository)
+			assert.Equal(t, tt.wantRef, reference)
+		})
+	}
+}
+
+func TestNewClientForReference_Options(t *testing.T) {
+	httpClient := &http.Client{}
+	logger := &mockLogger{}
+	auth := BasicAuth{Username: "user", Password: "pass"}
+
+	client, _, _, err := NewClientForReference("ghcr.io/org/app:v1",
+		WithAuth(auth),
+		WithHTTPClient(httpClient),
+		WithLogger(logger),
+	)
+
+	require.NoError(t, err)
+	assert.Equal(t, auth, client.Auth)
+	assert.Same(t, httpClient, client.HTTPClient)
+	assert.Same(t, logger, client.Logger)
+}
+
+func TestNewClientForReference_Invalid(t *testing.T) {
+	client, repository, reference, err := NewClientForReference("Invalid:Ref")
+
+	require.Error(t, err)
+	assert.Nil(t, client)
+	assert.Empty(t, repository)
+	assert.Empty(t, reference)
+}
+
+func TestValidateRepositoryName(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		wantErr string
+	}{
+		{name: "single component", input: "app"},
+		{name: "namespaced", input: "org/team/app"},
+		{name: "separators", input: "my.app/sub_dir/a__b/c--d"},
+		{name: "digits", input: "0/app2"},
+		{name: "max length", input: strings.Repeat("a", 255)},
+		{name: "empty", input: "", wantErr: "name is empty"},
+		{name: "too long", input: strings.Repeat("a", 256), wantErr: "exceeds limit of 255"},
+		{name: "uppercase", input: "org/MyApp", wantErr: `component "MyApp" must be lowercase`},
+		{name: "empty component", input: "org//app", wantErr: "empty path component"},
+		{name: "trailing slash", input: "org/app/", wantErr: "empty path component"},
+		{name: "leading separator", input: "-app", wantErr: `invalid component "-app"`},
+		{name: "trailing separator", input: "app.", wantErr: `invalid component "app."`},
+		{name: "triple underscore", input: "a___b", wantErr: `invalid component "a___b"`},
+		{name: "invalid character", input: "org/app:v1", wantErr: `invalid component "app:v1"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := ValidateRepositoryName(tt.input)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrInvalidRepositoryName)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestClient_ValidateNames(t *testing.T) {
+	registry := newFakeRegistry(t)
+	registry.addImage(t, ConfigBlob{}, nil, "v1")
+
+	client := registry.client()
+	client.ValidateNames = true
+
+	_, err := client.GetManifest(context.Background(), "Org/App", "v1")
+	require.ErrorIs(t, err, ErrInvalidRepositoryName)
+	_, err = client.ListTags(context.Background(), "org//app", nil)
+	require.ErrorIs(t, err, ErrInvalidRepositoryName)
+	_, err = client.HasBlob(context.Background(), "org/app!", "sha256:abc")
+	require.ErrorIs(t, err, ErrInvalidRepositoryName)
+	assert.Empty(t, registry.requests, "invalid names must not reach the registry")
+
+	_, err = client.GetManifest(context.Background(), "org/app", "v1")
+	require.NoError(t, err)
+
+	client.ValidateNames = false
+	_, err = client.HasManifest(context.Background(), "Org/App", "v1")
+	require.NoError(t, err, "names are not validated by default")
+}
@@ -0,0 +1,124 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// ociIndexMediaType is the media type of an OCI image index, which the Referrers API returns
+const ociIndexMediaType = "application/vnd.oci.image.index.v1+json"
+
+// GetReferrers lists the manifests (signatures, SBOMs, attestations, ...) whose subject is
+// the manifest with the given digest, optionally filtered by artifactType.
+// The Referrers API is used when the registry supports it. Otherwise the fallback tag
+// schema is read: an index tagged with the digest, "sha256:<hex>" becoming "sha256-<hex>".
+// A subject without referrers returns an empty list.
+func (c *BaseClient) GetReferrers(ctx context.Context, repository, digest, artifactType string) ([]ManifestReference, error) {
+	if !IsDigest(digest) {
+		return nil, fmt.Errorf("get referrers failed: invalid digest %q", digest)
+	}
+	if err := c.checkRepositoryName(repository); err != nil {
+		return nil, err
+	}
+
+	referrersURL := fmt.Sprintf("%s/v2/%s/referrers/%s", c.registryURL(), repository, digest)
+	if artifactType != "" {
+		referrersURL += "?artifactType=" + url.QueryEscape(artifactType)
+	}
+
+	c.logDebug("Registry request",
+		"operation", "GetReferrers",
+		"method", http.MethodGet,
+		"repository", repository,
+		"digest", digest,
+		"artifact_type", artifactType,
+		"url", referrersURL,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, referrersURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	setAcceptHeaders(req, referrersMediaTypes, nil)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeBody(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return c.getReferrersFallback(ctx, repository, digest, artifactType)
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("get referrers failed: %s - %s", resp.Status, string(body))
+	}
+
+	_, manifest, err := c.readManifest(resp)
+	if err != nil {
+		return nil, err
+	}
+	list, ok := manifest.ManifestData.(ManifestList)
+	if !ok {
+		return nil, fmt.Errorf("get referrers failed: unexpected media type %s", manifest.MediaType)
+	}
+
+	// Registries that do not filter by artifactType omit the OCI-Filters-Applied header
+	if !strings.Contains(resp.Header.Get("OCI-Filters-Applied"), "artifactType") {
+		return filterReferrers(list.Manifests, artifactType), nil
+	}
+	return nonNilReferrers(list.Manifests), nil
+}
+
+// getReferrersFallback reads the referrers index from the fallback tag schema
+func (c *BaseClient) getReferrersFallback(ctx context.Context, repository, digest, artifactType string) ([]ManifestReference, error) {
+	tag := strings.Replace(digest, ":", "-", 1)
+
+	c.logDebug("Referrers API not supported, using fallback tag",
+		"operation", "GetReferrers",
+		"repository", repository,
+		"digest", digest,
+		"tag", tag,
+	)
+
+	manifest, status, err := c.TryGetManifest(ctx, repository, tag, referrersMediaTypes...)
+	if status == http.StatusNotFound {
+		return []ManifestReference{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	list, ok := manifest.ManifestData.(ManifestList)
+	if !ok {
+		return nil, fmt.Errorf("get referrers failed: fallback tag %s is a %s, not an index", tag, manifest.MediaType)
+	}
+	return filterReferrers(list.Manifests, artifactType), nil
+}
+
+// filterReferrers keeps the referrers of artifactType, or all of them when it is empty
+func filterReferrers(referrers []ManifestReference, artifactType string) []ManifestReference {
+	if artifactType == "" {
+		return nonNilReferrers(referrers)
+	}
+	filtered := []ManifestReference{}
+	for _, referrer := range referrers {
+		if referrer.ArtifactType == artifactType {
+			filtered = append(filtered, referrer)
+		}
+	}
+	return filtered
+}
+
+// nonNilReferrers returns referrers, or an empty list when it is nil
+func nonNilReferrers(referrers []ManifestReference) []ManifestReference {
+	if referrers == nil {
+		return []ManifestReference{}
+	}
+	return referrers
+}
@@ -0,0 +1,125 @@
+package registryclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+const (
+	referrersSubject = "sha256:6e8b5a7c0f7d3f2a1b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a"
+	referrersIndex   = `{
+		"schemaVersion": 2,
+		"mediaType": "application/vnd.oci.image.index.v1+json",
+		"manifests": [
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:sig", "size": 100, "artifactType": "application/vnd.dev.cosign.artifact.sig.v1+json"},
+			{"mediaType": "application/vnd.oci.image.manifest.v1+json", "digest": "sha256:sbom", "size": 200, "artifactType": "application/spdx+json", "annotations": {"org.opencontainers.image.created": "2024-01-01T00:00:00Z"}}
+		]
+	}`
+)
+
+func TestGetReferrers_Native(t *testing.T) {
+	tests := []struct {
+		name           string
+		artifactType   string
+		filtersApplied bool
+		wantDigests    []string
+	}{
+		{name: "all", wantDigests: []string{"sha256:sig", "sha256:sbom"}},
+		{name: "filtered by registry", artifactType: "application/spdx+json", filtersApplied: true, wantDigests: []string{"sha256:sig", "sha256:sbom"}},
+		{name: "filtered by client", artifactType: "application/spdx+json", wantDigests: []string{"sha256:sbom"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var accept, gotArtifactType string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, "/v2/app/referrers/"+referrersSubject, r.URL.Path)
+				accept = r.Header.Get("Accept")
+				gotArtifactType = r.URL.Query().Get("artifactType")
+				if tt.filtersApplied {
+					w.Header().Set("OCI-Filters-Applied", "artifactType")
+				}
+				w.Header().Set("Content-Type", ociIndexMediaType)
+				_, _ = w.Write([]byte(referrersIndex))
+			}))
+			defer server.Close()
+
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+			referrers, err := client.GetReferrers(context.Background(), "app", referrersSubject, tt.artifactType)
+			require.NoError(t, err)
+
+			assert.Equal(t, ociIndexMediaType, accept)
+			assert.Equal(t, tt.artifactType, gotArtifactType)
+			digests := make([]string, len(referrers))
+			for i, referrer := range referrers {
+				digests[i] = referrer.Digest
+			}
+			assert.Equal(t, tt.wantDigests, digests)
+		})
+	}
+}
+
+func TestGetReferrers_Fallback(t *testing.T) {
+	var fallbackAccept string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/app/manifests/sha256-6e8b5a7c0f7d3f2a1b9c8d7e6f5a4b3c2d1e0f9a8b7c6d5e4f3a2b1c0d9e8f7a":
+			fallbackAccept = r.Header.Get("Accept")
+			w.Header().Set("Content-Type", ociIndexMediaType)
+			_, _ = w.Write([]byte(referrersIndex))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+
+	referrers, err := client.GetReferrers(context.Background(), "app", referrersSubject, "")
+	require.NoError(t, err)
+	require.Len(t, referrers, 2)
+	assert.Equal(t, ociIndexMediaType, fallbackAccept)
+	assert.Equal(t, "application/vnd.dev.cosign.artifact.sig.v1+json", referrers[0].ArtifactType)
+	assert.Equal(t, int64(200), referrers[1].Size)
+	assert.Equal(t, "2024-01-01T00:00:00Z", referrers[1].Annotations["org.opencontainers.image.created"])
+
+	referrers, err = client.GetReferrers(context.Background(), "app", referrersSubject, "application/spdx+json")
+	require.NoError(t, err)
+	require.Len(t, referrers, 1)
+	assert.Equal(t, "sha256:sbom", referrers[0].Digest)
+}
+
+func TestGetReferrers_NoReferrers(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	referrers, err := client.GetReferrers(context.Background(), "app", referrersSubject, "")
+
+	require.NoError(t, err)
+	assert.Equal(t, []ManifestReference{}, referrers)
+}
+
+func TestGetReferrers_Errors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+
+	_, err := client.GetReferrers(context.Background(), "app", referrersSubject, "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "get referrers failed: 401")
+
+	_, err = client.GetReferrers(context.Background(), "app", "latest", "")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid digest")
+}
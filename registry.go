@@ -1,16 +1,23 @@
 package registryclient
 
 import (
+	"bytes"
 	"context"
+	"errors"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
+	"slices"
 	"strings"
+	"time"
 
 	json "github.com/eznix86/registry-client/jsoncompat"
 )
 
+// Accept headers sent by each kind of operation, matching what it can parse: manifest
+// operations handle images and indexes in both formats, config fetches both config types,
+// and referrer lookups only an OCI index. See setAcceptHeaders.
 var defaultManifestMediaTypes = []string{
 	"application/vnd.oci.image.manifest.v1+json",
 	"application/vnd.oci.image.index.v1+json",
@@ -18,6 +25,22 @@ var defaultManifestMediaTypes = []string{
 	"application/vnd.docker.distribution.manifest.list.v2+json",
 }
 
+// indexFirstManifestMediaTypes is defaultManifestMediaTypes with the index types first,
+// sent when PreferIndex is set
+var indexFirstManifestMediaTypes = []string{
+	"application/vnd.oci.image.index.v1+json",
+	"application/vnd.docker.distribution.manifest.list.v2+json",
+	"application/vnd.oci.image.manifest.v1+json",
+	"application/vnd.docker.distribution.manifest.v2+json",
+}
+
+var defaultConfigMediaTypes = []string{
+	"application/vnd.oci.image.config.v1+json",
+	"application/vnd.docker.container.image.v1+json",
+}
+
+var referrersMediaTypes = []string{ociIndexMediaType}
+
 // ParseConfigBlob parses a config blob's content into a structured ConfigBlob.
 func ParseConfigBlob(content []byte) (*ConfigBlob, error) {
 	var cfg ConfigBlob
@@ -27,13 +50,52 @@ func ParseConfigBlob(content []byte) (*ConfigBlob, error) {
 	return &cfg, nil
 }
 
-func ParseManifest(b []byte) (*Manifest, error) {
+// DefaultMaxManifestBytes is the manifest size limit GetManifest applies when
+// MaxManifestBytes is not set, matching the 4 MiB registries are expected to accept
+const DefaultMaxManifestBytes = 4 << 20
+
+// ErrManifestTooLarge is returned when a manifest exceeds the manifest size limit
+var ErrManifestTooLarge = errors.New("manifest too large")
+
+// ParseManifestLimited parses a manifest like ParseManifest, but fails with
+// ErrManifestTooLarge when b is larger than maxSize bytes. maxSize <= 0 disables the check.
+func ParseManifestLimited(b []byte, maxSize int, mediaTypeHint ...string) (*Manifest, error) {
+	if maxSize > 0 && len(b) > maxSize {
+		return nil, fmt.Errorf("%w: %d bytes exceeds limit of %d", ErrManifestTooLarge, len(b), maxSize)
+	}
+	return ParseManifest(b, mediaTypeHint...)
+}
+
+// ErrNoRawManifest is returned by Manifest.Payload for manifests that were not parsed from bytes
+var ErrNoRawManifest = errors.New("manifest has no raw content")
+
+// Payload returns the exact bytes the manifest was parsed from. Use it when copying or
+// hashing a manifest: re-marshaling the parsed struct changes field order and whitespace
+// and drops unknown fields such as annotations, so the digest would no longer match.
+func (m *Manifest) Payload() ([]byte, error) {
+	if len(m.Raw) == 0 {
+		return nil, ErrNoRawManifest
+	}
+	return m.Raw, nil
+}
+
+// ParseManifest parses an image manifest or index. The mediaType field is optional in OCI
+// manifests: when it is absent, the first mediaTypeHint (typically the response Content-Type)
+// is used, and failing that the type is inferred from a "manifests" or "layers" field.
+func ParseManifest(b []byte, mediaTypeHint ...string) (*Manifest, error) {
 	var m Manifest
 	if err := json.Unmarshal(b, &m); err != nil {
 		return nil, err
 	}
 
 	m.Raw = b
+	if m.MediaType == "" {
+		mediaType, err := inferManifestMediaType(b, mediaTypeHint)
+		if err != nil {
+			return nil, err
+		}
+		m.MediaType = mediaType
+	}
 
 	switch m.MediaType {
 	case "application/vnd.oci.image.manifest.v1+json":
@@ -62,10 +124,112 @@ func ParseManifest(b []byte) (*Manifest, error) {
 	return &m, nil
 }
 
+// ErrInvalidManifest is returned by Manifest.Validate for a manifest missing required fields
+var ErrInvalidManifest = errors.New("invalid manifest")
+
+// Validate checks the structural requirements ParseManifest does not: schemaVersion 2,
+// a config digest for image manifests, and a well-formed digest on every layer and index
+// entry (an index may have no entries). Sizes must not be negative.
+func (m *Manifest) Validate() error {
+	if m.SchemaVersion != 2 {
+		return fmt.Errorf("%w: schemaVersion %d, expected 2", ErrInvalidManifest, m.SchemaVersion)
+	}
+
+	switch data := m.ManifestData.(type) {
+	case ImageManifest:
+		if err := validateDescriptor("config", data.Config.Digest, data.Config.Size); err != nil {
+			return err
+		}
+		for i, layer := range data.Layers {
+			if err := validateDescriptor(fmt.Sprintf("layer %d", i), layer.Digest, layer.Size); err != nil {
+				return err
+			}
+		}
+	case ManifestList:
+		for i, child := range data.Manifests {
+			if err := validateDescriptor(fmt.Sprintf("manifest %d", i), child.Digest, child.Size); err != nil {
+				return err
+			}
+		}
+	default:
+		return fmt.Errorf("%w: unsupported mediaType: %s", ErrInvalidManifest, m.MediaType)
+	}
+	return nil
+}
+
+// validateDescriptor checks the digest and size of a descriptor named name
+func validateDescriptor(name, digest string, size int64) error {
+	switch {
+	case digest == "":
+		return fmt.Errorf("%w: %s has no digest", ErrInvalidManifest, name)
+	case !digestRegexp.MatchString(digest):
+		return fmt.Errorf("%w: %s has malformed digest %q", ErrInvalidManifest, name, digest)
+	case size < 0:
+		return fmt.Errorf("%w: %s has negative size %d", ErrInvalidManifest, name, size)
+	}
+	return nil
+}
+
+// inferManifestMediaType returns the media type of a manifest without a mediaType field:
+// the first hint naming a manifest type, otherwise the OCI index or image manifest type
+// depending on whether the manifest has "manifests" or "layers"
+func inferManifestMediaType(b []byte, hints []string) (string, error) {
+	for _, hint := range hints {
+		mediaType, _, _ := strings.Cut(hint, ";")
+		mediaType = strings.TrimSpace(mediaType)
+		if manifestSchemaVersion(mediaType) == 2 {
+			return mediaType, nil
+		}
+	}
+
+	var fields struct {
+		Manifests *[]any `json:"manifests"`
+		Layers    *[]any `json:"layers"`
+	}
+	if err := json.Unmarshal(b, &fields); err != nil {
+		return "", err
+	}
+	switch {
+	case fields.Manifests != nil:
+		return ociIndexMediaType, nil
+	case fields.Layers != nil:
+		return ociManifestMediaType, nil
+	default:
+		return "", fmt.Errorf("unsupported mediaType: manifest has no mediaType, manifests or layers")
+	}
+}
+
+// acceptHeadersContextKey is the type of AcceptHeadersKey
+type acceptHeadersContextKey struct{}
+
+// AcceptHeadersKey is the context key for a []string of manifest Accept headers.
+// Manifest operations use it when no acceptHeaders are passed to the call.
+var AcceptHeadersKey = acceptHeadersContextKey{}
+
+// ContextWithAcceptHeaders returns a copy of ctx carrying manifest Accept headers under AcceptHeadersKey
+func ContextWithAcceptHeaders(ctx context.Context, headers ...string) context.Context {
+	return context.WithValue(ctx, AcceptHeadersKey, headers)
+}
+
 // addAcceptHeaders adds Accept headers for OCI/Docker manifests.
-// If customHeaders is provided, only those are used.
-func addAcceptHeaders(req *http.Request, customHeaders []string) {
+// If customHeaders is provided, only those are used. Otherwise headers stored in
+// the request context under AcceptHeadersKey are used, then the defaults, listing
+// index types first when PreferIndex is set.
+func (c *BaseClient) addAcceptHeaders(req *http.Request, customHeaders []string) {
 	headers := defaultManifestMediaTypes
+	if c.PreferIndex {
+		headers = indexFirstManifestMediaTypes
+	}
+	if ctxHeaders, ok := req.Context().Value(AcceptHeadersKey).([]string); ok && len(ctxHeaders) > 0 {
+		headers = ctxHeaders
+	}
+	setAcceptHeaders(req, headers, customHeaders)
+}
+
+// setAcceptHeaders adds an Accept header for each media type of customHeaders, or of
+// defaults when customHeaders is empty. Every operation sets Accept through it.
+func setAcceptHeaders(req *http.Request, defaults, customHeaders []string) {
+	headers := defaults
 	if len(customHeaders) > 0 {
 		headers = customHeaders
 	}
@@ -76,13 +240,24 @@ func addAcceptHeaders(req *http.Request, customHeaders []string) {
 
 // parseLinkHeader parses the Link header and extracts pagination parameters.
 // Link format: </v2/_catalog?last=repo&n=100>; rel="next"
+// Links with another relation type (e.g. rel="prev") are skipped; a link without rel is
+// taken as the next page.
 func parseLinkHeader(linkHeader string) PaginatedResponse {
 	if linkHeader == "" {
 		return PaginatedResponse{}
 	}
 
-	// Parse the link header
-	parts := strings.Split(linkHeader, ";")
+	for link := range strings.SplitSeq(linkHeader, ",") {
+		if next, hasRel := linkRel(link, "next"); next || !hasRel {
+			return parseNextLink(link)
+		}
+	}
+	return PaginatedResponse{}
+}
+
+// parseNextLink extracts the pagination parameters of a single link
+func parseNextLink(link string) PaginatedResponse {
+	parts := strings.Split(link, ";")
 
 	// Extract URL from <...>
 	urlPart := strings.TrimSpace(parts[0])
@@ -108,9 +283,34 @@ func parseLinkHeader(linkHeader string) PaginatedResponse {
 		HasMore: true,
 		Last:    last,
 		N:       n,
+		Cursor:  last,
 	}
 }
 
+// linkRel reports whether a link of a Link header has rel among its relation types, and
+// whether it has a rel parameter at all. The parameter name is matched case-insensitively
+// and the value may be quoted or not: rel="next", rel=next and Rel="prev next" all match next.
+func linkRel(link, rel string) (matches, hasRel bool) {
+	params := link
+	if end := strings.Index(link, ">"); end != -1 {
+		params = link[end+1:]
+	}
+
+	for param := range strings.SplitSeq(params, ";") {
+		name, value, ok := strings.Cut(param, "=")
+		if !ok || !strings.EqualFold(strings.TrimSpace(name), "rel") {
+			continue
+		}
+		hasRel = true
+		for relType := range strings.FieldsSeq(strings.Trim(strings.TrimSpace(value), `"`)) {
+			if strings.EqualFold(relType, rel) {
+				return true, true
+			}
+		}
+	}
+	return false, hasRel
+}
+
 // applyPagination adds pagination query parameters to the request if provided
 func applyPagination(req *http.Request, pagination *PaginationParams) {
 	if pagination == nil {
@@ -120,17 +320,80 @@ func applyPagination(req *http.Request, pagination *PaginationParams) {
 	if pagination.N > 0 {
 		q.Add("n", fmt.Sprintf("%d", pagination.N))
 	}
-	if pagination.Last != "" {
-		q.Add("last", pagination.Last)
+	if last := pagination.position(); last != "" {
+		q.Add("last", last)
+	}
+	// Slashes are valid in a query string; keep them raw so namespaced repository
+	// cursors (e.g. "team/app") reach registries that do not decode %2F
+	req.URL.RawQuery = strings.ReplaceAll(q.Encode(), "%2F", "/")
+}
+
+// pagination returns the parameters to send for p, adjusted to what earlier responses
+// of the endpoint showed of the registry: n is dropped once the registry ignored it, and
+// capped to the page size the registry limits it to
+func (c *BaseClient) pagination(state *pageSizeState, p *PaginationParams) *PaginationParams {
+	if p == nil || p.N <= 0 {
+		return p
+	}
+	adjusted := *p
+	if state.ignored.Load() {
+		adjusted.N = 0
+	} else if limit := int(state.max.Load()); limit > 0 && adjusted.N > limit {
+		adjusted.N = limit
+	}
+	return &adjusted
+}
+
+// notePageSize records whether a paginated response of the endpoint of state honored the
+// n that was sent: a page with more items than requested means the registry ignores n,
+// and a Link asking for a smaller n means it caps n. A short page alone is not taken as
+// a cap, since registries may filter what a page returns.
+func (c *BaseClient) notePageSize(state *pageSizeState, operation string, sent *PaginationParams, returned int, next PaginatedResponse) {
+	if sent == nil || sent.N <= 0 {
+		return
+	}
+
+	if returned > sent.N {
+		if !state.ignored.Swap(true) {
+			c.logDebug("Registry ignores page size, no longer sending n",
+				"operation", operation,
+				"requested", sent.N,
+				"returned", returned,
+			)
+		}
+		return
+	}
+	if next.N <= 0 || next.N >= sent.N {
+		return
+	}
+
+	if int(state.max.Swap(int64(next.N))) != next.N {
+		c.logDebug("Registry caps page size",
+			"operation", operation,
+			"requested", sent.N,
+			"max_page_size", next.N,
+		)
 	}
-	req.URL.RawQuery = q.Encode()
 }
 
 // HealthCheck performs a GET on /v2/ to verify registry availability.
 // Returns the HTTP status code and only returns an error for programming errors (invalid URL).
 // Connection failures (refused, timeout, DNS errors) are mapped to 503 Service Unavailable.
 func (c *BaseClient) HealthCheck(ctx context.Context) (int, error) {
-	url := fmt.Sprintf("%s/v2/", c.BaseURL)
+	status, err := c.CheckHealth(ctx)
+	if err != nil {
+		return 0, err
+	}
+	return status.Code, nil
+}
+
+// CheckHealth is HealthCheck for monitoring: besides the status code it reports the
+// latency of the GET on /v2/ (retries included) and whether the registry requires
+// authentication, i.e. answers 401 with a WWW-Authenticate challenge. Since that GET
+// carries Auth, a client with credentials sends a second GET without them to tell.
+// Errors and connection failures are handled like HealthCheck.
+func (c *BaseClient) CheckHealth(ctx context.Context) (*HealthStatus, error) {
+	url := fmt.Sprintf("%s/v2/", c.registryURL())
 
 	c.logDebug("Registry request",
 		"operation", "HealthCheck",
@@ -140,9 +403,11 @@ func (c *BaseClient) HealthCheck(ctx context.Context) (int, error) {
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return 0, err
+		return nil, err
 	}
+	start := time.Now()
 	resp, err := c.Do(req)
+	latency := time.Since(start)
 	if err != nil {
 
 		c.logDebug("Registry unreachable",
@@ -150,22 +415,54 @@ func (c *BaseClient) HealthCheck(ctx context.Context) (int, error) {
 			"error", err.Error(),
 		)
 
-		return http.StatusServiceUnavailable, nil
+		return &HealthStatus{Code: http.StatusServiceUnavailable, Latency: latency}, nil
 	}
 	defer c.closeBody(resp.Body)
 
+	status := &HealthStatus{
+		Code:         resp.StatusCode,
+		Latency:      latency,
+		RequiresAuth: isAuthChallenge(resp),
+	}
+	if c.Auth != nil && !status.RequiresAuth {
+		status.RequiresAuth = c.anonymousChallenge(ctx, url)
+	}
+
 	c.logDebug("Registry response",
 		"operation", "HealthCheck",
-		"status_code", resp.StatusCode,
+		"status_code", status.Code,
+		"latency", status.Latency.String(),
+		"requires_auth", status.RequiresAuth,
 	)
 
-	return resp.StatusCode, nil
+	return status, nil
+}
+
+// isAuthChallenge reports whether resp is a 401 with a WWW-Authenticate challenge
+func isAuthChallenge(resp *http.Response) bool {
+	return resp.StatusCode == http.StatusUnauthorized && resp.Header.Get("WWW-Authenticate") != ""
+}
+
+// anonymousChallenge reports whether a GET of url without credentials is answered with
+// an authentication challenge. Failures report false.
+func (c *BaseClient) anonymousChallenge(ctx context.Context, url string) bool {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return false
+	}
+	resp, err := c.doWithoutAuth(req)
+	if err != nil {
+		c.logDebug("Anonymous probe failed", "operation", "HealthCheck", "url", url, "error", err.Error())
+		return false
+	}
+	defer c.closeBody(resp.Body)
+	return isAuthChallenge(resp)
 }
 
 // GetCatalog retrieves the list of repositories from /v2/_catalog.
 // Optional pagination parameters can be provided.
 func (c *BaseClient) GetCatalog(ctx context.Context, pagination *PaginationParams) (*CatalogResponse, error) {
-	url := fmt.Sprintf("%s/v2/_catalog", c.BaseURL)
+	url := fmt.Sprintf("%s/v2/_catalog", c.registryURL())
 
 	logArgs := []any{
 		"operation", "GetCatalog",
@@ -173,7 +470,7 @@ func (c *BaseClient) GetCatalog(ctx context.Context, pagination *PaginationParam
 		"url", url,
 	}
 	if pagination != nil {
-		logArgs = append(logArgs, "page_size", pagination.N, "last", pagination.Last)
+		logArgs = append(logArgs, "page_size", pagination.N, "last", pagination.position())
 	}
 	c.logDebug("Registry request", logArgs...)
 
@@ -182,7 +479,8 @@ func (c *BaseClient) GetCatalog(ctx context.Context, pagination *PaginationParam
 		return nil, err
 	}
 
-	applyPagination(req, pagination)
+	sent := c.pagination(&c.catalogPageSize, pagination)
+	applyPagination(req, sent)
 
 	resp, err := c.Do(req)
 	if err != nil {
@@ -204,6 +502,7 @@ func (c *BaseClient) GetCatalog(ctx context.Context, pagination *PaginationParam
 
 	linkHeader := resp.Header.Get("Link")
 	paginationResp := parseLinkHeader(linkHeader)
+	c.notePageSize(&c.catalogPageSize, "GetCatalog", sent, len(data.Repositories), paginationResp)
 
 	c.logDebug("Registry response",
 		"operation", "GetCatalog",
@@ -211,6 +510,11 @@ func (c *BaseClient) GetCatalog(ctx context.Context, pagination *PaginationParam
 		"has_more", paginationResp.HasMore,
 	)
 
+	// Some registries return "repositories": null for an empty catalog
+	if data.Repositories == nil {
+		data.Repositories = []string{}
+	}
+
 	return &CatalogResponse{
 		Repositories:      data.Repositories,
 		PaginatedResponse: paginationResp,
@@ -220,7 +524,28 @@ func (c *BaseClient) GetCatalog(ctx context.Context, pagination *PaginationParam
 // GetManifest retrieves a manifest by repository and reference.
 // Optional acceptHeaders can override defaults.
 func (c *BaseClient) GetManifest(ctx context.Context, repository, reference string, acceptHeaders ...string) (*ManifestResponse, error) {
-	url := fmt.Sprintf("%s/v2/%s/manifests/%s", c.BaseURL, repository, reference)
+	manifest, _, err := c.TryGetManifest(ctx, repository, reference, acceptHeaders...)
+	return manifest, err
+}
+
+// TryGetManifest is GetManifest that also returns the HTTP status code of the response,
+// so callers can handle e.g. 404 as "not found" without parsing the error.
+// The status is 0 when no response was received.
+func (c *BaseClient) TryGetManifest(ctx context.Context, repository, reference string, acceptHeaders ...string) (*ManifestResponse, int, error) {
+	if err := c.checkRepositoryName(repository); err != nil {
+		return nil, 0, err
+	}
+
+	if manifest := c.cachedManifest(repository, reference); manifest != nil {
+		c.logDebug("Manifest cache hit",
+			"operation", "GetManifest",
+			"repository", repository,
+			"reference", reference,
+		)
+		return manifest, http.StatusOK, nil
+	}
+
+	url := fmt.Sprintf("%s/v2/%s/manifests/%s", c.registryURL(), repository, reference)
 
 	c.logDebug("Registry request",
 		"operation", "GetManifest",
@@ -232,28 +557,23 @@ func (c *BaseClient) GetManifest(ctx context.Context, repository, reference stri
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
-	addAcceptHeaders(req, acceptHeaders)
+	c.addAcceptHeaders(req, acceptHeaders)
 
-	resp, err := c.Do(req)
+	resp, err := c.doRetryNotFound(req)
 	if err != nil {
-		return nil, err
+		return nil, 0, err
 	}
 	defer c.closeBody(resp.Body)
 	if resp.StatusCode != http.StatusOK {
 		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("get manifest failed: %s - %s", resp.Status, string(body))
+		return nil, resp.StatusCode, fmt.Errorf("get manifest failed: %s - %s", resp.Status, string(body))
 	}
 
-	body, err := io.ReadAll(resp.Body)
+	body, manifest, err := c.readManifest(resp)
 	if err != nil {
-		return nil, err
-	}
-
-	manifest, err := ParseManifest(body)
-	if err != nil {
-		return nil, err
+		return nil, resp.StatusCode, err
 	}
 
 	c.logDebug("Registry response",
@@ -265,18 +585,96 @@ func (c *BaseClient) GetManifest(ctx context.Context, repository, reference stri
 		"schema_version", manifest.SchemaVersion,
 	)
 
+	// Only content verified against the digest it was requested by is cached
+	if c.ManifestCache != nil && IsDigest(reference) && VerifyDigest(body, reference) == nil {
+		c.ManifestCache.Add(manifestCacheKey(repository, reference), bytes.Clone(body), manifest.MediaType)
+	}
+
 	return &ManifestResponse{
 		SchemaVersion: manifest.SchemaVersion,
 		MediaType:     manifest.MediaType,
+		IsIndex:       isIndexMediaType(manifest.MediaType),
 		ManifestData:  manifest.ManifestData,
 		Digest:        resp.Header.Get("Docker-Content-Digest"),
 		RawContent:    body,
-	}, nil
+		Headers:       resp.Header.Clone(),
+		Attempts:      ResponseAttempts(resp),
+	}, resp.StatusCode, nil
+}
+
+// manifestCacheKey returns the ManifestCache key of a manifest of repository
+func manifestCacheKey(repository, digest string) string {
+	return repository + "@" + digest
+}
+
+// cachedManifest returns the manifest ManifestCache holds for a digest reference of
+// repository, or nil
+func (c *BaseClient) cachedManifest(repository, reference string) *ManifestResponse {
+	if c.ManifestCache == nil || !IsDigest(reference) {
+		return nil
+	}
+	content, mediaType, ok := c.ManifestCache.Get(manifestCacheKey(repository, reference))
+	if !ok {
+		return nil
+	}
+	manifest, err := ParseManifest(content, mediaType)
+	if err != nil {
+		return nil
+	}
+	return &ManifestResponse{
+		SchemaVersion: manifest.SchemaVersion,
+		MediaType:     manifest.MediaType,
+		IsIndex:       isIndexMediaType(manifest.MediaType),
+		ManifestData:  manifest.ManifestData,
+		Digest:        reference,
+		RawContent:    bytes.Clone(content),
+	}
+}
+
+// readManifest reads and parses a manifest response body, enforcing the manifest size limit
+// without reading more than one byte past it
+func (c *BaseClient) readManifest(resp *http.Response) ([]byte, *Manifest, error) {
+	limit := c.maxManifestBytes()
+	reader := io.Reader(resp.Body)
+	if limit > 0 {
+		if resp.ContentLength > int64(limit) {
+			return nil, nil, fmt.Errorf("%w: %d bytes exceeds limit of %d", ErrManifestTooLarge, resp.ContentLength, limit)
+		}
+		reader = io.LimitReader(resp.Body, int64(limit)+1)
+	}
+
+	body, err := io.ReadAll(reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	manifest, err := ParseManifestLimited(body, limit, resp.Header.Get("Content-Type"))
+	if err != nil {
+		return nil, nil, err
+	}
+	if c.StrictJSON {
+		if err := checkStrictManifest(body, manifest.MediaType); err != nil {
+			return nil, nil, err
+		}
+	}
+	return body, manifest, nil
+}
+
+// maxManifestBytes returns the manifest size limit, where 0 means the default and negative means none
+func (c *BaseClient) maxManifestBytes() int {
+	if c.MaxManifestBytes == 0 {
+		return DefaultMaxManifestBytes
+	}
+	return c.MaxManifestBytes
 }
 
 // HasManifest checks whether a manifest exists for a repository/reference.
 func (c *BaseClient) HasManifest(ctx context.Context, repository, reference string, acceptHeaders ...string) (bool, error) {
-	url := fmt.Sprintf("%s/v2/%s/manifests/%s", c.BaseURL, repository, reference)
+	if err := c.checkRepositoryName(repository); err != nil {
+		return false, err
+	}
+
+	url := fmt.Sprintf("%s/v2/%s/manifests/%s", c.registryURL(), repository, reference)
 
 	c.logDebug("Registry request",
 		"operation", "HasManifest",
@@ -291,7 +689,7 @@ func (c *BaseClient) HasManifest(ctx context.Context, repository, reference stri
 		return false, err
 	}
 
-	addAcceptHeaders(req, acceptHeaders)
+	c.addAcceptHeaders(req, acceptHeaders)
 
 	resp, err := c.Do(req)
 	if err != nil {
@@ -318,35 +716,103 @@ func (c *BaseClient) HasManifest(ctx context.Context, repository, reference stri
 	}
 }
 
-// GetBlob fetches a blob
-func (c *BaseClient) GetBlob(ctx context.Context, repository, digest string) (*BlobResponse, error) {
-	url := fmt.Sprintf("%s/v2/%s/blobs/%s", c.BaseURL, repository, digest)
+// ErrManifestNotFound is returned by HeadManifest when the registry answers 404
+var ErrManifestNotFound = errors.New("manifest not found")
+
+// HeadManifest issues a HEAD for a manifest and returns its digest and media type without
+// downloading the body. A missing manifest fails with ErrManifestNotFound. SchemaVersion is derived from the media type when it is known;
+// IsIndex tells whether a platform must be picked before fetching an image.
+// ManifestData and RawContent are nil.
+// Optional acceptHeaders can override defaults.
+func (c *BaseClient) HeadManifest(ctx context.Context, repository, reference string, acceptHeaders ...string) (*ManifestResponse, error) {
+	if err := c.checkRepositoryName(repository); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/v2/%s/manifests/%s", c.registryURL(), repository, reference)
 
 	c.logDebug("Registry request",
-		"operation", "GetBlob",
-		"method", http.MethodGet,
+		"operation", "HeadManifest",
+		"method", http.MethodHead,
 		"repository", repository,
-		"digest", digest,
+		"reference", reference,
 		"url", url,
 	)
 
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
 	if err != nil {
 		return nil, err
 	}
 
+	c.addAcceptHeaders(req, acceptHeaders)
+
 	resp, err := c.Do(req)
 	if err != nil {
 		return nil, err
 	}
 	defer c.closeBody(resp.Body)
 
-	if resp.StatusCode != http.StatusOK {
-		body, _ := io.ReadAll(resp.Body)
-		return nil, fmt.Errorf("get blob failed: %s - %s", resp.Status, string(body))
+	switch resp.StatusCode {
+	case http.StatusOK:
+	case http.StatusNotFound:
+		return nil, fmt.Errorf("head manifest failed: %s: %w", resp.Status, ErrManifestNotFound)
+	default:
+		return nil, fmt.Errorf("head manifest failed: %s", resp.Status)
 	}
 
-	content, err := io.ReadAll(resp.Body)
+	mediaType, _, _ := strings.Cut(resp.Header.Get("Content-Type"), ";")
+	mediaType = strings.TrimSpace(mediaType)
+
+	c.logDebug("Registry response",
+		"operation", "HeadManifest",
+		"repository", repository,
+		"reference", reference,
+		"media_type", mediaType,
+		"digest", resp.Header.Get("Docker-Content-Digest"),
+	)
+
+	return &ManifestResponse{
+		SchemaVersion: manifestSchemaVersion(mediaType),
+		MediaType:     mediaType,
+		IsIndex:       isIndexMediaType(mediaType),
+		Digest:        resp.Header.Get("Docker-Content-Digest"),
+		Headers:       resp.Header.Clone(),
+		Attempts:      ResponseAttempts(resp),
+	}, nil
+}
+
+// isIndexMediaType reports whether mediaType is an OCI image index or a Docker manifest list
+func isIndexMediaType(mediaType string) bool {
+	return mediaType == ociIndexMediaType || mediaType == "application/vnd.docker.distribution.manifest.list.v2+json"
+}
+
+// manifestSchemaVersion returns the schemaVersion implied by a manifest media type, or 0 if unknown
+func manifestSchemaVersion(mediaType string) int {
+	switch mediaType {
+	case "application/vnd.docker.distribution.manifest.v1+json",
+		"application/vnd.docker.distribution.manifest.v1+prettyjws":
+		return 1
+	case "application/vnd.docker.distribution.manifest.v2+json",
+		"application/vnd.docker.distribution.manifest.list.v2+json",
+		"application/vnd.oci.image.manifest.v1+json",
+		"application/vnd.oci.image.index.v1+json":
+		return 2
+	default:
+		return 0
+	}
+}
+
+// GetBlob fetches a blob.
+// When MaxBlobBytes is set, blobs larger than the limit fail with ErrBlobTooLarge.
+// Optional acceptHeaders are sent as Accept headers; none are sent by default.
+func (c *BaseClient) GetBlob(ctx context.Context, repository, digest string, acceptHeaders ...string) (*BlobResponse, error) {
+	resp, err := c.openBlob(ctx, "GetBlob", repository, digest, acceptHeaders)
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeBody(resp.Body)
+
+	content, err := c.readBlobBody(resp)
 	if err != nil {
 		return nil, err
 	}
@@ -359,16 +825,23 @@ func (c *BaseClient) GetBlob(ctx context.Context, repository, digest string) (*B
 	)
 
 	return &BlobResponse{
-		Digest:  resp.Header.Get("Docker-Content-Digest"),
-		Content: content,
-		Size:    int64(len(content)),
+		Digest:    resp.Header.Get("Docker-Content-Digest"),
+		Content:   content,
+		Size:      int64(len(content)),
+		MediaType: resp.Header.Get("Content-Type"),
+		Headers:   resp.Header.Clone(),
+		Attempts:  ResponseAttempts(resp),
 	}, nil
 }
 
 // ListTags retrieves all tags for a given repository.
 // Optional pagination parameters can be provided.
 func (c *BaseClient) ListTags(ctx context.Context, repository string, pagination *PaginationParams) (*TagsResponse, error) {
-	url := fmt.Sprintf("%s/v2/%s/tags/list", c.BaseURL, repository)
+	if err := c.checkRepositoryName(repository); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/v2/%s/tags/list", c.registryURL(), repository)
 
 	logArgs := []any{
 		"operation", "ListTags",
@@ -377,7 +850,7 @@ func (c *BaseClient) ListTags(ctx context.Context, repository string, pagination
 		"url", url,
 	}
 	if pagination != nil {
-		logArgs = append(logArgs, "page_size", pagination.N, "last", pagination.Last)
+		logArgs = append(logArgs, "page_size", pagination.N, "last", pagination.position())
 	}
 	c.logDebug("Registry request", logArgs...)
 
@@ -386,7 +859,8 @@ func (c *BaseClient) ListTags(ctx context.Context, repository string, pagination
 		return nil, err
 	}
 
-	applyPagination(req, pagination)
+	sent := c.pagination(&c.tagsPageSize, pagination)
+	applyPagination(req, sent)
 
 	resp, err := c.Do(req)
 	if err != nil {
@@ -409,6 +883,7 @@ func (c *BaseClient) ListTags(ctx context.Context, repository string, pagination
 
 	linkHeader := resp.Header.Get("Link")
 	paginationResp := parseLinkHeader(linkHeader)
+	c.notePageSize(&c.tagsPageSize, "ListTags", sent, len(data.Tags), paginationResp)
 
 	c.logDebug("Registry response",
 		"operation", "ListTags",
@@ -417,6 +892,11 @@ func (c *BaseClient) ListTags(ctx context.Context, repository string, pagination
 		"has_more", paginationResp.HasMore,
 	)
 
+	// Some registries return "tags": null for a repository without tags
+	if data.Tags == nil {
+		data.Tags = []string{}
+	}
+
 	return &TagsResponse{
 		Name:              data.Name,
 		Tags:              data.Tags,
@@ -424,11 +904,99 @@ func (c *BaseClient) ListTags(ctx context.Context, repository string, pagination
 	}, nil
 }
 
+// PutManifest pushes a manifest to repository under reference, a tag or its digest.
+// manifest is sent unchanged with mediaType as Content-Type. The returned digest is the
+// one reported by the registry, or computed locally when the registry does not report one.
+// For a manifest with a subject, the returned Subject tells whether the registry linked it.
+func (c *BaseClient) PutManifest(ctx context.Context, repository, reference, mediaType string, manifest []byte) (*ManifestResponse, error) {
+	if err := c.checkRepositoryName(repository); err != nil {
+		return nil, err
+	}
+
+	url := fmt.Sprintf("%s/v2/%s/manifests/%s", c.registryURL(), repository, reference)
+
+	c.logDebug("Registry request",
+		"operation", "PutManifest",
+		"method", http.MethodPut,
+		"repository", repository,
+		"reference", reference,
+		"media_type", mediaType,
+		"size_bytes", len(manifest),
+		"url", url,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, url, bytes.NewReader(manifest))
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", mediaType)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("put manifest failed: %s - %s", resp.Status, string(body))
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		digest = c.computeDigest(manifest)
+	}
+
+	c.logDebug("Registry response",
+		"operation", "PutManifest",
+		"repository", repository,
+		"reference", reference,
+		"digest", digest,
+		"subject", resp.Header.Get("OCI-Subject"),
+	)
+
+	response := &ManifestResponse{
+		SchemaVersion: manifestSchemaVersion(mediaType),
+		MediaType:     mediaType,
+		IsIndex:       isIndexMediaType(mediaType),
+		Digest:        digest,
+		RawContent:    manifest,
+		Headers:       resp.Header.Clone(),
+		Attempts:      ResponseAttempts(resp),
+		Subject:       resp.Header.Get("OCI-Subject"),
+	}
+	if parsed, err := ParseManifest(manifest, mediaType); err == nil {
+		response.ManifestData = parsed.ManifestData
+	}
+	return response, nil
+}
+
+// ErrTagReference is returned by DeleteManifest when given a tag instead of a digest
+var ErrTagReference = errors.New("manifest reference is a tag, not a digest")
+
 // DeleteManifest deletes a manifest by repository and digest.
-// Note: reference must be a digest (sha256:...), not a tag.
+// Note: reference must be a digest (sha256:...), not a tag. Tags fail with ErrTagReference
+// unless ResolveTagsOnDelete is set, in which case the tag is resolved to its digest first.
 // Optional acceptHeaders can override defaults.
 func (c *BaseClient) DeleteManifest(ctx context.Context, repository, digest string, acceptHeaders ...string) error {
-	url := fmt.Sprintf("%s/v2/%s/manifests/%s", c.BaseURL, repository, digest)
+	_, err := c.DeleteManifestConfirm(ctx, repository, digest, acceptHeaders...)
+	return err
+}
+
+// DeleteManifestConfirm deletes a manifest like DeleteManifest and returns the digest the
+// registry reports in Docker-Content-Digest, or the digest that was deleted when the
+// registry does not echo it. In dry-run mode it returns the digest that would be deleted.
+func (c *BaseClient) DeleteManifestConfirm(ctx context.Context, repository, digest string, acceptHeaders ...string) (string, error) {
+	if err := c.checkRepositoryName(repository); err != nil {
+		return "", err
+	}
+
+	digest, err := c.deleteDigest(ctx, repository, digest, acceptHeaders)
+	if err != nil {
+		return "", err
+	}
+
+	url := fmt.Sprintf("%s/v2/%s/manifests/%s", c.registryURL(), repository, digest)
 
 	if c.DisableDelete {
 		c.logInfo("DELETE DISABLED (dry-run mode)",
@@ -437,7 +1005,7 @@ func (c *BaseClient) DeleteManifest(ctx context.Context, repository, digest stri
 			"digest", digest,
 			"url", url,
 		)
-		return nil
+		return digest, nil
 	}
 
 	c.logDebug("Registry request",
@@ -450,35 +1018,98 @@ func (c *BaseClient) DeleteManifest(ctx context.Context, repository, digest stri
 
 	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, url, nil)
 	if err != nil {
-		return err
+		return "", err
 	}
 
-	addAcceptHeaders(req, acceptHeaders)
+	c.addAcceptHeaders(req, acceptHeaders)
 
 	resp, err := c.Do(req)
 	if err != nil {
-		return err
+		return "", err
 	}
 	defer c.closeBody(resp.Body)
 
-	if resp.StatusCode != http.StatusAccepted && resp.StatusCode != http.StatusNoContent {
+	if !c.deleteSucceeded(resp.StatusCode) {
 		body, _ := io.ReadAll(resp.Body)
-		return fmt.Errorf("delete manifest failed: %s - %s", resp.Status, string(body))
+		return "", fmt.Errorf("delete manifest failed: %s - %s", resp.Status, string(body))
 	}
 
-	c.logDebug("Registry response",
+	confirmed := resp.Header.Get("Docker-Content-Digest")
+	c.logInfo("Manifest deleted",
 		"operation", "DeleteManifest",
 		"repository", repository,
 		"digest", digest,
+		"confirmed_digest", confirmed,
 		"status_code", resp.StatusCode,
 	)
 
-	return nil
+	if confirmed == "" {
+		return digest, nil
+	}
+	return confirmed, nil
+}
+
+// defaultDeleteSuccessStatuses are the statuses a deletion succeeds with when
+// DeleteSuccessStatuses is not set
+var defaultDeleteSuccessStatuses = []int{http.StatusOK, http.StatusAccepted, http.StatusNoContent}
+
+// deleteSucceeded reports whether status is one of DeleteSuccessStatuses
+func (c *BaseClient) deleteSucceeded(status int) bool {
+	statuses := c.DeleteSuccessStatuses
+	if statuses == nil {
+		statuses = defaultDeleteSuccessStatuses
+	}
+	return slices.Contains(statuses, status)
+}
+
+// deleteDigest returns reference unchanged when it is a digest. Tags are rejected with
+// ErrTagReference, or resolved through resolveManifestDigest when ResolveTagsOnDelete is set.
+func (c *BaseClient) deleteDigest(ctx context.Context, repository, reference string, acceptHeaders []string) (string, error) {
+	if IsDigest(reference) {
+		return reference, nil
+	}
+	if !c.ResolveTagsOnDelete {
+		return "", fmt.Errorf("%w: %s:%s must be resolved to a digest first (see ResolveTagsOnDelete)",
+			ErrTagReference, repository, reference)
+	}
+	return c.resolveManifestDigest(ctx, repository, reference, acceptHeaders)
+}
+
+// resolveManifestDigest issues a HEAD for a manifest and returns its Docker-Content-Digest
+func (c *BaseClient) resolveManifestDigest(ctx context.Context, repository, reference string, acceptHeaders []string) (string, error) {
+	url := fmt.Sprintf("%s/v2/%s/manifests/%s", c.registryURL(), repository, reference)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodHead, url, nil)
+	if err != nil {
+		return "", err
+	}
+
+	c.addAcceptHeaders(req, acceptHeaders)
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer c.closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusOK {
+		return "", fmt.Errorf("resolve manifest digest failed: %s", resp.Status)
+	}
+
+	digest := resp.Header.Get("Docker-Content-Digest")
+	if digest == "" {
+		return "", fmt.Errorf("resolve manifest digest failed: no Docker-Content-Digest header for %s:%s", repository, reference)
+	}
+	return digest, nil
 }
 
 // HasBlob checks if a blob exists in the repository.
 func (c *BaseClient) HasBlob(ctx context.Context, repository, digest string) (bool, error) {
-	url := fmt.Sprintf("%s/v2/%s/blobs/%s", c.BaseURL, repository, digest)
+	if err := c.checkRepositoryName(repository); err != nil {
+		return false, err
+	}
+
+	url := fmt.Sprintf("%s/v2/%s/blobs/%s", c.registryURL(), repository, digest)
 
 	c.logDebug("Registry request",
 		"operation", "HasBlob",
@@ -1,6 +1,7 @@
 package registryclient
 
 import (
+	"bytes"
 	"context"
 	"errors"
 	"io"
@@ -8,6 +9,8 @@ import (
 	"net/http/httptest"
 	"os"
 	"path/filepath"
+	"strconv"
+	"strings"
 	"testing"
 	"time"
 
@@ -100,6 +103,121 @@ func TestParseManifest(t *testing.T) {
 	})
 }
 
+func TestManifest_Validate(t *testing.T) {
+	t.Run("valid fixtures", func(t *testing.T) {
+		manifestFiles, err := filepath.Glob("testdata/manifests/*.json")
+		require.NoError(t, err)
+
+		for _, file := range manifestFiles {
+			manifestJSON, err := os.ReadFile(filepath.Clean(file))
+			require.NoError(t, err)
+			m, err := ParseManifest(manifestJSON)
+			if err != nil {
+				continue // unsupported media types are covered by TestParseManifest
+			}
+			assert.NoError(t, m.Validate(), filepath.Base(file))
+		}
+	})
+
+	tests := []struct {
+		file    string
+		wantErr string
+	}{
+		{file: "image-no-config-digest.json", wantErr: "config has no digest"},
+		{file: "image-layer-no-digest.json", wantErr: "layer 0 has no digest"},
+		{file: "index-entry-no-digest.json", wantErr: "manifest 0 has no digest"},
+		{file: "index-malformed-digest.json", wantErr: `manifest 0 has malformed digest "not a digest"`},
+		{file: "wrong-schema-version.json", wantErr: "schemaVersion 1, expected 2"},
+		{file: "negative-layer-size.json", wantErr: "layer 0 has negative size -1"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.file, func(t *testing.T) {
+			manifestJSON, err := os.ReadFile(filepath.Join("testdata/invalid", tt.file))
+			require.NoError(t, err)
+
+			m, err := ParseManifest(manifestJSON)
+			require.NoError(t, err, "malformed manifests are still valid JSON")
+
+			err = m.Validate()
+			require.ErrorIs(t, err, ErrInvalidManifest)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+
+	t.Run("empty index", func(t *testing.T) {
+		m, err := ParseManifest([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`))
+		require.NoError(t, err)
+		assert.NoError(t, m.Validate())
+	})
+
+	t.Run("not parsed", func(t *testing.T) {
+		err := (&Manifest{SchemaVersion: 2}).Validate()
+		require.ErrorIs(t, err, ErrInvalidManifest)
+	})
+}
+
+func TestParseManifest_MissingMediaType(t *testing.T) {
+	image, err := os.ReadFile("testdata/manifests/oci-image-manifest-no-mediatype.json")
+	require.NoError(t, err)
+	index, err := os.ReadFile("testdata/manifests/oci-image-index-no-mediatype.json")
+	require.NoError(t, err)
+
+	tests := []struct {
+		name          string
+		manifest      []byte
+		hint          []string
+		wantMediaType string
+		wantType      any
+	}{
+		{name: "image inferred from layers", manifest: image, wantMediaType: "application/vnd.oci.image.manifest.v1+json", wantType: ImageManifest{}},
+		{name: "index inferred from manifests", manifest: index, wantMediaType: "application/vnd.oci.image.index.v1+json", wantType: ManifestList{}},
+		{
+			name:          "content type hint",
+			manifest:      image,
+			hint:          []string{"application/vnd.docker.distribution.manifest.v2+json; charset=utf-8"},
+			wantMediaType: "application/vnd.docker.distribution.manifest.v2+json",
+			wantType:      ImageManifest{},
+		},
+		{name: "generic hint is ignored", manifest: index, hint: []string{"application/json"}, wantMediaType: "application/vnd.oci.image.index.v1+json", wantType: ManifestList{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseManifest(tt.manifest, tt.hint...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.wantMediaType, m.MediaType)
+			assert.IsType(t, tt.wantType, m.ManifestData)
+		})
+	}
+
+	t.Run("nothing to infer from", func(t *testing.T) {
+		m, err := ParseManifest([]byte(`{"schemaVersion":2}`))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unsupported mediaType")
+		assert.Nil(t, m)
+	})
+}
+
+func TestGetManifest_MissingMediaType(t *testing.T) {
+	index, err := os.ReadFile("testdata/manifests/oci-image-index-no-mediatype.json")
+	require.NoError(t, err)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.list.v2+json")
+		_, _ = w.Write(index)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	manifest, err := client.GetManifest(context.Background(), "app", "latest")
+	require.NoError(t, err)
+
+	assert.Equal(t, "application/vnd.docker.distribution.manifest.list.v2+json", manifest.MediaType)
+	require.IsType(t, ManifestList{}, manifest.ManifestData)
+	assert.Len(t, manifest.ManifestData.(ManifestList).Manifests, 2)
+}
+
 func TestParseLinkHeader(t *testing.T) {
 	tests := []struct {
 		name     string
@@ -113,6 +231,12 @@ func TestParseLinkHeader(t *testing.T) {
 		{name: "empty", input: "", wantMore: false, wantLast: "", wantN: 0},
 		{name: "malformed", input: "invalid", wantMore: true, wantLast: ""},
 		{name: "invalid URL", input: `<://invalid-url>; rel="next"`, wantMore: false},
+		{name: "unquoted rel", input: `</v2/_catalog?last=myrepo&n=100>; rel=next`, wantMore: true, wantLast: "myrepo", wantN: 100},
+		{name: "capitalized rel", input: `</v2/_catalog?last=myrepo&n=100>; Rel="next"`, wantMore: true, wantLast: "myrepo", wantN: 100},
+		{name: "uppercase value", input: `</v2/_catalog?last=myrepo&n=100>;REL=NEXT`, wantMore: true, wantLast: "myrepo", wantN: 100},
+		{name: "several relation types", input: `</v2/_catalog?last=myrepo&n=100>; rel="last next"`, wantMore: true, wantLast: "myrepo", wantN: 100},
+		{name: "next after prev", input: `</v2/_catalog?last=a&n=100>; rel="prev", </v2/_catalog?last=c&n=100>; rel=next`, wantMore: true, wantLast: "c", wantN: 100},
+		{name: "only prev", input: `</v2/_catalog?last=a&n=100>; rel="prev"`, wantMore: false},
 	}
 
 	for _, tt := range tests {
@@ -138,6 +262,7 @@ func TestApplyPagination(t *testing.T) {
 		{name: "only N", pagination: &PaginationParams{N: 25}, wantN: "25", wantLast: ""},
 		{name: "only Last", pagination: &PaginationParams{Last: "somerepo"}, wantN: "", wantLast: "somerepo"},
 		{name: "nil", pagination: nil, wantN: "", wantLast: ""},
+		{name: "Last with slashes", pagination: &PaginationParams{N: 10, Last: "team/subteam/repo"}, wantN: "10", wantLast: "team/subteam/repo"},
 	}
 
 	for _, tt := range tests {
@@ -148,10 +273,144 @@ func TestApplyPagination(t *testing.T) {
 			query := req.URL.Query()
 			assert.Equal(t, tt.wantN, query.Get("n"))
 			assert.Equal(t, tt.wantLast, query.Get("last"))
+			assert.NotContains(t, req.URL.RawQuery, "%2F")
 		})
 	}
 }
 
+func TestPageSizeDetection(t *testing.T) {
+	tags := []string{"a", "b", "c", "d", "e", "f"}
+
+	tests := []struct {
+		name string
+		// serve returns the tags of a page for the n that was sent (0 when absent)
+		serve     func(n int) []string
+		link      func(n int) string
+		wantQuery []string
+	}{
+		{
+			name:      "honors n",
+			serve:     func(n int) []string { return tags[:min(n, len(tags))] },
+			wantQuery: []string{"n=2", "n=2", "n=2"},
+		},
+		{
+			name:      "ignores n",
+			serve:     func(int) []string { return tags },
+			wantQuery: []string{"n=2", "", ""},
+		},
+		{
+			name:      "caps n through Link",
+			serve:     func(n int) []string { return tags[:min(n, 3)] },
+			link:      func(int) string { return `</v2/app/tags/list?last=c&n=3>; rel="next"` },
+			wantQuery: []string{"n=5", "n=3", "n=3"},
+		},
+		{
+			name:      "short page without Link n is not a cap",
+			serve:     func(n int) []string { return tags[:min(n, 3)] },
+			link:      func(int) string { return `</v2/app/tags/list?last=c>; rel="next"` },
+			wantQuery: []string{"n=5", "n=5", "n=5"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var queries []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				queries = append(queries, r.URL.RawQuery)
+				n, _ := strconv.Atoi(r.URL.Query().Get("n"))
+				if tt.link != nil {
+					w.Header().Set("Link", tt.link(n))
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"name": "app", "tags": tt.serve(n)})
+			}))
+			defer server.Close()
+
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+			requested, _ := strconv.Atoi(strings.TrimPrefix(tt.wantQuery[0], "n="))
+			for range len(tt.wantQuery) {
+				_, err := client.ListTags(context.Background(), "app", &PaginationParams{N: requested})
+				require.NoError(t, err)
+			}
+			assert.Equal(t, tt.wantQuery, queries)
+		})
+	}
+}
+
+func TestPageSizeDetection_Catalog(t *testing.T) {
+	var queries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		queries = append(queries, r.URL.RawQuery)
+		_, _ = w.Write([]byte(`{"repositories":["a","b","c"]}`))
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	_, err := client.GetCatalog(context.Background(), &PaginationParams{N: 1})
+	require.NoError(t, err)
+	for _, err := range client.CatalogStream(context.Background(), &PaginationParams{N: 1}) {
+		require.NoError(t, err)
+	}
+	_, err = client.ListTags(context.Background(), "app", &PaginationParams{N: 1, Last: "a"})
+	require.NoError(t, err)
+
+	assert.Equal(t, []string{"n=1", "", "last=a&n=1"}, queries, "n is dropped for the catalog once ignored, not for tag lists")
+}
+
+func TestGetCatalog_SlashCursorRoundTrip(t *testing.T) {
+	var rawQueries []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rawQueries = append(rawQueries, r.URL.RawQuery)
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</v2/_catalog?last=team%2Fsubteam%2Frepo&n=2>; rel="next"`)
+			_, _ = w.Write([]byte(`{"repositories":["team/a","team/subteam/repo"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"repositories":["team/z"]}`))
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+
+	first, err := client.GetCatalog(context.Background(), &PaginationParams{N: 2})
+	require.NoError(t, err)
+	require.True(t, first.HasMore)
+	assert.Equal(t, "team/subteam/repo", first.Last)
+
+	_, err = client.GetCatalog(context.Background(), &PaginationParams{N: 2, Last: first.Last})
+	require.NoError(t, err)
+
+	require.Len(t, rawQueries, 2)
+	assert.Equal(t, "last=team/subteam/repo&n=2", rawQueries[1])
+}
+
+func TestGetCatalog_Cursor(t *testing.T) {
+	var lasts []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		lasts = append(lasts, r.URL.Query().Get("last"))
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `</v2/_catalog?last=team%2Fb&n=2>; rel="next"`)
+			_, _ = w.Write([]byte(`{"repositories":["team/a","team/b"]}`))
+			return
+		}
+		_, _ = w.Write([]byte(`{"repositories":["team/c"]}`))
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+
+	first, err := client.GetCatalog(context.Background(), &PaginationParams{N: 2})
+	require.NoError(t, err)
+	assert.Equal(t, "team/b", first.Cursor)
+
+	second, err := client.GetCatalog(context.Background(), &PaginationParams{N: 2, Last: "ignored", Cursor: first.Cursor})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"team/c"}, second.Repositories)
+	assert.False(t, second.HasMore)
+	assert.Empty(t, second.Cursor)
+
+	assert.Equal(t, []string{"", "team/b"}, lasts)
+}
+
 func TestHealthCheck(t *testing.T) {
 	tests := []struct {
 		name        string
@@ -182,6 +441,80 @@ func TestHealthCheck(t *testing.T) {
 	}
 }
 
+func TestCheckHealth(t *testing.T) {
+	tests := []struct {
+		name             string
+		statusCode       int
+		challenge        string
+		delay            time.Duration
+		wantRequiresAuth bool
+	}{
+		{name: "healthy", statusCode: http.StatusOK, delay: 20 * time.Millisecond},
+		{name: "bearer challenge", statusCode: http.StatusUnauthorized, challenge: `Bearer realm="https://auth.example.com/token",service="registry"`, wantRequiresAuth: true},
+		{name: "basic challenge", statusCode: http.StatusUnauthorized, challenge: `Basic realm="registry"`, wantRequiresAuth: true},
+		{name: "401 without challenge", statusCode: http.StatusUnauthorized},
+		{name: "forbidden", statusCode: http.StatusForbidden, challenge: `Bearer realm="https://auth.example.com/token"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				time.Sleep(tt.delay)
+				if tt.challenge != "" {
+					w.Header().Set("WWW-Authenticate", tt.challenge)
+				}
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+			status, err := client.CheckHealth(context.Background())
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.statusCode, status.Code)
+			assert.Equal(t, tt.wantRequiresAuth, status.RequiresAuth)
+			assert.GreaterOrEqual(t, status.Latency, tt.delay)
+			assert.Positive(t, status.Latency)
+		})
+	}
+}
+
+func TestCheckHealth_WithCredentials(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if _, _, ok := r.BasicAuth(); !ok {
+			w.Header().Set("WWW-Authenticate", `Basic realm="registry"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		}
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, Auth: BasicAuth{Username: "user", Password: "pass"}}
+	status, err := client.CheckHealth(context.Background())
+
+	require.NoError(t, err)
+	assert.Equal(t, http.StatusOK, status.Code)
+	assert.True(t, status.RequiresAuth, "requires auth although the credentials were accepted")
+}
+
+func TestCheckHealth_Errors(t *testing.T) {
+	t.Run("unreachable", func(t *testing.T) {
+		client := &BaseClient{HTTPClient: &http.Client{Transport: &fakeRoundTripper{}}, BaseURL: "http://example.com"}
+		status, err := client.CheckHealth(context.Background())
+
+		require.NoError(t, err)
+		assert.Equal(t, http.StatusServiceUnavailable, status.Code)
+		assert.False(t, status.RequiresAuth)
+	})
+
+	t.Run("invalid base URL", func(t *testing.T) {
+		client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: "://invalid-url"}
+		status, err := client.CheckHealth(context.Background())
+
+		require.Error(t, err)
+		assert.Nil(t, status)
+	})
+}
+
 func TestGetCatalog(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -359,6 +692,70 @@ func TestGetBlob(t *testing.T) {
 	}
 }
 
+func TestNullListsAreEmpty(t *testing.T) {
+	tests := []struct {
+		name string
+		body string
+		call func(c *BaseClient) ([]string, error)
+	}{
+		{
+			name: "catalog null repositories",
+			body: `{"repositories":null}`,
+			call: func(c *BaseClient) ([]string, error) {
+				resp, err := c.GetCatalog(context.Background(), nil)
+				if err != nil {
+					return nil, err
+				}
+				return resp.Repositories, nil
+			},
+		},
+		{
+			name: "catalog missing repositories",
+			body: `{}`,
+			call: func(c *BaseClient) ([]string, error) {
+				resp, err := c.GetCatalog(context.Background(), nil)
+				if err != nil {
+					return nil, err
+				}
+				return resp.Repositories, nil
+			},
+		},
+		{
+			name: "tags null",
+			body: `{"name":"myrepo","tags":null}`,
+			call: func(c *BaseClient) ([]string, error) {
+				resp, err := c.ListTags(context.Background(), "myrepo", nil)
+				if err != nil {
+					return nil, err
+				}
+				return resp.Tags, nil
+			},
+		},
+		{
+			name: "all tags null",
+			body: `{"name":"myrepo","tags":null}`,
+			call: func(c *BaseClient) ([]string, error) {
+				return c.ListAllTags(context.Background(), "myrepo")
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				_, _ = w.Write([]byte(tt.body))
+			}))
+			defer server.Close()
+
+			list, err := tt.call(&BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL})
+
+			require.NoError(t, err)
+			assert.NotNil(t, list)
+			assert.Empty(t, list)
+		})
+	}
+}
+
 func TestListTags(t *testing.T) {
 	tests := []struct {
 		name       string
@@ -413,12 +810,16 @@ func TestDeleteManifest(t *testing.T) {
 	tests := []struct {
 		name       string
 		statusCode int
+		statuses   []int // DeleteSuccessStatuses
 		wantErr    bool
 	}{
 		{name: "accepted", statusCode: http.StatusAccepted},
 		{name: "no content", statusCode: http.StatusNoContent},
+		{name: "ok", statusCode: http.StatusOK},
 		{name: "not found", statusCode: http.StatusNotFound, wantErr: true},
 		{name: "not allowed", statusCode: http.StatusMethodNotAllowed, wantErr: true},
+		{name: "ok not in custom statuses", statusCode: http.StatusOK, statuses: []int{http.StatusAccepted}, wantErr: true},
+		{name: "custom status", statusCode: http.StatusMultiStatus, statuses: []int{http.StatusAccepted, http.StatusMultiStatus}},
 	}
 
 	for _, tt := range tests {
@@ -431,15 +832,137 @@ func TestDeleteManifest(t *testing.T) {
 			}))
 			defer server.Close()
 
-			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
-			err := client.DeleteManifest(context.Background(), "myrepo", "sha256:digest")
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, DeleteSuccessStatuses: tt.statuses}
+			err := client.DeleteManifest(context.Background(), "myrepo", sha256Digest([]byte("manifest")))
 
 			if tt.wantErr {
 				require.Error(t, err)
+				assert.Contains(t, err.Error(), "delete manifest failed")
+				return
+			}
+
+			require.NoError(t, err)
+		})
+	}
+}
+
+func TestDeleteManifestConfirm(t *testing.T) {
+	const digest = "sha256:a3ed95caeb02ffe68cdd9fd84406680ae93d633cb16422d00e8a7c22955b46d4"
+	tests := []struct {
+		name          string
+		echoed        string
+		disableDelete bool
+		want          string
+	}{
+		{name: "echoed by registry", echoed: digest, want: digest},
+		{name: "not echoed", want: digest},
+		{name: "dry run", disableDelete: true, want: digest},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodDelete, r.Method)
+				assert.Equal(t, "/v2/myrepo/manifests/"+digest, r.URL.Path)
+				if tt.echoed != "" {
+					w.Header().Set("Docker-Content-Digest", tt.echoed)
+				}
+				w.WriteHeader(http.StatusAccepted)
+			}))
+			defer server.Close()
+
+			logger := &mockLogger{}
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, Logger: logger, DisableDelete: tt.disableDelete}
+			confirmed, err := client.DeleteManifestConfirm(context.Background(), "myrepo", digest)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, confirmed)
+			if !tt.disableDelete {
+				require.Len(t, logger.infoCalls, 1)
+				assert.Equal(t, "Manifest deleted", logger.infoCalls[0].msg)
+				assert.Contains(t, logger.infoCalls[0].args, "confirmed_digest")
+				assert.Contains(t, logger.infoCalls[0].args, tt.echoed)
+			}
+		})
+	}
+}
+
+func TestPutManifest(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:c0ffee","size":2},"layers":[]}`)
+	digest := sha256Digest(manifest)
+
+	tests := []struct {
+		name       string
+		statusCode int
+		echoed     string
+		wantErr    string
+	}{
+		{name: "created", statusCode: http.StatusCreated, echoed: digest},
+		{name: "digest computed when not echoed", statusCode: http.StatusCreated},
+		{name: "rejected", statusCode: http.StatusBadRequest, wantErr: "put manifest failed: 400"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodPut, r.Method)
+				assert.Equal(t, "/v2/app/manifests/v1", r.URL.Path)
+				assert.Equal(t, "application/vnd.oci.image.manifest.v1+json", r.Header.Get("Content-Type"))
+				body, _ := io.ReadAll(r.Body)
+				assert.Equal(t, manifest, body)
+				if tt.echoed != "" {
+					w.Header().Set("Docker-Content-Digest", tt.echoed)
+				}
+				w.WriteHeader(tt.statusCode)
+			}))
+			defer server.Close()
+
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+			resp, err := client.PutManifest(context.Background(), "app", "v1", "application/vnd.oci.image.manifest.v1+json", manifest)
+
+			if tt.wantErr != "" {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), tt.wantErr)
 				return
 			}
+			require.NoError(t, err)
+			assert.Equal(t, digest, resp.Digest)
+			assert.Equal(t, 2, resp.SchemaVersion)
+			assert.Equal(t, manifest, resp.RawContent)
+			assert.Equal(t, "sha256:c0ffee", resp.ManifestData.(ImageManifest).Config.Digest)
+		})
+	}
+}
+
+func TestPutManifest_OCISubject(t *testing.T) {
+	subject := sha256Digest([]byte("image"))
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.empty.v1+json","digest":"sha256:44136fa355b3678a1146ad16f7e8649e94fb4fc21fe77e8310c060f61caaff8a","size":2},"layers":[],"subject":{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"` + subject + `","size":5}}`)
+
+	tests := []struct {
+		name        string
+		ociSubject  string
+		wantSubject string
+	}{
+		{name: "subject processed", ociSubject: subject, wantSubject: subject},
+		{name: "subject not processed", ociSubject: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.ociSubject != "" {
+					w.Header().Set("OCI-Subject", tt.ociSubject)
+				}
+				w.WriteHeader(http.StatusCreated)
+			}))
+			defer server.Close()
+
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+			resp, err := client.PutManifest(context.Background(), "app", sha256Digest(manifest), "application/vnd.oci.image.manifest.v1+json", manifest)
 
 			require.NoError(t, err)
+			assert.Equal(t, tt.wantSubject, resp.Subject)
+			assert.Equal(t, tt.wantSubject, resp.Headers.Get("OCI-Subject"))
 		})
 	}
 }
@@ -460,11 +983,48 @@ func TestDeleteManifest_DisableDelete(t *testing.T) {
 		BaseURL:       server.URL,
 		DisableDelete: true,
 	}
-	err := client.DeleteManifest(context.Background(), "myrepo", "sha256:digest")
+	err := client.DeleteManifest(context.Background(), "myrepo", sha256Digest([]byte("manifest")))
 	require.NoError(t, err)
 	assert.False(t, deleteCalled, "DELETE should not have been called when DisableDelete is true")
 }
 
+func TestDeleteManifest_TagReference(t *testing.T) {
+	registry := newFakeRegistry(t)
+	digest := registry.addManifest(t, map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+	}, "v1")
+
+	t.Run("rejected by default", func(t *testing.T) {
+		err := registry.client().DeleteManifest(context.Background(), "app", "v1")
+
+		require.ErrorIs(t, err, ErrTagReference)
+		assert.Contains(t, err.Error(), "app:v1")
+		assert.Zero(t, registry.requestCount(http.MethodDelete, "/v1"))
+	})
+
+	t.Run("resolved when enabled", func(t *testing.T) {
+		client := registry.client()
+		client.ResolveTagsOnDelete = true
+
+		err := client.DeleteManifest(context.Background(), "app", "v1")
+
+		require.NoError(t, err)
+		assert.Equal(t, 1, registry.requestCount(http.MethodHead, "/v1"))
+		assert.Equal(t, 1, registry.requestCount(http.MethodDelete, "/"+digest))
+	})
+
+	t.Run("unknown tag", func(t *testing.T) {
+		client := registry.client()
+		client.ResolveTagsOnDelete = true
+
+		err := client.DeleteManifest(context.Background(), "app", "missing")
+
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "resolve manifest digest failed")
+	})
+}
+
 func TestHasBlob(t *testing.T) {
 	testResourceExists(t, func(c *BaseClient, ctx context.Context, repo, ref string) (bool, error) {
 		return c.HasBlob(ctx, repo, ref)
@@ -485,7 +1045,7 @@ func TestAddAcceptHeaders(t *testing.T) {
 	for _, tt := range tests {
 		t.Run(tt.name, func(t *testing.T) {
 			req := httptest.NewRequest(http.MethodGet, "http://example.com", nil)
-			addAcceptHeaders(req, tt.custom)
+			(&BaseClient{}).addAcceptHeaders(req, tt.custom)
 
 			accepts := req.Header["Accept"]
 			assert.Len(t, accepts, tt.wantCount)
@@ -499,6 +1059,315 @@ func TestAddAcceptHeaders(t *testing.T) {
 	}
 }
 
+func TestAddAcceptHeaders_Context(t *testing.T) {
+	dockerV2 := "application/vnd.docker.distribution.manifest.v2+json"
+
+	tests := []struct {
+		name   string
+		ctx    context.Context
+		custom []string
+		want   []string
+	}{
+		{name: "context headers", ctx: ContextWithAcceptHeaders(context.Background(), dockerV2), want: []string{dockerV2}},
+		{name: "per call headers win", ctx: ContextWithAcceptHeaders(context.Background(), dockerV2), custom: []string{"application/custom+json"}, want: []string{"application/custom+json"}},
+		{name: "empty context headers use defaults", ctx: ContextWithAcceptHeaders(context.Background()), want: defaultManifestMediaTypes},
+		{name: "wrong value type is ignored", ctx: context.WithValue(context.Background(), AcceptHeadersKey, dockerV2), want: defaultManifestMediaTypes},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequestWithContext(tt.ctx, http.MethodGet, "http://example.com", nil)
+			(&BaseClient{}).addAcceptHeaders(req, tt.custom)
+
+			assert.Equal(t, tt.want, req.Header.Values("Accept"))
+		})
+	}
+}
+
+func TestPreferIndex(t *testing.T) {
+	var accept []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Values("Accept")
+		w.Header().Set("Content-Type", "application/vnd.oci.image.index.v1+json")
+		_, _ = w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[]}`))
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name        string
+		preferIndex bool
+		custom      []string
+		want        []string
+	}{
+		{name: "default order", want: defaultManifestMediaTypes},
+		{
+			name:        "index first",
+			preferIndex: true,
+			want: []string{
+				"application/vnd.oci.image.index.v1+json",
+				"application/vnd.docker.distribution.manifest.list.v2+json",
+				"application/vnd.oci.image.manifest.v1+json",
+				"application/vnd.docker.distribution.manifest.v2+json",
+			},
+		},
+		{name: "per call headers win", preferIndex: true, custom: []string{"application/custom+json"}, want: []string{"application/custom+json"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, PreferIndex: tt.preferIndex}
+
+			_, err := client.GetManifest(context.Background(), "library/nginx", "latest", tt.custom...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, accept)
+
+			_, err = client.HeadManifest(context.Background(), "library/nginx", "latest", tt.custom...)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, accept)
+		})
+	}
+}
+
+func TestGetManifest_ContextAcceptHeaders(t *testing.T) {
+	var accept []string
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Values("Accept")
+		w.Header().Set("Content-Type", "application/vnd.docker.distribution.manifest.v2+json")
+		_, _ = w.Write([]byte(`{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.v2+json"}`))
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	ctx := ContextWithAcceptHeaders(context.Background(), "application/vnd.docker.distribution.manifest.v2+json")
+
+	_, err := client.GetManifest(ctx, "repo", "latest")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"application/vnd.docker.distribution.manifest.v2+json"}, accept)
+}
+
+func TestResponseHeaders(t *testing.T) {
+	registry := newFakeRegistry(t)
+	digest := registry.addImage(t, ConfigBlob{OS: "linux"}, [][]byte{[]byte("layer")}, "v1")
+	layer := registry.addBlob([]byte("layer"))
+
+	inner := registry.server.Config.Handler
+	registry.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Docker-Distribution-API-Version", "registry/2.0")
+		w.Header().Set("X-Cache", "HIT")
+		inner.ServeHTTP(w, r)
+	})
+	client := registry.client()
+
+	manifest, err := client.GetManifest(context.Background(), "app", "v1")
+	require.NoError(t, err)
+	assert.Equal(t, "registry/2.0", manifest.Headers.Get("Docker-Distribution-API-Version"))
+	assert.Equal(t, "HIT", manifest.Headers.Get("X-Cache"))
+
+	head, err := client.HeadManifest(context.Background(), "app", digest)
+	require.NoError(t, err)
+	assert.Equal(t, "HIT", head.Headers.Get("X-Cache"))
+
+	blob, err := client.GetBlob(context.Background(), "app", layer)
+	require.NoError(t, err)
+	assert.Equal(t, "HIT", blob.Headers.Get("X-Cache"))
+
+	blob.Headers.Set("X-Cache", "changed")
+	again, err := client.GetBlob(context.Background(), "app", layer)
+	require.NoError(t, err)
+	assert.Equal(t, "HIT", again.Headers.Get("X-Cache"))
+}
+
+func TestAcceptHeaders_PerOperation(t *testing.T) {
+	registry := newFakeRegistry(t)
+	imageDigest := registry.addImage(t, ConfigBlob{OS: "linux"}, [][]byte{[]byte("layer")}, "v1")
+
+	var accept []string
+	inner := registry.server.Config.Handler
+	registry.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		accept = r.Header.Values("Accept")
+		inner.ServeHTTP(w, r)
+	})
+
+	client := registry.client()
+	manifest, err := client.GetManifest(context.Background(), "app", "v1")
+	require.NoError(t, err)
+	config := manifest.ManifestData.(ImageManifest).Config.Digest
+
+	tests := []struct {
+		name string
+		call func(ctx context.Context) error
+		want []string
+	}{
+		{
+			name: "GetManifest",
+			call: func(ctx context.Context) error { _, err := client.GetManifest(ctx, "app", "v1"); return err },
+			want: defaultManifestMediaTypes,
+		},
+		{
+			name: "HeadManifest",
+			call: func(ctx context.Context) error { _, err := client.HeadManifest(ctx, "app", "v1"); return err },
+			want: defaultManifestMediaTypes,
+		},
+		{
+			name: "HasManifest",
+			call: func(ctx context.Context) error { _, err := client.HasManifest(ctx, "app", "v1"); return err },
+			want: defaultManifestMediaTypes,
+		},
+		{
+			name: "GetConfigBlob",
+			call: func(ctx context.Context) error { _, err := client.GetConfigBlob(ctx, "app", config); return err },
+			want: defaultConfigMediaTypes,
+		},
+		{
+			name: "GetBlob",
+			call: func(ctx context.Context) error { _, err := client.GetBlob(ctx, "app", config); return err },
+			want: nil,
+		},
+		{
+			name: "GetReferrers",
+			call: func(ctx context.Context) error {
+				_, err := client.GetReferrers(ctx, "app", imageDigest, "")
+				return err
+			},
+			want: []string{"application/vnd.oci.image.index.v1+json"},
+		},
+		{
+			name: "GetReferrers ignores context manifest headers",
+			call: func(ctx context.Context) error {
+				_, err := client.GetReferrers(ContextWithAcceptHeaders(ctx, "application/vnd.docker.distribution.manifest.v2+json"), "app", imageDigest, "")
+				return err
+			},
+			want: []string{"application/vnd.oci.image.index.v1+json"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			accept = nil
+			require.NoError(t, tt.call(context.Background()))
+			assert.Equal(t, tt.want, accept)
+		})
+	}
+}
+
+func TestParseManifestLimited(t *testing.T) {
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json"}`)
+
+	tests := []struct {
+		name    string
+		maxSize int
+		wantErr bool
+	}{
+		{name: "no limit", maxSize: 0},
+		{name: "exact limit", maxSize: len(manifest)},
+		{name: "over limit", maxSize: len(manifest) - 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m, err := ParseManifestLimited(manifest, tt.maxSize)
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrManifestTooLarge)
+				assert.Nil(t, m)
+				return
+			}
+			require.NoError(t, err)
+			assert.Equal(t, "application/vnd.oci.image.manifest.v1+json", m.MediaType)
+		})
+	}
+}
+
+func TestGetManifest_MaxManifestBytes(t *testing.T) {
+	// A valid manifest padded with whitespace to just over the default limit
+	manifest := []byte(`{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json"}`)
+	padded := append(manifest, bytes.Repeat([]byte(" "), DefaultMaxManifestBytes+1-len(manifest))...)
+
+	tests := []struct {
+		name    string
+		body    []byte
+		limit   int
+		chunked bool
+		wantErr bool
+	}{
+		{name: "small manifest", body: manifest},
+		{name: "over default limit", body: padded, wantErr: true},
+		{name: "over default limit without content length", body: padded, chunked: true, wantErr: true},
+		{name: "over custom limit", body: manifest, limit: 10, wantErr: true},
+		{name: "limit disabled", body: padded, limit: -1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.chunked {
+					w.(http.Flusher).Flush()
+				}
+				_, _ = w.Write(tt.body)
+			}))
+			defer server.Close()
+
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, MaxManifestBytes: tt.limit}
+			resp, err := client.GetManifest(context.Background(), "repo", "latest")
+
+			if tt.wantErr {
+				require.ErrorIs(t, err, ErrManifestTooLarge)
+				assert.Nil(t, resp)
+				return
+			}
+			require.NoError(t, err)
+			assert.Len(t, resp.RawContent, len(tt.body))
+		})
+	}
+}
+
+func TestManifest_Payload_PreservesDigest(t *testing.T) {
+	// Pretty-printed, with fields in a non-struct order and annotations the parsed types drop
+	index := []byte(`{
+  "schemaVersion": 2,
+  "mediaType": "application/vnd.oci.image.index.v1+json",
+  "manifests": [
+    {
+      "digest": "sha256:aaa",
+      "mediaType": "application/vnd.oci.image.manifest.v1+json",
+      "size": 1234,
+      "platform": {"os": "linux", "architecture": "amd64"},
+      "annotations": {"org.opencontainers.image.ref.name": "v1"}
+    }
+  ],
+  "annotations": {"created-by": "test"}
+}`)
+	digest := sha256Digest(index)
+
+	manifest, err := ParseManifest(index)
+	require.NoError(t, err)
+
+	payload, err := manifest.Payload()
+	require.NoError(t, err)
+	require.NoError(t, VerifyDigest(payload, digest))
+
+	remarshaled, err := json.Marshal(manifest.ManifestData)
+	require.NoError(t, err)
+	assert.ErrorIs(t, VerifyDigest(remarshaled, digest), ErrDigestMismatch, "re-marshaling must not be used to copy")
+}
+
+func TestGetManifest_RawContentPreservesDigest(t *testing.T) {
+	registry := newFakeRegistry(t)
+	digest := registry.addIndex(t, map[string]Platform{
+		registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, nil): {Architecture: "amd64", OS: "linux"},
+	}, "v1")
+
+	resp, err := registry.client().GetManifest(context.Background(), "app", "v1")
+	require.NoError(t, err)
+
+	require.NoError(t, VerifyDigest(resp.RawContent, digest))
+	assert.Equal(t, digest, resp.Digest)
+}
+
+func TestManifest_Payload_NoRaw(t *testing.T) {
+	_, err := (&Manifest{SchemaVersion: 2}).Payload()
+	require.ErrorIs(t, err, ErrNoRawManifest)
+}
+
 // Error case tests for uncovered paths
 
 func TestParseManifest_MalformedImageManifest(t *testing.T) {
@@ -832,7 +1701,7 @@ func TestListTags_InvalidJSON(t *testing.T) {
 
 func TestDeleteManifest_InvalidBaseURL(t *testing.T) {
 	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: "://invalid-url"}
-	err := client.DeleteManifest(context.Background(), "repo", "digest")
+	err := client.DeleteManifest(context.Background(), "repo", sha256Digest([]byte("manifest")))
 
 	require.Error(t, err)
 }
@@ -843,7 +1712,7 @@ func TestDeleteManifest_NetworkError(t *testing.T) {
 		BaseURL:    "http://example.com",
 	}
 	client.HTTPClient.Transport = &fakeRoundTripper{}
-	err := client.DeleteManifest(context.Background(), "repo", "digest")
+	err := client.DeleteManifest(context.Background(), "repo", sha256Digest([]byte("manifest")))
 
 	require.Error(t, err)
 }
@@ -881,3 +1750,197 @@ func TestHasBlob_UnexpectedStatus(t *testing.T) {
 	assert.False(t, exists)
 	assert.Contains(t, err.Error(), "unexpected status")
 }
+
+func TestTryGetManifest_StatusCode(t *testing.T) {
+	registry := newFakeRegistry(t)
+	registry.addImage(t, ConfigBlob{}, nil, "v1")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/v2/private/manifests/v1":
+			w.WriteHeader(http.StatusUnauthorized)
+		default:
+			w.WriteHeader(http.StatusForbidden)
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name       string
+		client     *BaseClient
+		repository string
+		reference  string
+		wantStatus int
+		wantErr    bool
+	}{
+		{name: "found", client: registry.client(), repository: "app", reference: "v1", wantStatus: http.StatusOK},
+		{name: "not found", client: registry.client(), repository: "app", reference: "v2", wantStatus: http.StatusNotFound, wantErr: true},
+		{name: "unauthorized", client: &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}, repository: "private", reference: "v1", wantStatus: http.StatusUnauthorized, wantErr: true},
+		{name: "forbidden", client: &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}, repository: "denied", reference: "v1", wantStatus: http.StatusForbidden, wantErr: true},
+		{name: "no response", client: &BaseClient{HTTPClient: &http.Client{}, BaseURL: "http://127.0.0.1:0"}, repository: "app", reference: "v1", wantStatus: 0, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			manifest, status, err := tt.client.TryGetManifest(context.Background(), tt.repository, tt.reference)
+
+			assert.Equal(t, tt.wantStatus, status)
+			if tt.wantErr {
+				require.Error(t, err)
+				assert.Nil(t, manifest)
+				return
+			}
+			require.NoError(t, err)
+			assert.NotNil(t, manifest)
+		})
+	}
+}
+
+func TestHeadManifest(t *testing.T) {
+	registry := newFakeRegistry(t)
+	digest := registry.addImage(t, ConfigBlob{}, nil, "v1")
+	client := registry.client()
+
+	manifest, err := client.HeadManifest(context.Background(), "app", "v1")
+	require.NoError(t, err)
+
+	assert.Equal(t, digest, manifest.Digest)
+	assert.Equal(t, "application/vnd.oci.image.manifest.v1+json", manifest.MediaType)
+	assert.Equal(t, 2, manifest.SchemaVersion)
+	assert.Nil(t, manifest.RawContent)
+	assert.Nil(t, manifest.ManifestData)
+	assert.Equal(t, 1, registry.requestCount(http.MethodHead, "/manifests/v1"))
+	assert.Zero(t, registry.requestCount(http.MethodGet, "/manifests/v1"))
+
+	_, err = client.HeadManifest(context.Background(), "app", "missing")
+	require.ErrorIs(t, err, ErrManifestNotFound)
+	assert.Contains(t, err.Error(), "404")
+}
+
+func TestHeadManifest_IsIndex(t *testing.T) {
+	tests := []struct {
+		contentType string
+		want        bool
+	}{
+		{contentType: "application/vnd.oci.image.index.v1+json", want: true},
+		{contentType: "application/vnd.docker.distribution.manifest.list.v2+json; charset=utf-8", want: true},
+		{contentType: "application/vnd.oci.image.manifest.v1+json", want: false},
+		{contentType: "application/vnd.docker.distribution.manifest.v2+json", want: false},
+		{contentType: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.contentType, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.Equal(t, http.MethodHead, r.Method)
+				w.Header().Set("Content-Type", tt.contentType)
+				w.Header().Set("Docker-Content-Digest", "sha256:abc")
+			}))
+			defer server.Close()
+
+			client := &BaseClient{HTTPClient: server.Client(), BaseURL: server.URL}
+			manifest, err := client.HeadManifest(context.Background(), "app", "latest")
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, manifest.IsIndex)
+		})
+	}
+
+	t.Run("GetManifest", func(t *testing.T) {
+		registry := newFakeRegistry(t)
+		amd64 := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, nil, "image")
+		registry.addIndex(t, map[string]Platform{amd64: {OS: "linux", Architecture: "amd64"}}, "index")
+		client := registry.client()
+
+		index, err := client.GetManifest(context.Background(), "app", "index")
+		require.NoError(t, err)
+		assert.True(t, index.IsIndex)
+		image, err := client.GetManifest(context.Background(), "app", "image")
+		require.NoError(t, err)
+		assert.False(t, image.IsIndex)
+	})
+}
+
+func TestManifestSchemaVersion(t *testing.T) {
+	tests := []struct {
+		mediaType string
+		want      int
+	}{
+		{mediaType: "application/vnd.docker.distribution.manifest.v1+prettyjws", want: 1},
+		{mediaType: "application/vnd.docker.distribution.manifest.v2+json", want: 2},
+		{mediaType: "application/vnd.docker.distribution.manifest.list.v2+json", want: 2},
+		{mediaType: "application/vnd.oci.image.index.v1+json", want: 2},
+		{mediaType: "application/octet-stream", want: 0},
+		{mediaType: "", want: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.mediaType, func(t *testing.T) {
+			assert.Equal(t, tt.want, manifestSchemaVersion(tt.mediaType))
+		})
+	}
+}
+
+func TestBaseURLPathPrefix(t *testing.T) {
+	const prefix = "/artifactory/api/docker/docker-repo"
+
+	for _, baseSuffix := range []string{prefix, prefix + "/"} {
+		t.Run(baseSuffix, func(t *testing.T) {
+			registry := newFakeRegistry(t)
+			digest := registry.addImage(t, ConfigBlob{Architecture: "amd64", OS: "linux"}, [][]byte{[]byte("layer")}, "latest", "v1")
+
+			var paths []string
+			inner := registry.server.Config.Handler
+			registry.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				paths = append(paths, r.URL.Path)
+				if !strings.HasPrefix(r.URL.Path, prefix+"/v2/") {
+					w.WriteHeader(http.StatusNotFound)
+					return
+				}
+				http.StripPrefix(prefix, inner).ServeHTTP(w, r)
+			})
+
+			client := registry.client()
+			client.BaseURL += baseSuffix
+
+			manifest, err := client.GetManifest(context.Background(), "team/app", "latest")
+			require.NoError(t, err)
+			assert.Equal(t, digest, manifest.Digest)
+
+			tags, err := client.ListTags(context.Background(), "team/app", nil)
+			require.NoError(t, err)
+			assert.Equal(t, []string{"latest", "v1"}, tags.Tags)
+
+			assert.Equal(t, []string{
+				prefix + "/v2/team/app/manifests/latest",
+				prefix + "/v2/team/app/tags/list",
+			}, paths)
+		})
+	}
+}
+
+func TestBaseURLPathPrefix_TagPagination(t *testing.T) {
+	const prefix = "/artifactory/api/docker/docker-repo"
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, prefix+"/v2/app/tags/list", r.URL.Path)
+		if r.URL.Query().Get("last") == "" {
+			w.Header().Set("Link", `<`+prefix+`/v2/app/tags/list?last=a&n=1>; rel="next"`)
+			_, _ = w.Write([]byte(`{"name":"app","tags":["a"]}`))
+			return
+		}
+		assert.Equal(t, "a", r.URL.Query().Get("last"))
+		_, _ = w.Write([]byte(`{"name":"app","tags":["b"]}`))
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: server.Client(), BaseURL: server.URL + prefix + "/"}
+
+	first, err := client.ListTags(context.Background(), "app", &PaginationParams{N: 1})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"a"}, first.Tags)
+	require.True(t, first.HasMore)
+
+	second, err := client.ListTags(context.Background(), "app", &PaginationParams{N: 1, Last: first.Last})
+	require.NoError(t, err)
+	assert.Equal(t, []string{"b"}, second.Tags)
+}
@@ -0,0 +1,205 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"maps"
+	"slices"
+	"sync"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// RepositoryStorageBytes returns the bytes taken by the distinct blobs referenced by the
+// tags of a repository, counting blobs shared by several tags once (see RepositoryBlobs).
+// Blobs shared with other repositories are counted in full for each of them, and
+// manifests, untagged images and blobs the registry has not garbage collected yet are
+// not counted, so the figure can differ from the registry's own storage usage.
+func (c *BaseClient) RepositoryStorageBytes(ctx context.Context, repository string) (int64, error) {
+	blobs, err := c.RepositoryBlobs(ctx, repository)
+	if err != nil {
+		return 0, err
+	}
+
+	var total int64
+	for _, size := range blobs {
+		total += size
+	}
+
+	c.logDebug("Computed repository storage",
+		"operation", "RepositoryStorageBytes",
+		"repository", repository,
+		"blob_count", len(blobs),
+		"size_bytes", total,
+	)
+	return total, nil
+}
+
+// RepositoryBlobs returns every blob referenced by the tags of a repository, keyed by
+// digest with the size from the referencing descriptor. Each tag's manifest is fetched,
+// recursing into indexes, and the config and layer blobs are collected. Manifests and
+// blobs shared by several tags are only counted once. At most 8 manifests are fetched
+// at once, and the first error stops the walk and is returned.
+func (c *BaseClient) RepositoryBlobs(ctx context.Context, repository string) (map[string]int64, error) {
+	walker, err := c.walkRepository(ctx, "RepositoryBlobs", repository)
+	if err != nil {
+		return nil, err
+	}
+	return walker.blobs, nil
+}
+
+// ReferencedDigests returns, sorted, the digests of every manifest and blob reachable from
+// the tags of a repository: tagged manifests, the children of indexes, and their config
+// and layer blobs. It is the set a garbage collector must keep (see DanglingBlobs).
+func (c *BaseClient) ReferencedDigests(ctx context.Context, repository string) ([]string, error) {
+	walker, err := c.walkRepository(ctx, "ReferencedDigests", repository)
+	if err != nil {
+		return nil, err
+	}
+
+	digests := slices.Collect(maps.Keys(walker.manifests))
+	for digest := range walker.blobs {
+		if !walker.manifests[digest] {
+			digests = append(digests, digest)
+		}
+	}
+	slices.Sort(digests)
+	return digests, nil
+}
+
+// DanglingBlobs returns, in order, the digests of stored that no tag of the repository
+// references (see ReferencedDigests): candidates for garbage collection.
+// The distribution API cannot list the blobs a registry holds, so stored must come from the
+// storage backend, e.g. the blob directories of a filesystem-backed registry. Untagged
+// manifests pushed by digest and their blobs are reported as dangling; referrers such as
+// signatures are only kept when they are tagged.
+func (c *BaseClient) DanglingBlobs(ctx context.Context, repository string, stored []string) ([]string, error) {
+	referenced, err := c.ReferencedDigests(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	var dangling []string
+	for _, digest := range stored {
+		if _, found := slices.BinarySearch(referenced, digest); !found {
+			dangling = append(dangling, digest)
+		}
+	}
+
+	c.logDebug("Found dangling blobs",
+		"operation", "DanglingBlobs",
+		"repository", repository,
+		"stored_count", len(stored),
+		"referenced_count", len(referenced),
+		"dangling_count", len(dangling),
+	)
+	return dangling, nil
+}
+
+// walkRepository walks the manifests of every tag of a repository, at most
+// defaultConcurrency tags at once, collecting the manifests and blobs they reference.
+// The walk stops at the first error, which is returned.
+func (c *BaseClient) walkRepository(ctx context.Context, operation, repository string) (*blobWalker, error) {
+	tags, err := c.ListAllTags(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	walker := &blobWalker{
+		client:     c,
+		repository: repository,
+		manifests:  map[string]bool{},
+		blobs:      map[string]int64{},
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(defaultConcurrency)
+	for _, tag := range tags {
+		g.Go(func() error {
+			if err := walker.walkTag(ctx, tag); err != nil {
+				return fmt.Errorf("tag %s: %w", tag, err)
+			}
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	c.logDebug("Collected repository blobs",
+		"operation", operation,
+		"repository", repository,
+		"tag_count", len(tags),
+		"manifest_count", len(walker.manifests),
+		"blob_count", len(walker.blobs),
+	)
+
+	return walker, nil
+}
+
+// blobWalker collects the blobs of manifests fetched concurrently by walkRepository
+type blobWalker struct {
+	client     *BaseClient
+	repository string
+
+	mu        sync.Mutex
+	manifests map[string]bool // digests of manifests already walked
+	blobs     map[string]int64
+}
+
+// walkTag fetches the manifest of a tag and collects its blobs unless another tag
+// resolved to the same manifest first
+func (w *blobWalker) walkTag(ctx context.Context, tag string) error {
+	manifest, err := w.client.GetManifest(ctx, w.repository, tag)
+	if err != nil {
+		return err
+	}
+	digest := manifest.Digest
+	if digest == "" {
+		digest = w.client.computeDigest(manifest.RawContent)
+	}
+	if !w.visit(digest) {
+		return nil
+	}
+	return w.collect(ctx, manifest)
+}
+
+// collect records the blobs of an image manifest, or walks the children of an index
+func (w *blobWalker) collect(ctx context.Context, manifest *ManifestResponse) error {
+	switch data := manifest.ManifestData.(type) {
+	case ImageManifest:
+		w.mu.Lock()
+		defer w.mu.Unlock()
+		if data.Config.Digest != "" {
+			w.blobs[data.Config.Digest] = data.Config.Size
+		}
+		for _, layer := range data.Layers {
+			w.blobs[layer.Digest] = layer.Size
+		}
+	case ManifestList:
+		for _, child := range data.Manifests {
+			if !w.visit(child.Digest) {
+				continue
+			}
+			childManifest, err := w.client.GetManifest(ctx, w.repository, child.Digest)
+			if err != nil {
+				return err
+			}
+			if err := w.collect(ctx, childManifest); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// visit marks a manifest digest as walked and reports whether it was new
+func (w *blobWalker) visit(digest string) bool {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	if w.manifests[digest] {
+		return false
+	}
+	w.manifests[digest] = true
+	return true
+}
@@ -0,0 +1,136 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"slices"
+	"testing"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRepositoryBlobs(t *testing.T) {
+	registry := newFakeRegistry(t)
+	base := []byte("shared base layer")
+
+	configs := []ConfigBlob{
+		{Created: "2024-01-01T00:00:00Z"},
+		{Created: "2024-02-01T00:00:00Z"},
+		{Created: "2024-03-01T00:00:00Z"},
+	}
+	v1 := registry.addImage(t, configs[0], [][]byte{base, []byte("app v1")}, "v1")
+	registry.addImage(t, configs[1], [][]byte{base, []byte("app v2")}, "v2", "latest")
+	arm := registry.addImage(t, configs[2], [][]byte{base, []byte("app arm64")})
+	registry.addIndex(t, map[string]Platform{arm: {Architecture: "arm64", OS: "linux"}}, "multi")
+	registry.addIndex(t, map[string]Platform{
+		arm: {Architecture: "arm64", OS: "linux"},
+		v1:  {Architecture: "amd64", OS: "linux"},
+	}, "multi-v1")
+
+	want := map[string]int64{
+		sha256Digest(base):                int64(len(base)),
+		sha256Digest([]byte("app v1")):    6,
+		sha256Digest([]byte("app v2")):    6,
+		sha256Digest([]byte("app arm64")): 9,
+	}
+	for _, config := range configs {
+		configJSON, err := json.Marshal(config)
+		require.NoError(t, err)
+		want[sha256Digest(configJSON)] = int64(len(configJSON))
+	}
+
+	blobs, err := registry.client().RepositoryBlobs(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Equal(t, want, blobs)
+	assert.Equal(t, 1, registry.requestCount(http.MethodGet, "/manifests/"+arm), "manifests shared by indexes must be fetched once")
+}
+
+func TestRepositoryBlobs_Empty(t *testing.T) {
+	registry := newFakeRegistry(t)
+
+	blobs, err := registry.client().RepositoryBlobs(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Empty(t, blobs)
+}
+
+func TestRepositoryBlobs_MissingChild(t *testing.T) {
+	registry := newFakeRegistry(t)
+	registry.addIndex(t, map[string]Platform{"sha256:missing": {Architecture: "amd64", OS: "linux"}}, "broken")
+
+	blobs, err := registry.client().RepositoryBlobs(context.Background(), "app")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tag broken")
+	assert.Nil(t, blobs)
+}
+
+func TestRepositoryBlobs_Concurrency(t *testing.T) {
+	registry := newFakeRegistry(t)
+	for i := range 3 * defaultConcurrency {
+		registry.addImage(t, ConfigBlob{}, [][]byte{fmt.Appendf(nil, "layer %d", i)}, fmt.Sprintf("v%d", i))
+	}
+	registry.manifests["broken"] = []byte("{") // listed first, failing while most tags wait
+	peak := registry.trackInFlight()
+
+	_, err := registry.client().RepositoryBlobs(context.Background(), "app")
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tag broken")
+	require.NotErrorIs(t, err, context.Canceled, "the first error is returned, not the cancellations it caused")
+	assert.LessOrEqual(t, peak.Load(), int32(defaultConcurrency))
+}
+
+func TestRepositoryStorageBytes(t *testing.T) {
+	registry := newFakeRegistry(t)
+	base := []byte("shared base layer")
+	config := ConfigBlob{Created: "2024-01-01T00:00:00Z"}
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+
+	// Both tags share the base layer and the config; only the app layers differ
+	registry.addImage(t, config, [][]byte{base, []byte("app v1")}, "v1")
+	registry.addImage(t, config, [][]byte{base, []byte("app v2!")}, "v2", "latest")
+
+	total, err := registry.client().RepositoryStorageBytes(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Equal(t, int64(len(base)+len("app v1")+len("app v2!")+len(configJSON)), total)
+
+	empty := newFakeRegistry(t)
+	total, err = empty.client().RepositoryStorageBytes(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Zero(t, total)
+}
+
+func TestDanglingBlobs(t *testing.T) {
+	registry := newFakeRegistry(t)
+	base := []byte("shared base layer")
+	config := ConfigBlob{Created: "2024-01-01T00:00:00Z"}
+	configJSON, err := json.Marshal(config)
+	require.NoError(t, err)
+
+	amd64 := registry.addImage(t, config, [][]byte{base, []byte("app amd64")})
+	index := registry.addIndex(t, map[string]Platform{amd64: {Architecture: "amd64", OS: "linux"}}, "latest")
+	// The previous image lost its tag when it was deleted; its manifest and layer remain in storage
+	old := registry.addImage(t, ConfigBlob{Created: "2023-01-01T00:00:00Z"}, [][]byte{base, []byte("app old")})
+
+	referenced, err := registry.client().ReferencedDigests(context.Background(), "app")
+	require.NoError(t, err)
+	want := []string{index, amd64, sha256Digest(configJSON), sha256Digest(base), sha256Digest([]byte("app amd64"))}
+	assert.ElementsMatch(t, want, referenced)
+	assert.IsIncreasing(t, referenced)
+
+	stored := append(slices.Clone(want), old, sha256Digest([]byte("app old")))
+	dangling, err := registry.client().DanglingBlobs(context.Background(), "app", stored)
+	require.NoError(t, err)
+	assert.Equal(t, []string{old, sha256Digest([]byte("app old"))}, dangling)
+}
+
+func TestDanglingBlobs_Error(t *testing.T) {
+	registry := newFakeRegistry(t)
+	registry.addIndex(t, map[string]Platform{"sha256:missing": {Architecture: "amd64", OS: "linux"}}, "broken")
+
+	dangling, err := registry.client().DanglingBlobs(context.Background(), "app", []string{"sha256:abc"})
+	require.Error(t, err)
+	assert.Nil(t, dangling, "nothing may be reported dangling when the referenced set is incomplete")
+}
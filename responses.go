@@ -1,9 +1,24 @@
 package registryclient
 
+import (
+	"io/fs"
+	"net/http"
+	"time"
+)
+
 // PaginationParams contains parameters for paginated requests
 type PaginationParams struct {
-	N    int    // Page size (0 for no limit)
-	Last string // Last item from previous page
+	N      int    // Page size (0 for no limit)
+	Last   string // Last item from previous page
+	Cursor string // PaginatedResponse.Cursor of the previous page, passed back verbatim; takes precedence over Last
+}
+
+// position returns where the page starts: Cursor if set, otherwise Last
+func (p *PaginationParams) position() string {
+	if p.Cursor != "" {
+		return p.Cursor
+	}
+	return p.Last
 }
 
 // PaginatedResponse provides pagination metadata
@@ -11,12 +26,17 @@ type PaginatedResponse struct {
 	HasMore bool   // Whether more results are available
 	Last    string // Last item in current page (for next request)
 	N       int    // Page size from Link header (if present)
+	Cursor  string // Opaque position of the next page, for PaginationParams.Cursor
 }
 
 // CatalogResponse represents the response from catalog endpoints
 type CatalogResponse struct {
 	Repositories []string
 	PaginatedResponse
+
+	// VersionCounts maps repositories to their number of versions, for registries that
+	// report it in the catalog (GitHub). Nil otherwise.
+	VersionCounts map[string]int
 }
 
 // TagsResponse represents the response from tags endpoints
@@ -30,18 +50,57 @@ type TagsResponse struct {
 type ManifestResponse struct {
 	SchemaVersion int
 	MediaType     string
-	ManifestData  any // ImageManifest or ManifestList
+	IsIndex       bool // Whether MediaType is an OCI image index or Docker manifest list
+	ManifestData  any  // ImageManifest or ManifestList
 
 	// HTTP response metadata
 	Digest     string
 	RawContent []byte
+	Headers    http.Header // Copy of the response headers (nil when not fetched from the registry)
+	Attempts   int         // Requests sent to fetch it, retries included (0 when not fetched from the registry)
+
+	// Subject is the OCI-Subject header of PutManifest: the digest of the subject the
+	// registry indexed for the Referrers API. Empty when the registry did not process the
+	// subject, in which case the referrers fallback tag must be updated by the pusher.
+	Subject string
 }
 
 // BlobResponse represents the response from blob endpoints
 type BlobResponse struct {
-	Digest  string
-	Content []byte
-	Size    int64
+	Digest    string
+	Content   []byte
+	Size      int64
+	MediaType string      // Content-Type reported by the registry
+	Headers   http.Header // Copy of the response headers (nil when assembled from several responses)
+	Attempts  int         // Requests sent to fetch it, retries included (0 when assembled from several responses)
+}
+
+// BlobStat is what a HEAD on a blob reports (see StatBlob)
+type BlobStat struct {
+	Digest         string // Docker-Content-Digest, or the requested digest when absent
+	Size           int64  // Content-Length (-1 when unknown)
+	MediaType      string // Content-Type reported by the registry
+	SupportsRanges bool   // Whether Accept-Ranges lists "bytes", so GetBlobRange can be used
+}
+
+// HealthStatus is the result of CheckHealth
+type HealthStatus struct {
+	Code         int           // HTTP status of /v2/ (503 when the registry is unreachable)
+	Latency      time.Duration // Time until the response, or the connection failure
+	RequiresAuth bool          // Whether /v2/ answers 401 with an authentication challenge to requests without credentials
+}
+
+// RegistryCapabilities reports what a registry supports (see Capabilities)
+type RegistryCapabilities struct {
+	APIVersion    string // Docker-Distribution-API-Version advertised by /v2/ (empty when absent)
+	Authenticated bool   // Whether /v2/ accepted the request; the other probes need it
+	AuthScheme    string // Challenge scheme when /v2/ answered 401 ("Bearer", "Basic")
+	Catalog       bool   // Whether /v2/_catalog can be listed
+	Pagination    bool   // Whether a one-item catalog page linked to the next (false with a single repository)
+	Repository    string // Repository the fields below were probed on ("" when the catalog named none)
+	Referrers     bool   // Whether the Referrers API is served
+	Delete        bool   // Whether manifest deletion is enabled (false, not probed, with DisableDelete)
+	RangeRequests bool   // Whether blobs advertise Accept-Ranges: bytes
 }
 
 // GitHubPackage represents a GitHub container package
@@ -53,6 +112,17 @@ type GitHubPackage struct {
 	URL         string `json:"url"`
 	CreatedAt   string `json:"created_at"`
 	UpdatedAt   string `json:"updated_at"`
+
+	VersionCount int `json:"version_count,omitempty"` // Number of versions, 0 when the API omits it
+
+	// Only returned for a single package (GetPackage)
+	Owner *GitHubPackageOwner `json:"owner,omitempty"`
+}
+
+// GitHubPackageOwner is the user or organization owning a GitHub package
+type GitHubPackageOwner struct {
+	Login string `json:"login"`
+	Type  string `json:"type"`
 }
 
 // GitHubPackagesResponse represents the response from GitHub packages endpoint
@@ -63,9 +133,11 @@ type GitHubPackagesResponse struct {
 
 // GitHubPackageVersion represents a GitHub package version
 type GitHubPackageVersion struct {
-	ID       int                   `json:"id"`
-	Name     string                `json:"name"`
-	Metadata GitHubPackageMetadata `json:"metadata"`
+	ID        int                   `json:"id"`
+	Name      string                `json:"name"`
+	CreatedAt string                `json:"created_at"`
+	UpdatedAt string                `json:"updated_at"`
+	Metadata  GitHubPackageMetadata `json:"metadata"`
 }
 
 // GitHubPackageMetadata contains package metadata
@@ -77,3 +149,56 @@ type GitHubPackageMetadata struct {
 type GitHubContainerMetadata struct {
 	Tags []string `json:"tags"`
 }
+
+// TagInfo represents a Docker Hub tag with its digest and last update time
+type TagInfo struct {
+	Name        string    `json:"name"`
+	Digest      string    `json:"digest"`
+	LastUpdated time.Time `json:"last_updated"`
+}
+
+// dockerHubTagsPage represents a single page from the Docker Hub tags API
+type dockerHubTagsPage struct {
+	Next    string    `json:"next"`
+	Results []TagInfo `json:"results"`
+}
+
+// ImageInspect is a docker-inspect-style summary of an image
+type ImageInspect struct {
+	Digest       string // Digest of the resolved manifest
+	MediaType    string
+	Created      string
+	Architecture string
+	OS           string
+	Size         int64 // Total compressed size of the layers
+	Labels       map[string]string
+
+	// Partial is true when the reference is an index and no platform was given.
+	// Only Digest and MediaType are populated in that case.
+	Partial bool
+}
+
+// LayerInfo pairs a layer of an image manifest with its uncompressed diff_id from the config
+type LayerInfo struct {
+	Digest    string // Digest of the compressed layer blob
+	Size      int64  // Compressed size
+	MediaType string
+	DiffID    string // Digest of the uncompressed layer tar, as listed in rootfs.diff_ids
+}
+
+// LayerFile is an entry of a layer tar (see ListLayerFiles)
+type LayerFile struct {
+	Path     string      // Cleaned entry name, relative to the image root
+	Size     int64       // Size of the file content (0 for directories and links)
+	Mode     fs.FileMode // Permission and type bits
+	Whiteout bool        // Whether the entry is a ".wh." whiteout deleting a path from lower layers
+}
+
+// UploadSession tracks an in-progress blob upload.
+// It can be persisted and reused to resume an upload after a restart.
+type UploadSession struct {
+	Repository string `json:"repository"`
+	Location   string `json:"location"` // Absolute URL of the upload session
+	UUID       string `json:"uuid"`     // Docker-Upload-UUID reported by the registry
+	Offset     int64  `json:"offset"`   // Number of bytes the registry has received
+}
@@ -0,0 +1,145 @@
+package registryclient
+
+import (
+	"fmt"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+)
+
+// The strict schemas list every field the OCI image spec and the Docker image and
+// distribution specs define, so StrictJSON decoding rejects anything else. Values are
+// only typed where the structure matters; the lenient types in types.go do the parsing.
+
+// strictManifestSchema covers image manifests and indexes in both formats
+type strictManifestSchema struct {
+	SchemaVersion int                `json:"schemaVersion"`
+	MediaType     string             `json:"mediaType,omitempty"`
+	ArtifactType  string             `json:"artifactType,omitempty"`
+	Config        *strictDescriptor  `json:"config,omitempty"`
+	Layers        []strictDescriptor `json:"layers,omitempty"`
+	Manifests     []strictDescriptor `json:"manifests,omitempty"`
+	Subject       *strictDescriptor  `json:"subject,omitempty"`
+	Annotations   map[string]string  `json:"annotations,omitempty"`
+}
+
+type strictDescriptor struct {
+	MediaType    string            `json:"mediaType"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	URLs         []string          `json:"urls,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+	Data         string            `json:"data,omitempty"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Platform     *strictPlatform   `json:"platform,omitempty"`
+}
+
+type strictPlatform struct {
+	Architecture string   `json:"architecture"`
+	OS           string   `json:"os"`
+	OSVersion    string   `json:"os.version,omitempty"`
+	OSFeatures   []string `json:"os.features,omitempty"`
+	Variant      string   `json:"variant,omitempty"`
+	Features     []string `json:"features,omitempty"`
+}
+
+// strictConfigSchema covers OCI image configs and Docker image configs, legacy fields included
+type strictConfigSchema struct {
+	Created      string                 `json:"created,omitempty"`
+	Author       string                 `json:"author,omitempty"`
+	Architecture string                 `json:"architecture"`
+	OS           string                 `json:"os"`
+	OSVersion    string                 `json:"os.version,omitempty"`
+	OSFeatures   []string               `json:"os.features,omitempty"`
+	Variant      string                 `json:"variant,omitempty"`
+	Config       *strictContainerConfig `json:"config,omitempty"`
+	RootFS       *strictRootFS          `json:"rootfs,omitempty"`
+	History      []strictHistoryEntry   `json:"history,omitempty"`
+
+	// Docker legacy fields
+	ID              string                 `json:"id,omitempty"`
+	Parent          string                 `json:"parent,omitempty"`
+	Comment         string                 `json:"comment,omitempty"`
+	Container       string                 `json:"container,omitempty"`
+	ContainerConfig *strictContainerConfig `json:"container_config,omitempty"`
+	DockerVersion   string                 `json:"docker_version,omitempty"`
+	Size            int64                  `json:"Size,omitempty"`
+}
+
+type strictContainerConfig struct {
+	User         any `json:"User,omitempty"`
+	ExposedPorts any `json:"ExposedPorts,omitempty"`
+	Env          any `json:"Env,omitempty"`
+	Entrypoint   any `json:"Entrypoint,omitempty"`
+	Cmd          any `json:"Cmd,omitempty"`
+	Volumes      any `json:"Volumes,omitempty"`
+	WorkingDir   any `json:"WorkingDir,omitempty"`
+	Labels       any `json:"Labels,omitempty"`
+	StopSignal   any `json:"StopSignal,omitempty"`
+	ArgsEscaped  any `json:"ArgsEscaped,omitempty"`
+	Memory       any `json:"Memory,omitempty"`
+	MemorySwap   any `json:"MemorySwap,omitempty"`
+	CPUShares    any `json:"CpuShares,omitempty"`
+	Healthcheck  any `json:"Healthcheck,omitempty"`
+
+	// Docker container configuration
+	Hostname        any `json:"Hostname,omitempty"`
+	Domainname      any `json:"Domainname,omitempty"`
+	AttachStdin     any `json:"AttachStdin,omitempty"`
+	AttachStdout    any `json:"AttachStdout,omitempty"`
+	AttachStderr    any `json:"AttachStderr,omitempty"`
+	Tty             any `json:"Tty,omitempty"`
+	OpenStdin       any `json:"OpenStdin,omitempty"`
+	StdinOnce       any `json:"StdinOnce,omitempty"`
+	Image           any `json:"Image,omitempty"`
+	NetworkDisabled any `json:"NetworkDisabled,omitempty"`
+	MacAddress      any `json:"MacAddress,omitempty"`
+	OnBuild         any `json:"OnBuild,omitempty"`
+	StopTimeout     any `json:"StopTimeout,omitempty"`
+	Shell           any `json:"Shell,omitempty"`
+}
+
+type strictRootFS struct {
+	Type      string   `json:"type"`
+	DiffIDs   []string `json:"diff_ids"`
+	BaseLayer string   `json:"base_layer,omitempty"` // Windows images
+}
+
+type strictHistoryEntry struct {
+	Created    string `json:"created,omitempty"`
+	Author     string `json:"author,omitempty"`
+	CreatedBy  string `json:"created_by,omitempty"`
+	Comment    string `json:"comment,omitempty"`
+	EmptyLayer bool   `json:"empty_layer,omitempty"`
+}
+
+// checkStrictManifest fails when a manifest of the given media type has a field its
+// specification does not define, including index fields in an image manifest and the
+// reverse
+func checkStrictManifest(b []byte, mediaType string) error {
+	var m strictManifestSchema
+	if err := json.UnmarshalStrict(b, &m); err != nil {
+		return fmt.Errorf("%w: %w", ErrInvalidManifest, err)
+	}
+
+	switch mediaType {
+	case ociManifestMediaType, "application/vnd.docker.distribution.manifest.v2+json":
+		if m.Manifests != nil {
+			return fmt.Errorf("%w: image manifest has a \"manifests\" field", ErrInvalidManifest)
+		}
+	case ociIndexMediaType, "application/vnd.docker.distribution.manifest.list.v2+json":
+		if m.Config != nil || m.Layers != nil {
+			return fmt.Errorf("%w: index has a \"config\" or \"layers\" field", ErrInvalidManifest)
+		}
+	}
+	return nil
+}
+
+// checkStrictConfig fails when an image config has a field the OCI and Docker image
+// specifications do not define
+func checkStrictConfig(b []byte) error {
+	var cfg strictConfigSchema
+	if err := json.UnmarshalStrict(b, &cfg); err != nil {
+		return fmt.Errorf("invalid config: %w", err)
+	}
+	return nil
+}
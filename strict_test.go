@@ -0,0 +1,140 @@
+package registryclient
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestCheckStrictManifest_Fixtures(t *testing.T) {
+	files, err := filepath.Glob("testdata/manifests/*.json")
+	require.NoError(t, err)
+
+	for _, file := range files {
+		if filepath.Base(file) == "unsupported-mediatype.json" {
+			continue
+		}
+		t.Run(filepath.Base(file), func(t *testing.T) {
+			content, err := os.ReadFile(file)
+			require.NoError(t, err)
+			manifest, err := ParseManifest(content)
+			require.NoError(t, err)
+
+			assert.NoError(t, checkStrictManifest(content, manifest.MediaType))
+		})
+	}
+}
+
+func TestCheckStrictManifest(t *testing.T) {
+	tests := []struct {
+		name      string
+		manifest  string
+		mediaType string
+		wantErr   string
+	}{
+		{
+			name:      "unknown top-level field",
+			manifest:  `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:a","size":1},"layers":[],"extra":true}`,
+			mediaType: "application/vnd.oci.image.manifest.v1+json",
+			wantErr:   "extra",
+		},
+		{
+			name:      "misspelled layer field",
+			manifest:  `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:a","size":1},"layers":[{"mediaType":"application/vnd.oci.image.layer.v1.tar+gzip","digest":"sha256:b","szie":1}]}`,
+			mediaType: "application/vnd.oci.image.manifest.v1+json",
+			wantErr:   "szie",
+		},
+		{
+			name:      "unknown platform field",
+			manifest:  `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.index.v1+json","manifests":[{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:a","size":1,"platform":{"architecture":"amd64","os":"linux","cpu":"x86"}}]}`,
+			mediaType: "application/vnd.oci.image.index.v1+json",
+			wantErr:   "cpu",
+		},
+		{
+			name:      "index fields in an image manifest",
+			manifest:  `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","config":{"mediaType":"application/vnd.oci.image.config.v1+json","digest":"sha256:a","size":1},"layers":[],"manifests":[]}`,
+			mediaType: "application/vnd.oci.image.manifest.v1+json",
+			wantErr:   `image manifest has a "manifests" field`,
+		},
+		{
+			name:      "image fields in an index",
+			manifest:  `{"schemaVersion":2,"mediaType":"application/vnd.docker.distribution.manifest.list.v2+json","manifests":[],"layers":[]}`,
+			mediaType: "application/vnd.docker.distribution.manifest.list.v2+json",
+			wantErr:   `index has a "config" or "layers" field`,
+		},
+		{
+			name:      "subject, annotations and artifact type",
+			manifest:  `{"schemaVersion":2,"mediaType":"application/vnd.oci.image.manifest.v1+json","artifactType":"application/spdx+json","config":{"mediaType":"application/vnd.oci.empty.v1+json","digest":"sha256:a","size":2,"data":"e30="},"layers":[{"mediaType":"application/spdx+json","digest":"sha256:b","size":1,"annotations":{"org.opencontainers.image.title":"sbom.json"},"urls":["https://example.com/b"]}],"subject":{"mediaType":"application/vnd.oci.image.manifest.v1+json","digest":"sha256:c","size":3},"annotations":{"a":"b"}}`,
+			mediaType: "application/vnd.oci.image.manifest.v1+json",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			err := checkStrictManifest([]byte(tt.manifest), tt.mediaType)
+			if tt.wantErr == "" {
+				require.NoError(t, err)
+				return
+			}
+			require.ErrorIs(t, err, ErrInvalidManifest)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestCheckStrictConfig(t *testing.T) {
+	valid, err := os.ReadFile("testdata/config/valid-config.json")
+	require.NoError(t, err)
+	require.NoError(t, checkStrictConfig(valid))
+
+	docker := `{"architecture":"amd64","os":"linux","id":"x","parent":"y","container":"z","docker_version":"24.0.7",` +
+		`"config":{"Hostname":"","AttachStdin":false,"Env":["A=B"],"Cmd":["sh"],"Image":"sha256:a","OnBuild":null},` +
+		`"container_config":{"Hostname":"abc","Cmd":["/bin/sh","-c","#(nop) CMD [\"sh\"]"]},` +
+		`"rootfs":{"type":"layers","diff_ids":[]},"history":[{"created":"2024-01-01T00:00:00Z","created_by":"CMD","empty_layer":true}]}`
+	require.NoError(t, checkStrictConfig([]byte(docker)), "Docker legacy fields are allowed")
+
+	err = checkStrictConfig([]byte(`{"archtecture":"amd64","os":"linux"}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "archtecture")
+
+	err = checkStrictConfig([]byte(`{"architecture":"amd64","os":"linux","config":{"Entrpoint":["sh"]}}`))
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "Entrpoint")
+}
+
+func TestStrictJSON(t *testing.T) {
+	registry := newFakeRegistry(t)
+	config := registry.addBlob([]byte(`{"architecture":"amd64","os":"linux","rootfs":{"type":"layers","diff_ids":[]},"unexpected":1}`))
+	digest := registry.addManifest(t, map[string]any{
+		"schemaVersion": 2,
+		"mediaType":     "application/vnd.oci.image.manifest.v1+json",
+		"config":        map[string]any{"mediaType": "application/vnd.oci.image.config.v1+json", "digest": config, "size": 1},
+		"layers":        []any{},
+		"extraField":    "typo",
+	}, "latest")
+
+	t.Run("lenient by default", func(t *testing.T) {
+		client := registry.client()
+		_, err := client.GetManifest(context.Background(), "app", "latest")
+		require.NoError(t, err)
+		_, err = client.GetConfigBlob(context.Background(), "app", config)
+		require.NoError(t, err)
+	})
+
+	t.Run("strict", func(t *testing.T) {
+		client := registry.client()
+		client.StrictJSON = true
+
+		_, err := client.GetManifest(context.Background(), "app", digest)
+		require.ErrorIs(t, err, ErrInvalidManifest)
+		assert.Contains(t, err.Error(), "extraField")
+
+		_, err = client.GetConfigBlob(context.Background(), "app", config)
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "unexpected")
+	})
+}
@@ -0,0 +1,384 @@
+package registryclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"regexp"
+	"slices"
+	"strings"
+	"time"
+
+	"golang.org/x/sync/errgroup"
+)
+
+// ListAllTags drains every page of ListTags and returns all tags of a repository.
+// Fails with ErrTooManyPages after MaxPages pages.
+func (c *BaseClient) ListAllTags(ctx context.Context, repository string) ([]string, error) {
+	tags := []string{}
+	pagination := &PaginationParams{N: c.pageSize(0, 0)}
+
+	for page := 1; ; page++ {
+		if err := c.checkPageLimit("ListAllTags", page); err != nil {
+			return nil, err
+		}
+
+		resp, err := c.ListTags(ctx, repository, pagination)
+		if err != nil {
+			return nil, err
+		}
+		tags = append(tags, resp.Tags...)
+
+		if !resp.HasMore || resp.Cursor == "" || len(resp.Tags) == 0 {
+			return tags, nil
+		}
+		pagination.Cursor = resp.Cursor
+		if resp.N > 0 {
+			pagination.N = resp.N
+		}
+	}
+}
+
+// ListTagsMatching returns all tags of a repository matching pattern.
+// The pattern is a Go regular expression (RE2 syntax) and is unanchored,
+// so use ^ and $ to match whole tags (e.g. `^release-\d+$`).
+func (c *BaseClient) ListTagsMatching(ctx context.Context, repository, pattern string) ([]string, error) {
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid tag pattern %q: %w", pattern, err)
+	}
+
+	tags, err := c.ListAllTags(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+
+	matching := []string{}
+	for _, tag := range tags {
+		if re.MatchString(tag) {
+			matching = append(matching, tag)
+		}
+	}
+
+	c.logDebug("Filtered tags",
+		"operation", "ListTagsMatching",
+		"repository", repository,
+		"pattern", pattern,
+		"tag_count", len(tags),
+		"match_count", len(matching),
+	)
+
+	return matching, nil
+}
+
+// ListTagsWithDigests lists all tags of a repository and resolves each to its manifest
+// digest with a HEAD request, sending at most concurrency requests at once
+// (0 or less uses a default of 8). Tags listed more than once are resolved once.
+// When the registry reports no Docker-Content-Digest, the manifest is fetched and its
+// digest computed locally. The first error stops the remaining requests and is returned.
+func (c *BaseClient) ListTagsWithDigests(ctx context.Context, repository string, concurrency int) (map[string]string, error) {
+	tags, err := c.ListAllTags(ctx, repository)
+	if err != nil {
+		return nil, err
+	}
+	slices.Sort(tags)
+	tags = slices.Compact(tags)
+
+	if concurrency <= 0 {
+		concurrency = defaultConcurrency
+	}
+
+	g, ctx := errgroup.WithContext(ctx)
+	g.SetLimit(concurrency)
+	digests := make([]string, len(tags))
+	for i, tag := range tags {
+		g.Go(func() error {
+			digest, err := c.tagDigest(ctx, repository, tag)
+			if err != nil {
+				return fmt.Errorf("tag %s: %w", tag, err)
+			}
+			digests[i] = digest
+			return nil
+		})
+	}
+	if err := g.Wait(); err != nil {
+		return nil, err
+	}
+
+	result := make(map[string]string, len(tags))
+	for i, tag := range tags {
+		result[tag] = digests[i]
+	}
+
+	c.logDebug("Resolved tag digests",
+		"operation", "ListTagsWithDigests",
+		"repository", repository,
+		"tag_count", len(tags),
+		"concurrency", concurrency,
+	)
+
+	return result, nil
+}
+
+// tagDigest resolves a tag to its manifest digest, preferring a HEAD request
+func (c *BaseClient) tagDigest(ctx context.Context, repository, tag string) (string, error) {
+	head, err := c.HeadManifest(ctx, repository, tag)
+	if err != nil {
+		return "", err
+	}
+	if head.Digest != "" {
+		return head.Digest, nil
+	}
+
+	manifest, err := c.GetManifest(ctx, repository, tag)
+	if err != nil {
+		return "", err
+	}
+	if manifest.Digest != "" {
+		return manifest.Digest, nil
+	}
+	return c.computeDigest(manifest.RawContent), nil
+}
+
+// SameImage reports whether two references (tags or digests) of a repository resolve to the
+// same manifest digest, using HEAD requests. A reference that does not exist is not the same
+// image as anything, so false is returned without an error.
+func (c *BaseClient) SameImage(ctx context.Context, repository, refA, refB string) (bool, error) {
+	digests := make([]string, 2)
+	for i, ref := range []string{refA, refB} {
+		digest, err := c.tagDigest(ctx, repository, ref)
+		if errors.Is(err, ErrManifestNotFound) {
+			c.logDebug("Reference not found",
+				"operation", "SameImage",
+				"repository", repository,
+				"reference", ref,
+			)
+			return false, nil
+		}
+		if err != nil {
+			return false, fmt.Errorf("reference %s: %w", ref, err)
+		}
+		digests[i] = digest
+	}
+
+	c.logDebug("Compared references",
+		"operation", "SameImage",
+		"repository", repository,
+		"reference_a", refA,
+		"digest_a", digests[0],
+		"reference_b", refB,
+		"digest_b", digests[1],
+	)
+
+	return digests[0] == digests[1], nil
+}
+
+// taggedImage is a tag resolved to its manifest digest and image creation time
+type taggedImage struct {
+	tag     string
+	digest  string
+	created time.Time
+}
+
+// PruneTags deletes old images from a repository, keeping the keep most recently created.
+// Tags matching the pattern (see ListTagsMatching) are resolved to their manifest digest and
+// ordered by the created time of the image config; for an index the first child image is used.
+// keep counts distinct digests, so several tags of one image use up a single slot. The
+// manifests of the other images are deleted by digest, which removes every tag pointing at it,
+// so before deleting, every tag of the repository is resolved with ListTagsWithDigests and
+// images also tagged outside the pattern are kept (and logged as a warning).
+// Returns the deleted digests; with DisableDelete nothing is deleted and the digests are
+// those that would be. On error, the digests deleted so far are returned.
+func (c *BaseClient) PruneTags(ctx context.Context, repository string, keep int, matching string) ([]string, error) {
+	if keep < 0 {
+		return nil, fmt.Errorf("keep must not be negative: %d", keep)
+	}
+
+	images, err := c.resolveTaggedImages(ctx, repository, matching)
+	if err != nil {
+		return nil, err
+	}
+
+	// Newest first; ties are ordered by tag so the result is deterministic
+	slices.SortStableFunc(images, func(a, b taggedImage) int {
+		if n := b.created.Compare(a.created); n != 0 {
+			return n
+		}
+		return strings.Compare(a.tag, b.tag)
+	})
+
+	// keep counts images, not tags: the tags of a kept digest are all kept
+	kept := map[string]bool{}
+	for _, image := range images {
+		if len(kept) == keep {
+			break
+		}
+		kept[image.digest] = true
+	}
+
+	var candidates []string
+	for _, image := range images {
+		if !kept[image.digest] && !slices.Contains(candidates, image.digest) {
+			candidates = append(candidates, image.digest)
+		}
+	}
+
+	tagged, err := c.tagsOutside(ctx, repository, images, candidates)
+	if err != nil {
+		return nil, err
+	}
+
+	deleted := []string{}
+	for _, digest := range candidates {
+		if tags := tagged[digest]; len(tags) > 0 {
+			c.logWarn("Keeping manifest tagged outside the prune pattern",
+				"operation", "PruneTags",
+				"repository", repository,
+				"digest", digest,
+				"tags", tags,
+			)
+			continue
+		}
+
+		if err := c.DeleteManifest(ctx, repository, digest); err != nil {
+			return deleted, err
+		}
+		deleted = append(deleted, digest)
+	}
+
+	c.logDebug("Pruned tags",
+		"operation", "PruneTags",
+		"repository", repository,
+		"pattern", matching,
+		"tag_count", len(images),
+		"deleted_count", len(deleted),
+	)
+
+	return deleted, nil
+}
+
+// tagsOutside returns, for each of the candidate digests, the tags pointing at it that are
+// not among images. Every tag of the repository is resolved with ListTagsWithDigests.
+func (c *BaseClient) tagsOutside(ctx context.Context, repository string, images []taggedImage, candidates []string) (map[string][]string, error) {
+	if len(candidates) == 0 {
+		return nil, nil
+	}
+
+	digests, err := c.ListTagsWithDigests(ctx, repository, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	tagged := map[string][]string{}
+	for tag, digest := range digests {
+		if !slices.Contains(candidates, digest) {
+			continue
+		}
+		if slices.ContainsFunc(images, func(image taggedImage) bool { return image.tag == tag }) {
+			continue
+		}
+		tagged[digest] = append(tagged[digest], tag)
+	}
+	for _, tags := range tagged {
+		slices.Sort(tags)
+	}
+	return tagged, nil
+}
+
+// ErrManifestInUse is returned by DeleteManifestChecked when tags still point at the digest
+var ErrManifestInUse = errors.New("manifest is referenced by tags")
+
+// DeleteManifestChecked deletes a manifest by digest after checking which tags point at it,
+// since deleting the digest removes all of them. The tags are resolved with
+// ListTagsWithDigests. When any are found and force is false, nothing is deleted and the
+// tags are returned with ErrManifestInUse. Otherwise the manifest is deleted (respecting
+// DisableDelete) and the removed tags are returned.
+func (c *BaseClient) DeleteManifestChecked(ctx context.Context, repository, digest string, force bool) ([]string, error) {
+	digest, err := c.deleteDigest(ctx, repository, digest, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	digests, err := c.ListTagsWithDigests(ctx, repository, 0)
+	if err != nil {
+		return nil, err
+	}
+
+	var tags []string
+	for tag, tagDigest := range digests {
+		if tagDigest == digest {
+			tags = append(tags, tag)
+		}
+	}
+	slices.Sort(tags)
+
+	if len(tags) > 0 && !force {
+		return tags, fmt.Errorf("%w: %s@%s is tagged %s", ErrManifestInUse, repository, digest, strings.Join(tags, ", "))
+	}
+
+	if len(tags) > 0 {
+		c.logWarn("Deleting tagged manifest",
+			"operation", "DeleteManifestChecked",
+			"repository", repository,
+			"digest", digest,
+			"tags", tags,
+		)
+	}
+
+	if err := c.DeleteManifest(ctx, repository, digest); err != nil {
+		return nil, err
+	}
+	return tags, nil
+}
+
+// resolveTaggedImages resolves every tag matching pattern to its digest and creation time
+func (c *BaseClient) resolveTaggedImages(ctx context.Context, repository, pattern string) ([]taggedImage, error) {
+	tags, err := c.ListTagsMatching(ctx, repository, pattern)
+	if err != nil {
+		return nil, err
+	}
+
+	images := make([]taggedImage, 0, len(tags))
+	for _, tag := range tags {
+		image, err := c.resolveTaggedImage(ctx, repository, tag)
+		if err != nil {
+			return nil, fmt.Errorf("resolve tag %s: %w", tag, err)
+		}
+		images = append(images, *image)
+	}
+	return images, nil
+}
+
+// resolveTaggedImage fetches a tag's manifest and the created time from its image config
+func (c *BaseClient) resolveTaggedImage(ctx context.Context, repository, tag string) (*taggedImage, error) {
+	manifest, err := c.GetManifest(ctx, repository, tag)
+	if err != nil {
+		return nil, err
+	}
+	digest := manifest.Digest
+	if digest == "" {
+		digest = c.computeDigest(manifest.RawContent)
+	}
+
+	if list, ok := manifest.ManifestData.(ManifestList); ok {
+		if len(list.Manifests) == 0 {
+			return &taggedImage{tag: tag, digest: digest}, nil
+		}
+		if manifest, err = c.getChildManifest(ctx, repository, list.Manifests[0]); err != nil {
+			return nil, err
+		}
+	}
+
+	image, ok := manifest.ManifestData.(ImageManifest)
+	if !ok {
+		return &taggedImage{tag: tag, digest: digest}, nil
+	}
+	config, err := c.GetConfigBlob(ctx, repository, image.Config.Digest)
+	if err != nil {
+		return nil, err
+	}
+
+	// An unparsable or missing created time sorts as oldest
+	created, _ := time.Parse(time.RFC3339Nano, config.Created)
+	return &taggedImage{tag: tag, digest: digest, created: created}, nil
+}
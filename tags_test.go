@@ -0,0 +1,370 @@
+package registryclient
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// newPagedTagsServer serves tags in pages of pageSize using Link headers
+func newPagedTagsServer(t *testing.T, tags []string, pageSize int) *httptest.Server {
+	t.Helper()
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := 0
+		if last := r.URL.Query().Get("last"); last != "" {
+			for i, tag := range tags {
+				if tag == last {
+					start = i + 1
+				}
+			}
+		}
+		end := min(start+pageSize, len(tags))
+		if end < len(tags) {
+			w.Header().Set("Link", fmt.Sprintf(`</v2/app/tags/list?last=%s&n=%d>; rel="next"`, tags[end-1], pageSize))
+		}
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": "app", "tags": tags[start:end]})
+	}))
+}
+
+func TestListAllTags(t *testing.T) {
+	tags := []string{"a", "b", "c", "d", "e"}
+	server := newPagedTagsServer(t, tags, 2)
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	got, err := client.ListAllTags(context.Background(), "app")
+
+	require.NoError(t, err)
+	assert.Equal(t, tags, got)
+}
+
+func TestListAllTags_PaginationLoop(t *testing.T) {
+	var requests int
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		w.Header().Set("Link", `</v2/app/tags/list?last=b&n=2>; rel="next"`)
+		_ = json.NewEncoder(w).Encode(map[string]any{"name": "app", "tags": []string{"a", "b"}})
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, MaxPages: 5}
+	tags, err := client.ListAllTags(context.Background(), "app")
+
+	require.ErrorIs(t, err, ErrTooManyPages)
+	assert.Nil(t, tags)
+	assert.Equal(t, 5, requests)
+}
+
+func TestListTagsMatching(t *testing.T) {
+	tags := []string{"latest", "v1.0", "v1.2.3", "v2.0", "release-1", "release-22", "release-x", "prerelease-3"}
+	server := newPagedTagsServer(t, tags, 3)
+	defer server.Close()
+
+	tests := []struct {
+		name    string
+		pattern string
+		want    []string
+	}{
+		{name: "version prefix", pattern: `^v1\..*`, want: []string{"v1.0", "v1.2.3"}},
+		{name: "unanchored", pattern: `v1.*`, want: []string{"v1.0", "v1.2.3"}},
+		{name: "anchored release", pattern: `^release-\d+$`, want: []string{"release-1", "release-22"}},
+		{name: "no match", pattern: `^nightly`, want: []string{}},
+	}
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := client.ListTagsMatching(context.Background(), "app", tt.pattern)
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestListTagsMatching_InvalidPattern(t *testing.T) {
+	requests := 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	got, err := client.ListTagsMatching(context.Background(), "app", `release-(\d+`)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "invalid tag pattern")
+	assert.Nil(t, got)
+	assert.Zero(t, requests, "pattern must be validated before listing tags")
+}
+
+func TestListTagsMatching_ListError(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	got, err := client.ListTagsMatching(context.Background(), "app", `.*`)
+
+	require.Error(t, err)
+	assert.Nil(t, got)
+}
+
+func TestPruneTags(t *testing.T) {
+	registry := newFakeRegistry(t)
+	v1 := registry.addImage(t, ConfigBlob{Created: "2024-01-01T00:00:00Z"}, [][]byte{[]byte("v1")}, "v1")
+	v2 := registry.addImage(t, ConfigBlob{Created: "2024-02-01T00:00:00Z"}, [][]byte{[]byte("v2")}, "v2")
+	v3 := registry.addImage(t, ConfigBlob{Created: "2024-03-01T00:00:00Z"}, [][]byte{[]byte("v3")}, "v3", "v3.0")
+	v4 := registry.addImage(t, ConfigBlob{Created: "2024-04-01T00:00:00Z"}, [][]byte{[]byte("v4")})
+	registry.addIndex(t, map[string]Platform{v4: {Architecture: "amd64", OS: "linux"}}, "v4")
+	registry.addImage(t, ConfigBlob{Created: "2023-01-01T00:00:00Z"}, [][]byte{[]byte("nightly")}, "nightly")
+
+	client := registry.client()
+	deleted, err := client.PruneTags(context.Background(), "app", 2, `^v`)
+	require.NoError(t, err)
+
+	// v4 and v3 are kept; v3.0 shares the kept v3 digest and must not be deleted
+	assert.Equal(t, []string{v2, v1}, deleted)
+
+	tags, err := client.ListAllTags(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"nightly", "v3", "v3.0", "v4"}, tags)
+	assert.Contains(t, registry.manifests, v3)
+}
+
+func TestPruneTags_SharedDigests(t *testing.T) {
+	registry := newFakeRegistry(t)
+	v1 := registry.addImage(t, ConfigBlob{Created: "2024-01-01T00:00:00Z"}, [][]byte{[]byte("v1")}, "v1")
+	registry.addImage(t, ConfigBlob{Created: "2024-02-01T00:00:00Z"}, [][]byte{[]byte("v2")}, "v2")
+	registry.addImage(t, ConfigBlob{Created: "2024-03-01T00:00:00Z"}, [][]byte{[]byte("v3")}, "v3", "v3.0", "latest")
+
+	client := registry.client()
+	deleted, err := client.PruneTags(context.Background(), "app", 2, `.*`)
+	require.NoError(t, err)
+
+	// The three tags of the newest image count as one of the two images kept
+	assert.Equal(t, []string{v1}, deleted)
+	tags, err := client.ListAllTags(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"latest", "v2", "v3", "v3.0"}, tags)
+}
+
+func TestPruneTags_TaggedOutsidePattern(t *testing.T) {
+	registry := newFakeRegistry(t)
+	v1 := registry.addImage(t, ConfigBlob{Created: "2024-01-01T00:00:00Z"}, [][]byte{[]byte("v1")}, "v1.0", "stable")
+	v2 := registry.addImage(t, ConfigBlob{Created: "2024-02-01T00:00:00Z"}, [][]byte{[]byte("v2")}, "v2.0")
+	registry.addImage(t, ConfigBlob{Created: "2024-03-01T00:00:00Z"}, [][]byte{[]byte("v3")}, "v3.0")
+
+	client := registry.client()
+	deleted, err := client.PruneTags(context.Background(), "app", 1, `^v`)
+	require.NoError(t, err)
+
+	// v1.0 is pruned, but stable still points at its digest
+	assert.Equal(t, []string{v2}, deleted)
+	assert.Zero(t, registry.requestCount(http.MethodDelete, "/manifests/"+v1))
+	tags, err := client.ListAllTags(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Equal(t, []string{"stable", "v1.0", "v3.0"}, tags)
+}
+
+func TestPruneTags_KeepAll(t *testing.T) {
+	registry := newFakeRegistry(t)
+	registry.addImage(t, ConfigBlob{Created: "2024-01-01T00:00:00Z"}, [][]byte{[]byte("v1")}, "v1")
+
+	deleted, err := registry.client().PruneTags(context.Background(), "app", 5, `.*`)
+	require.NoError(t, err)
+	assert.Empty(t, deleted)
+	assert.Contains(t, registry.manifests, "v1")
+}
+
+func TestPruneTags_NegativeKeep(t *testing.T) {
+	registry := newFakeRegistry(t)
+
+	_, err := registry.client().PruneTags(context.Background(), "app", -1, `.*`)
+	require.Error(t, err)
+	assert.Zero(t, registry.requestCount(http.MethodGet, "/tags/list"))
+}
+
+func TestDeleteManifestChecked(t *testing.T) {
+	newRegistry := func(t *testing.T) (*fakeRegistry, string, string) {
+		registry := newFakeRegistry(t)
+		shared := registry.addImage(t, ConfigBlob{Created: "2024-01-01T00:00:00Z"}, nil, "v1.2.3", "latest", "stable")
+		untagged := registry.addImage(t, ConfigBlob{Created: "2023-01-01T00:00:00Z"}, nil)
+		return registry, shared, untagged
+	}
+
+	t.Run("refuses tagged digest", func(t *testing.T) {
+		registry, shared, _ := newRegistry(t)
+
+		tags, err := registry.client().DeleteManifestChecked(context.Background(), "app", shared, false)
+		require.ErrorIs(t, err, ErrManifestInUse)
+		assert.Equal(t, []string{"latest", "stable", "v1.2.3"}, tags)
+		assert.Contains(t, registry.manifests, shared)
+		assert.Zero(t, registry.requestCount(http.MethodDelete, "/manifests/"+shared))
+	})
+
+	t.Run("force deletes tagged digest", func(t *testing.T) {
+		registry, shared, _ := newRegistry(t)
+
+		tags, err := registry.client().DeleteManifestChecked(context.Background(), "app", shared, true)
+		require.NoError(t, err)
+		assert.Equal(t, []string{"latest", "stable", "v1.2.3"}, tags)
+		assert.Equal(t, 1, registry.requestCount(http.MethodDelete, "/manifests/"+shared))
+	})
+
+	t.Run("untagged digest", func(t *testing.T) {
+		registry, _, untagged := newRegistry(t)
+
+		tags, err := registry.client().DeleteManifestChecked(context.Background(), "app", untagged, false)
+		require.NoError(t, err)
+		assert.Empty(t, tags)
+		assert.NotContains(t, registry.manifests, untagged)
+	})
+
+	t.Run("tag reference", func(t *testing.T) {
+		registry, _, _ := newRegistry(t)
+
+		_, err := registry.client().DeleteManifestChecked(context.Background(), "app", "latest", true)
+		require.ErrorIs(t, err, ErrTagReference)
+		assert.Zero(t, registry.requestCount(http.MethodGet, "/tags/list"))
+	})
+}
+
+func TestListTagsWithDigests(t *testing.T) {
+	registry := newFakeRegistry(t)
+	v1 := registry.addImage(t, ConfigBlob{Created: "2024-01-01T00:00:00Z"}, nil, "v1")
+	v2 := registry.addImage(t, ConfigBlob{Created: "2024-02-01T00:00:00Z"}, nil, "v2", "latest")
+
+	for _, concurrency := range []int{0, 1, 4} {
+		t.Run(fmt.Sprintf("concurrency %d", concurrency), func(t *testing.T) {
+			digests, err := registry.client().ListTagsWithDigests(context.Background(), "app", concurrency)
+			require.NoError(t, err)
+			assert.Equal(t, map[string]string{"v1": v1, "v2": v2, "latest": v2}, digests)
+		})
+	}
+	assert.Zero(t, registry.requestCount(http.MethodGet, "/manifests/v1"), "digests must be resolved with HEAD")
+}
+
+func TestListTagsWithDigests_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/v2/app/tags/list" {
+			_, _ = w.Write([]byte(`{"name":"app","tags":["v1","v2"]}`))
+			return
+		}
+		w.WriteHeader(http.StatusNotFound)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	digests, err := client.ListTagsWithDigests(context.Background(), "app", 2)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "404")
+	assert.Nil(t, digests)
+}
+
+func TestListTagsWithDigests_Concurrency(t *testing.T) {
+	registry := newFakeRegistry(t)
+	for i := range 12 {
+		registry.addImage(t, ConfigBlob{}, [][]byte{fmt.Appendf(nil, "layer %d", i)}, fmt.Sprintf("v%d", i))
+	}
+	registry.manifests["broken"] = []byte("{}") // listed first, failing while most tags wait
+	peak := registry.trackInFlight()
+	handler := registry.server.Config.Handler
+	registry.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasSuffix(r.URL.Path, "/manifests/broken") {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+
+	_, err := registry.client().ListTagsWithDigests(context.Background(), "app", 3)
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "tag broken")
+	require.NotErrorIs(t, err, context.Canceled, "the first error is returned, not the cancellations it caused")
+	assert.LessOrEqual(t, peak.Load(), int32(3))
+}
+
+func TestSameImage(t *testing.T) {
+	registry := newFakeRegistry(t)
+	v1 := registry.addImage(t, ConfigBlob{Created: "2024-01-01T00:00:00Z"}, nil, "v1.2.3", "latest")
+	registry.addImage(t, ConfigBlob{Created: "2024-02-01T00:00:00Z"}, nil, "v2")
+
+	tests := []struct {
+		name       string
+		refA, refB string
+		want       bool
+	}{
+		{name: "same digest", refA: "v1.2.3", refB: "latest", want: true},
+		{name: "tag and its digest", refA: "latest", refB: v1, want: true},
+		{name: "different images", refA: "v1.2.3", refB: "v2", want: false},
+		{name: "missing reference", refA: "v1.2.3", refB: "missing", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			same, err := registry.client().SameImage(context.Background(), "app", tt.refA, tt.refB)
+			require.NoError(t, err)
+			assert.Equal(t, tt.want, same)
+		})
+	}
+	assert.Zero(t, registry.requestCount(http.MethodGet, "/manifests/latest"), "references must be resolved with HEAD")
+}
+
+func TestSameImage_Error(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	same, err := client.SameImage(context.Background(), "app", "v1", "latest")
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "reference v1: head manifest failed: 401")
+	assert.False(t, same)
+}
+
+func TestListAllTags_DefaultPageSize(t *testing.T) {
+	tests := []struct {
+		name            string
+		defaultPageSize int
+		wantN           []string
+	}{
+		{name: "registry default", defaultPageSize: 0, wantN: []string{"", ""}},
+		{name: "configured", defaultPageSize: 1000, wantN: []string{"1000", "1000"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var gotN []string
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				gotN = append(gotN, r.URL.Query().Get("n"))
+				if r.URL.Query().Get("last") == "" {
+					// The Link header carries no n, so the configured page size must be kept
+					w.Header().Set("Link", `</v2/app/tags/list?last=a>; rel="next"`)
+					_ = json.NewEncoder(w).Encode(map[string]any{"name": "app", "tags": []string{"a"}})
+					return
+				}
+				_ = json.NewEncoder(w).Encode(map[string]any{"name": "app", "tags": []string{"b"}})
+			}))
+			defer server.Close()
+
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, DefaultPageSize: tt.defaultPageSize}
+			tags, err := client.ListAllTags(context.Background(), "app")
+
+			require.NoError(t, err)
+			assert.Equal(t, []string{"a", "b"}, tags)
+			assert.Equal(t, tt.wantN, gotN)
+		})
+	}
+}
@@ -0,0 +1,210 @@
+package registryclient
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+)
+
+// TokenRefresher is an Auth that can obtain new credentials from a 401 challenge.
+// BaseClient.Do calls Refresh with the WWW-Authenticate header and retries the request once.
+type TokenRefresher interface {
+	Auth
+	Refresh(ctx context.Context, httpClient *http.Client, challenge string) error
+}
+
+// TokenAuth implements the registry token flow: on a Bearer challenge it requests a
+// token from the challenge realm (with Basic credentials when Username is set) and
+// sends it on subsequent requests. Expired tokens are refreshed the same way.
+// TokenAuth must be used as a pointer and is safe for concurrent use.
+type TokenAuth struct {
+	Username string
+	Password string
+
+	mu    sync.RWMutex
+	token string
+}
+
+// Apply sets the current token, if any
+func (a *TokenAuth) Apply(req *http.Request) {
+	a.mu.RLock()
+	defer a.mu.RUnlock()
+	if a.token != "" {
+		req.Header.Set("Authorization", "Bearer "+a.token)
+	}
+}
+
+// Refresh requests a new token for a Bearer challenge
+func (a *TokenAuth) Refresh(ctx context.Context, httpClient *http.Client, challenge string) error {
+	scheme, params := parseChallenge(challenge)
+	if !strings.EqualFold(scheme, "Bearer") || params["realm"] == "" {
+		return fmt.Errorf("unsupported auth challenge: %q", challenge)
+	}
+
+	token, err := a.fetchToken(ctx, httpClient, params)
+	if err != nil {
+		return err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.token = token
+	return nil
+}
+
+// fetchToken requests a token from the realm of a Bearer challenge
+func (a *TokenAuth) fetchToken(ctx context.Context, httpClient *http.Client, params map[string]string) (string, error) {
+	tokenURL, err := url.Parse(params["realm"])
+	if err != nil {
+		return "", err
+	}
+	query := tokenURL.Query()
+	for _, key := range []string{"service", "scope"} {
+		if params[key] != "" {
+			query.Set(key, params[key])
+		}
+	}
+	tokenURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, tokenURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+	if a.Username != "" {
+		req.SetBasicAuth(a.Username, a.Password)
+	}
+
+	resp, err := httpClient.Do(req)
+	if err != nil {
+		return "", err
+	}
+	defer func() { _ = resp.Body.Close() }()
+
+	if resp.StatusCode != http.StatusOK {
+		body, _ := io.ReadAll(resp.Body)
+		return "", fmt.Errorf("token request failed: %s - %s", resp.Status, string(body))
+	}
+
+	var data struct {
+		Token       string `json:"token"`
+		AccessToken string `json:"access_token"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&data); err != nil {
+		return "", err
+	}
+	if data.Token == "" {
+		data.Token = data.AccessToken
+	}
+	if data.Token == "" {
+		return "", fmt.Errorf("token request failed: no token in response")
+	}
+	return data.Token, nil
+}
+
+// parseChallenge splits a WWW-Authenticate header such as
+// `Bearer realm="https://auth.example.com/token",service="registry",scope="repository:app:pull"`
+// into its scheme and parameters
+func parseChallenge(header string) (string, map[string]string) {
+	scheme, rest, _ := strings.Cut(strings.TrimSpace(header), " ")
+	params := map[string]string{}
+
+	for rest != "" {
+		var key, value string
+		key, rest, _ = strings.Cut(strings.TrimLeft(rest, " ,"), "=")
+		if strings.HasPrefix(rest, `"`) {
+			value, rest, _ = strings.Cut(rest[1:], `"`)
+		} else {
+			value, rest, _ = strings.Cut(rest, ",")
+		}
+		if key = strings.TrimSpace(key); key != "" {
+			params[strings.ToLower(key)] = value
+		}
+	}
+	return scheme, params
+}
+
+// checkRealm applies AllowedHosts to the token realm of challenge. A realm that does
+// not parse is refused rather than handed to the refresher unchecked.
+func (c *BaseClient) checkRealm(challenge string) error {
+	if len(c.AllowedHosts) == 0 {
+		return nil
+	}
+	_, params := parseChallenge(challenge)
+	if params["realm"] == "" {
+		return nil
+	}
+	realm, err := url.Parse(params["realm"])
+	if err != nil {
+		return fmt.Errorf("%w: invalid token realm %q", ErrHostNotAllowed, params["realm"])
+	}
+	return c.checkHost(realm)
+}
+
+// ErrInsufficientScope is returned when a registry still answers 403 insufficient_scope
+// after TokenAuth requested a token for the scope in the challenge
+var ErrInsufficientScope = errors.New("insufficient scope")
+
+// refreshAndRetry handles a 401 response, or a 403 whose challenge reports insufficient_scope,
+// when Auth is a TokenRefresher: it refreshes the token from the challenge (which carries the
+// scope the request needs) and sends req once more. Other responses are returned unchanged,
+// as is a second 401, which is then a genuine authentication failure. A second
+// insufficient_scope is returned as ErrInsufficientScope.
+func (c *BaseClient) refreshAndRetry(req *http.Request, resp *http.Response) (*http.Response, error) {
+	refresher, ok := c.Auth.(TokenRefresher)
+	if !ok || !isBodyReplayable(req) || !c.authorizes(req.URL) {
+		return resp, nil
+	}
+	insufficientScope := isInsufficientScope(resp)
+	if resp.StatusCode != http.StatusUnauthorized && !insufficientScope {
+		return resp, nil
+	}
+	challenge := resp.Header.Get("WWW-Authenticate")
+	if challenge == "" {
+		return resp, nil
+	}
+	attempts := ResponseAttempts(resp)
+	c.closeBody(resp.Body)
+
+	c.logDebug("Refreshing registry token",
+		"method", req.Method,
+		"url", req.URL.String(),
+		"insufficient_scope", insufficientScope,
+	)
+
+	if err := c.checkRealm(challenge); err != nil {
+		return nil, err
+	}
+	if err := refresher.Refresh(req.Context(), c.httpClient(), challenge); err != nil {
+		if insufficientScope {
+			return nil, fmt.Errorf("%w: %w", ErrInsufficientScope, err)
+		}
+		return nil, err
+	}
+
+	resp, err := c.doWithRetry(req)
+	if err != nil {
+		return nil, err
+	}
+	if isInsufficientScope(resp) {
+		_, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+		c.closeBody(resp.Body)
+		return nil, fmt.Errorf("%w: %s %s requires %s", ErrInsufficientScope, req.Method, req.URL.Path, params["scope"])
+	}
+	return withAttempts(resp, attempts+ResponseAttempts(resp)), nil
+}
+
+// isInsufficientScope reports whether resp is a 403 with an insufficient_scope challenge
+func isInsufficientScope(resp *http.Response) bool {
+	if resp.StatusCode != http.StatusForbidden {
+		return false
+	}
+	_, params := parseChallenge(resp.Header.Get("WWW-Authenticate"))
+	return params["error"] == "insufficient_scope"
+}
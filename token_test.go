@@ -0,0 +1,311 @@
+package registryclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"sync"
+	"testing"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+// tokenRegistry is a registry that only accepts the most recently issued token
+type tokenRegistry struct {
+	mu            sync.Mutex
+	issued        int
+	valid         string
+	tokenRequests []*http.Request
+	server        *httptest.Server
+}
+
+func newTokenRegistry(t *testing.T) *tokenRegistry {
+	t.Helper()
+	r := &tokenRegistry{}
+	r.server = httptest.NewServer(http.HandlerFunc(r.serveHTTP))
+	t.Cleanup(r.server.Close)
+	return r
+}
+
+// expire invalidates the current token, as a short-lived token would
+func (r *tokenRegistry) expire() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.valid = ""
+}
+
+func (r *tokenRegistry) serveHTTP(w http.ResponseWriter, req *http.Request) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if req.URL.Path == "/token" {
+		r.tokenRequests = append(r.tokenRequests, req)
+		r.issued++
+		r.valid = fmt.Sprintf("token-%d", r.issued)
+		_ = json.NewEncoder(w).Encode(map[string]any{"token": r.valid, "expires_in": 300})
+		return
+	}
+
+	if r.valid == "" || req.Header.Get("Authorization") != "Bearer "+r.valid {
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token",service="test-registry",scope="repository:app:pull"`, r.server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+		return
+	}
+	_ = json.NewEncoder(w).Encode(map[string]any{"name": "app", "tags": []string{"v1"}})
+}
+
+func TestTokenAuth_RefreshesExpiredToken(t *testing.T) {
+	registry := newTokenRegistry(t)
+	client := &BaseClient{
+		HTTPClient: &http.Client{},
+		BaseURL:    registry.server.URL,
+		Auth:       &TokenAuth{Username: "user", Password: "pass"},
+	}
+
+	tags, err := client.ListTags(context.Background(), "app", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1"}, tags.Tags)
+
+	registry.expire()
+
+	tags, err = client.ListTags(context.Background(), "app", nil)
+	require.NoError(t, err)
+	assert.Equal(t, []string{"v1"}, tags.Tags)
+
+	require.Len(t, registry.tokenRequests, 2)
+	req, err := http.NewRequest(http.MethodGet, registry.server.URL+"/v2/app/tags/list", nil)
+	require.NoError(t, err)
+	registry.expire()
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	_ = resp.Body.Close()
+	assert.Equal(t, 2, ResponseAttempts(resp), "the rejected request and its retry with a new token")
+
+	require.Len(t, registry.tokenRequests, 3)
+	tokenReq := registry.tokenRequests[1]
+	assert.Equal(t, "test-registry", tokenReq.URL.Query().Get("service"))
+	assert.Equal(t, "repository:app:pull", tokenReq.URL.Query().Get("scope"))
+	username, password, ok := tokenReq.BasicAuth()
+	assert.True(t, ok)
+	assert.Equal(t, "user", username)
+	assert.Equal(t, "pass", password)
+}
+
+func TestTokenAuth_GenuineAuthFailure(t *testing.T) {
+	var tokenRequests, registryRequests int
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			tokenRequests++
+			_ = json.NewEncoder(w).Encode(map[string]any{"access_token": "rejected"})
+			return
+		}
+		registryRequests++
+		w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm="%s/token"`, server.URL))
+		w.WriteHeader(http.StatusUnauthorized)
+	}))
+	defer server.Close()
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, Auth: &TokenAuth{}}
+	_, err := client.ListTags(context.Background(), "app", nil)
+
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "401")
+	assert.Equal(t, 1, tokenRequests, "token must be refreshed only once per request")
+	assert.Equal(t, 2, registryRequests)
+}
+
+func TestTokenAuth_AllowedHostsRealm(t *testing.T) {
+	tests := []struct {
+		name  string
+		realm string
+	}{
+		{name: "realm on another host", realm: "https://auth.example.com/token"},
+		{name: "unparsable realm", realm: "http://%zz/token"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				assert.NotEqual(t, "/token", r.URL.Path)
+				w.Header().Set("WWW-Authenticate", fmt.Sprintf(`Bearer realm=%q`, tt.realm))
+				w.WriteHeader(http.StatusUnauthorized)
+			}))
+			defer server.Close()
+
+			serverURL, err := url.Parse(server.URL)
+			require.NoError(t, err)
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, Auth: &TokenAuth{}, AllowedHosts: []string{serverURL.Host}}
+
+			_, err = client.ListTags(context.Background(), "app", nil)
+			require.ErrorIs(t, err, ErrHostNotAllowed)
+		})
+	}
+}
+
+func TestTokenAuth_RefreshErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/denied":
+			w.WriteHeader(http.StatusUnauthorized)
+		case "/empty":
+			_, _ = w.Write([]byte(`{}`))
+		default:
+			_, _ = w.Write([]byte(`not json`))
+		}
+	}))
+	defer server.Close()
+
+	tests := []struct {
+		name      string
+		challenge string
+		wantErr   string
+	}{
+		{name: "basic challenge", challenge: `Basic realm="registry"`, wantErr: "unsupported auth challenge"},
+		{name: "missing realm", challenge: `Bearer service="registry"`, wantErr: "unsupported auth challenge"},
+		{name: "invalid credentials", challenge: fmt.Sprintf(`Bearer realm="%s/denied"`, server.URL), wantErr: "token request failed: 401"},
+		{name: "no token", challenge: fmt.Sprintf(`Bearer realm="%s/empty"`, server.URL), wantErr: "no token in response"},
+		{name: "invalid JSON", challenge: fmt.Sprintf(`Bearer realm="%s/json"`, server.URL), wantErr: "invalid character"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			auth := &TokenAuth{}
+			err := auth.Refresh(context.Background(), &http.Client{}, tt.challenge)
+
+			require.Error(t, err)
+			assert.Contains(t, err.Error(), tt.wantErr)
+		})
+	}
+}
+
+func TestTokenAuth_NonReplayableBodyIsNotRetried(t *testing.T) {
+	registry := newTokenRegistry(t)
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: registry.server.URL, Auth: &TokenAuth{}}
+
+	req, err := http.NewRequest(http.MethodPut, registry.server.URL+"/v2/app/manifests/v1", nil)
+	require.NoError(t, err)
+	req.Body = io.NopCloser(strings.NewReader("manifest")) // no GetBody, so it cannot be replayed
+
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	defer client.closeBody(resp.Body)
+
+	assert.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	assert.Empty(t, registry.tokenRequests)
+}
+
+func TestParseChallenge(t *testing.T) {
+	tests := []struct {
+		name       string
+		header     string
+		wantScheme string
+		wantParams map[string]string
+	}{
+		{
+			name:       "docker hub",
+			header:     `Bearer realm="https://auth.docker.io/token",service="registry.docker.io",scope="repository:library/nginx:pull"`,
+			wantScheme: "Bearer",
+			wantParams: map[string]string{"realm": "https://auth.docker.io/token", "service": "registry.docker.io", "scope": "repository:library/nginx:pull"},
+		},
+		{
+			name:       "comma in quoted scope",
+			header:     `Bearer realm="https://ghcr.io/token", scope="repository:org/app:pull,push"`,
+			wantScheme: "Bearer",
+			wantParams: map[string]string{"realm": "https://ghcr.io/token", "scope": "repository:org/app:pull,push"},
+		},
+		{
+			name:       "unquoted values",
+			header:     `Basic realm=registry,charset=UTF-8`,
+			wantScheme: "Basic",
+			wantParams: map[string]string{"realm": "registry", "charset": "UTF-8"},
+		},
+		{name: "scheme only", header: "Bearer", wantScheme: "Bearer", wantParams: map[string]string{}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			scheme, params := parseChallenge(tt.header)
+			assert.Equal(t, tt.wantScheme, scheme)
+			assert.Equal(t, tt.wantParams, params)
+		})
+	}
+}
+
+// newScopedRegistry serves a registry where pushes need a token with the push scope.
+// The token endpoint grants the requested scope unless grantPush is false.
+func newScopedRegistry(t *testing.T, grantPush bool) (*httptest.Server, *[]string) {
+	t.Helper()
+	var scopes []string
+	var server *httptest.Server
+	server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/token" {
+			scope := r.URL.Query().Get("scope")
+			scopes = append(scopes, scope)
+			if !grantPush {
+				scope = "repository:app:pull"
+			}
+			_ = json.NewEncoder(w).Encode(map[string]any{"token": scope})
+			return
+		}
+
+		challenge := fmt.Sprintf(`Bearer realm="%s/token",service="test-registry",scope="repository:app:pull`, server.URL)
+		token := strings.TrimPrefix(r.Header.Get("Authorization"), "Bearer ")
+		switch {
+		case token == "":
+			w.Header().Set("WWW-Authenticate", challenge+`"`)
+			w.WriteHeader(http.StatusUnauthorized)
+		case r.Method == http.MethodPut && !strings.HasSuffix(token, ",push"):
+			w.Header().Set("WWW-Authenticate", challenge+`,push",error="insufficient_scope"`)
+			w.WriteHeader(http.StatusForbidden)
+		default:
+			w.WriteHeader(http.StatusCreated)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server, &scopes
+}
+
+func TestTokenAuth_InsufficientScope(t *testing.T) {
+	server, scopes := newScopedRegistry(t, true)
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, Auth: &TokenAuth{}}
+
+	// A pull-only token is issued first
+	req, err := http.NewRequest(http.MethodGet, server.URL+"/v2/app/manifests/v1", nil)
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+	require.NoError(t, err)
+	client.closeBody(resp.Body)
+	require.Equal(t, []string{"repository:app:pull"}, *scopes)
+
+	req, err = http.NewRequest(http.MethodPut, server.URL+"/v2/app/manifests/v1", bytes.NewReader([]byte("manifest")))
+	require.NoError(t, err)
+	resp, err = client.Do(req)
+	require.NoError(t, err)
+	defer client.closeBody(resp.Body)
+
+	assert.Equal(t, http.StatusCreated, resp.StatusCode)
+	assert.Equal(t, []string{"repository:app:pull", "repository:app:pull,push"}, *scopes)
+}
+
+func TestTokenAuth_InsufficientScopeNotGranted(t *testing.T) {
+	server, scopes := newScopedRegistry(t, false)
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL, Auth: &TokenAuth{}}
+	client.Auth.(*TokenAuth).token = "repository:app:pull"
+
+	req, err := http.NewRequest(http.MethodPut, server.URL+"/v2/app/manifests/v1", bytes.NewReader([]byte("manifest")))
+	require.NoError(t, err)
+	resp, err := client.Do(req)
+
+	require.ErrorIs(t, err, ErrInsufficientScope)
+	assert.Nil(t, resp)
+	assert.Contains(t, err.Error(), "repository:app:pull,push")
+	assert.Len(t, *scopes, 1, "token must be refreshed only once per request")
+}
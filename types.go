@@ -12,13 +12,16 @@ type Manifest struct {
 
 // ImageConfig represents the configuration reference in a manifest
 type ImageConfig struct {
-	Digest string `json:"digest"`
+	MediaType string `json:"mediaType,omitempty"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size,omitempty"`
 }
 
 // Layer represents a single layer in an image manifest
 type Layer struct {
-	Digest string `json:"digest"`
-	Size   int64  `json:"size"`
+	MediaType string `json:"mediaType,omitempty"`
+	Digest    string `json:"digest"`
+	Size      int64  `json:"size"`
 }
 
 // ImageManifest represents an OCI/Docker image manifest
@@ -29,8 +32,11 @@ type ImageManifest struct {
 
 // Platform represents the platform information for a manifest
 type Platform struct {
-	Architecture string `json:"architecture"`
-	OS           string `json:"os"`
+	Architecture string   `json:"architecture"`
+	OS           string   `json:"os"`
+	Variant      string   `json:"variant,omitempty"`
+	OSFeatures   []string `json:"os.features,omitempty"`
+	Features     []string `json:"features,omitempty"`
 }
 
 // ManifestReference represents a reference to a platform-specific manifest
@@ -38,6 +44,21 @@ type ManifestReference struct {
 	MediaType string   `json:"mediaType"`
 	Digest    string   `json:"digest"`
 	Platform  Platform `json:"platform"`
+
+	// Set on referrers (see GetReferrers)
+	Size         int64             `json:"size,omitempty"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
+}
+
+// Descriptor describes content addressed by digest, such as the config, layers and
+// subject of a manifest
+type Descriptor struct {
+	MediaType    string            `json:"mediaType"`
+	Digest       string            `json:"digest"`
+	Size         int64             `json:"size"`
+	ArtifactType string            `json:"artifactType,omitempty"`
+	Annotations  map[string]string `json:"annotations,omitempty"`
 }
 
 // ManifestList represents an OCI image index or Docker manifest list
@@ -76,6 +97,7 @@ type RootFS struct {
 type ConfigBlob struct {
 	Architecture string          `json:"architecture"`
 	OS           string          `json:"os"`
+	Variant      string          `json:"variant,omitempty"`
 	Config       ContainerConfig `json:"config"`
 	Created      string          `json:"created"`
 	History      []HistoryEntry  `json:"history"`
@@ -0,0 +1,301 @@
+package registryclient
+
+import (
+	"bytes"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// OpenBlobUpload starts a blob upload session in repository.
+func (c *BaseClient) OpenBlobUpload(ctx context.Context, repository string) (*UploadSession, error) {
+	if err := c.checkRepositoryName(repository); err != nil {
+		return nil, err
+	}
+
+	uploadURL := fmt.Sprintf("%s/v2/%s/blobs/uploads/", c.registryURL(), repository)
+
+	c.logDebug("Registry request",
+		"operation", "OpenBlobUpload",
+		"method", http.MethodPost,
+		"repository", repository,
+		"url", uploadURL,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, uploadURL, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer c.closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(resp.Body)
+		return nil, fmt.Errorf("open blob upload failed: %s - %s", resp.Status, string(body))
+	}
+
+	session := &UploadSession{Repository: repository}
+	if err := c.updateUploadSession(session, resp); err != nil {
+		return nil, err
+	}
+
+	c.logDebug("Registry response",
+		"operation", "OpenBlobUpload",
+		"repository", repository,
+		"upload_uuid", session.UUID,
+	)
+
+	return session, nil
+}
+
+// UploadChunk appends chunk to the upload at the session's current offset.
+// On success the session's Location and Offset are advanced.
+// When ctx is cancelled the session is cancelled on the registry; after other errors it
+// is kept so the upload can be resumed.
+func (c *BaseClient) UploadChunk(ctx context.Context, session *UploadSession, chunk []byte) error {
+	c.logDebug("Registry request",
+		"operation", "UploadChunk",
+		"method", http.MethodPatch,
+		"repository", session.Repository,
+		"upload_uuid", session.UUID,
+		"offset", session.Offset,
+		"size_bytes", len(chunk),
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPatch, session.Location, bytes.NewReader(chunk))
+	if err != nil {
+		return err
+	}
+	req.Header.Set("Content-Type", "application/octet-stream")
+	req.Header.Set("Content-Range", fmt.Sprintf("%d-%d", session.Offset, session.Offset+int64(len(chunk))-1))
+
+	resp, err := c.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			c.abortUpload(ctx, session)
+		}
+		return err
+	}
+	defer c.closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusAccepted {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("upload chunk failed: %s - %s", resp.Status, string(body))
+	}
+
+	offset := session.Offset + int64(len(chunk))
+	if err := c.updateUploadSession(session, resp); err != nil {
+		return err
+	}
+	session.Offset = offset
+	return nil
+}
+
+// CommitUpload completes the upload, asserting the uploaded content has digest.
+// Returns the digest reported by the registry. When the commit fails or ctx is
+// cancelled, the session is cancelled on the registry.
+func (c *BaseClient) CommitUpload(ctx context.Context, session *UploadSession, digest string) (string, error) {
+	commitURL, err := url.Parse(session.Location)
+	if err != nil {
+		return "", err
+	}
+	q := commitURL.Query()
+	q.Set("digest", digest)
+	commitURL.RawQuery = q.Encode()
+
+	c.logDebug("Registry request",
+		"operation", "CommitUpload",
+		"method", http.MethodPut,
+		"repository", session.Repository,
+		"upload_uuid", session.UUID,
+		"digest", digest,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPut, commitURL.String(), nil)
+	if err != nil {
+		return "", err
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		if ctx.Err() != nil {
+			c.abortUpload(ctx, session)
+		}
+		return "", err
+	}
+	defer c.closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusCreated {
+		body, _ := io.ReadAll(resp.Body)
+		c.abortUpload(ctx, session)
+		return "", fmt.Errorf("commit upload failed: %s - %s", resp.Status, string(body))
+	}
+
+	committed := resp.Header.Get("Docker-Content-Digest")
+	if committed == "" {
+		committed = digest
+	}
+
+	c.logDebug("Registry response",
+		"operation", "CommitUpload",
+		"repository", session.Repository,
+		"digest", committed,
+	)
+
+	return committed, nil
+}
+
+// PushBlob uploads content to repository and returns its digest.
+// The upload is skipped when the registry already has a blob with that digest.
+func (c *BaseClient) PushBlob(ctx context.Context, repository string, content []byte) (string, error) {
+	digest := canonicalDigest(content)
+
+	exists, err := c.HasBlob(ctx, repository, digest)
+	if err != nil {
+		return "", err
+	}
+	if exists {
+		c.logDebug("Blob already exists, skipping upload",
+			"operation", "PushBlob",
+			"repository", repository,
+			"digest", digest,
+		)
+		return digest, nil
+	}
+
+	return c.uploadBlob(ctx, repository, content, digest)
+}
+
+// uploadBlob uploads content in a single chunk and commits it under digest
+func (c *BaseClient) uploadBlob(ctx context.Context, repository string, content []byte, digest string) (string, error) {
+	session, err := c.OpenBlobUpload(ctx, repository)
+	if err != nil {
+		return "", err
+	}
+	if len(content) > 0 {
+		if err := c.UploadChunk(ctx, session, content); err != nil {
+			// On a cancelled ctx UploadChunk has already cancelled the session
+			if ctx.Err() == nil {
+				c.abortUpload(ctx, session)
+			}
+			return "", err
+		}
+	}
+	return c.CommitUpload(ctx, session, digest)
+}
+
+// GetUploadStatus refreshes the session's Offset from the registry.
+// Use it to resume a persisted session after a restart.
+func (c *BaseClient) GetUploadStatus(ctx context.Context, session *UploadSession) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, session.Location, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer c.closeBody(resp.Body)
+
+	if resp.StatusCode != http.StatusNoContent {
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("get upload status failed: %s - %s", resp.Status, string(body))
+	}
+
+	if err := c.updateUploadSession(session, resp); err != nil {
+		return err
+	}
+	session.Offset = parseUploadRange(resp.Header.Get("Range"))
+	return nil
+}
+
+// CancelUpload deletes an upload session so the registry can release its storage.
+// A session the registry no longer knows is not an error.
+func (c *BaseClient) CancelUpload(ctx context.Context, session *UploadSession) error {
+	c.logDebug("Registry request",
+		"operation", "CancelUpload",
+		"method", http.MethodDelete,
+		"repository", session.Repository,
+		"upload_uuid", session.UUID,
+	)
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodDelete, session.Location, nil)
+	if err != nil {
+		return err
+	}
+
+	resp, err := c.Do(req)
+	if err != nil {
+		return err
+	}
+	defer c.closeBody(resp.Body)
+
+	switch resp.StatusCode {
+	case http.StatusNoContent, http.StatusAccepted, http.StatusOK, http.StatusNotFound:
+		return nil
+	default:
+		body, _ := io.ReadAll(resp.Body)
+		return fmt.Errorf("cancel upload failed: %s - %s", resp.Status, string(body))
+	}
+}
+
+// uploadCancelTimeout bounds the DELETE sent to clean up an aborted upload
+const uploadCancelTimeout = 30 * time.Second
+
+// abortUpload cancels an upload session after a failure. It runs even when ctx is
+// already cancelled, and only logs a failure since the original error matters more.
+func (c *BaseClient) abortUpload(ctx context.Context, session *UploadSession) {
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), uploadCancelTimeout)
+	defer cancel()
+
+	if err := c.CancelUpload(ctx, session); err != nil {
+		c.logWarn("Failed to cancel upload session",
+			"repository", session.Repository,
+			"upload_uuid", session.UUID,
+			"error", err.Error(),
+		)
+	}
+}
+
+// updateUploadSession records the Location and Docker-Upload-UUID of an upload response
+func (c *BaseClient) updateUploadSession(session *UploadSession, resp *http.Response) error {
+	location := resp.Header.Get("Location")
+	if location == "" {
+		return fmt.Errorf("upload response missing Location header")
+	}
+
+	resolved, err := resp.Request.URL.Parse(location)
+	if err != nil {
+		return fmt.Errorf("invalid upload location %q: %w", location, err)
+	}
+
+	session.Location = resolved.String()
+	if uuid := resp.Header.Get("Docker-Upload-UUID"); uuid != "" {
+		session.UUID = uuid
+	}
+	return nil
+}
+
+// parseUploadRange converts an upload Range header ("0-<last byte>") into a byte offset.
+// A missing or unparsable header means nothing was uploaded yet.
+func parseUploadRange(rangeHeader string) int64 {
+	_, end, found := strings.Cut(rangeHeader, "-")
+	if !found {
+		return 0
+	}
+	last, err := strconv.ParseInt(end, 10, 64)
+	if err != nil || last < 0 {
+		return 0
+	}
+	return last + 1
+}
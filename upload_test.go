@@ -0,0 +1,275 @@
+package registryclient
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+
+	json "github.com/eznix86/registry-client/jsoncompat"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBlobUpload(t *testing.T) {
+	registry := newFakeRegistry(t)
+	client := registry.client()
+	content := []byte("first chunk|second chunk")
+	digest := sha256Digest(content)
+
+	session, err := client.OpenBlobUpload(context.Background(), "app")
+	require.NoError(t, err)
+	assert.Equal(t, "app", session.Repository)
+	assert.Equal(t, "session-1", session.UUID)
+	assert.Equal(t, registry.server.URL+"/v2/app/blobs/uploads/session-1?_state=opaque", session.Location)
+	assert.Zero(t, session.Offset)
+
+	require.NoError(t, client.UploadChunk(context.Background(), session, content[:12]))
+	assert.Equal(t, int64(12), session.Offset)
+	require.NoError(t, client.UploadChunk(context.Background(), session, content[12:]))
+	assert.Equal(t, int64(len(content)), session.Offset)
+
+	committed, err := client.CommitUpload(context.Background(), session, digest)
+	require.NoError(t, err)
+	assert.Equal(t, digest, committed)
+	assert.Equal(t, content, registry.blobs[digest])
+}
+
+func TestBlobUpload_ResumeFromPersistedSession(t *testing.T) {
+	registry := newFakeRegistry(t)
+	content := []byte("resumable upload content")
+	digest := sha256Digest(content)
+
+	session, err := registry.client().OpenBlobUpload(context.Background(), "app")
+	require.NoError(t, err)
+	require.NoError(t, registry.client().UploadChunk(context.Background(), session, content[:10]))
+
+	// Simulate a restart: persist the session and lose the in-memory offset
+	persisted, err := json.Marshal(session)
+	require.NoError(t, err)
+	var resumed UploadSession
+	require.NoError(t, json.Unmarshal(persisted, &resumed))
+	resumed.Offset = 0
+
+	client := registry.client()
+	require.NoError(t, client.GetUploadStatus(context.Background(), &resumed))
+	assert.Equal(t, int64(10), resumed.Offset)
+
+	require.NoError(t, client.UploadChunk(context.Background(), &resumed, content[resumed.Offset:]))
+	committed, err := client.CommitUpload(context.Background(), &resumed, digest)
+	require.NoError(t, err)
+	assert.Equal(t, digest, committed)
+}
+
+func TestPushBlob(t *testing.T) {
+	registry := newFakeRegistry(t)
+	client := registry.client()
+
+	for _, content := range [][]byte{[]byte("blob content"), {}} {
+		digest, err := client.PushBlob(context.Background(), "app", content)
+		require.NoError(t, err)
+		assert.Equal(t, sha256Digest(content), digest)
+		require.Contains(t, registry.blobs, digest)
+		assert.Equal(t, string(content), string(registry.blobs[digest]))
+	}
+	assert.Equal(t, 2, registry.requestCount(http.MethodPost, "/blobs/uploads/"))
+	assert.Equal(t, 1, registry.requestCount(http.MethodPatch, "/session-1"), "empty blobs are committed without a chunk")
+
+	// A blob the registry already has is not uploaded again
+	_, err := client.PushBlob(context.Background(), "app", []byte("blob content"))
+	require.NoError(t, err)
+	assert.Equal(t, 2, registry.requestCount(http.MethodPost, "/blobs/uploads/"))
+}
+
+func TestBlobUpload_Errors(t *testing.T) {
+	registry := newFakeRegistry(t)
+	client := registry.client()
+	ctx := context.Background()
+
+	t.Run("chunk at wrong offset", func(t *testing.T) {
+		session, err := client.OpenBlobUpload(ctx, "app")
+		require.NoError(t, err)
+		session.Offset = 5
+
+		err = client.UploadChunk(ctx, session, []byte("data"))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "upload chunk failed")
+	})
+
+	t.Run("commit with wrong digest", func(t *testing.T) {
+		session, err := client.OpenBlobUpload(ctx, "app")
+		require.NoError(t, err)
+		require.NoError(t, client.UploadChunk(ctx, session, []byte("data")))
+
+		_, err = client.CommitUpload(ctx, session, sha256Digest([]byte("other")))
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "commit upload failed")
+	})
+
+	t.Run("status of unknown session", func(t *testing.T) {
+		err := client.GetUploadStatus(ctx, &UploadSession{Location: registry.server.URL + "/v2/app/blobs/uploads/unknown"})
+		require.Error(t, err)
+		assert.Contains(t, err.Error(), "get upload status failed")
+	})
+
+	t.Run("invalid location", func(t *testing.T) {
+		_, err := client.CommitUpload(ctx, &UploadSession{Location: "://invalid"}, "sha256:abc")
+		require.Error(t, err)
+		require.Error(t, client.UploadChunk(ctx, &UploadSession{Location: "://invalid"}, []byte("x")))
+		require.Error(t, client.GetUploadStatus(ctx, &UploadSession{Location: "://invalid"}))
+	})
+}
+
+func TestOpenBlobUpload_Errors(t *testing.T) {
+	tests := []struct {
+		name     string
+		status   int
+		location string
+		wantErr  string
+	}{
+		{name: "denied", status: http.StatusUnauthorized, wantErr: "open blob upload failed"},
+		{name: "missing location", status: http.StatusAccepted, wantErr: "missing Location"},
+		{name: "invalid location", status: http.StatusAccepted, location: "http://[::1", wantErr: "invalid upload location"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+				if tt.location != "" {
+					w.Header().Set("Location", tt.location)
+				}
+				w.WriteHeader(tt.status)
+			}))
+			defer server.Close()
+
+			client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+			session, err := client.OpenBlobUpload(context.Background(), "app")
+
+			require.Error(t, err)
+			assert.Nil(t, session)
+			assert.True(t, strings.Contains(err.Error(), tt.wantErr), err.Error())
+		})
+	}
+}
+
+func TestParseUploadRange(t *testing.T) {
+	assert.Equal(t, int64(0), parseUploadRange(""))
+	assert.Equal(t, int64(1), parseUploadRange("0-0"))
+	assert.Equal(t, int64(1024), parseUploadRange("0-1023"))
+	assert.Equal(t, int64(0), parseUploadRange("0-abc"))
+	assert.Equal(t, int64(0), parseUploadRange("bytes"))
+}
+
+func TestBlobUpload_CancelOnContextCancel(t *testing.T) {
+	var mu sync.Mutex
+	var deletes []string
+	patchStarted, release := make(chan struct{}), make(chan struct{})
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodPost:
+			w.Header().Set("Location", "/v2/app/blobs/uploads/session-1")
+			w.WriteHeader(http.StatusAccepted)
+		case http.MethodPatch:
+			close(patchStarted)
+			<-release // hang until the client has given up
+		case http.MethodDelete:
+			mu.Lock()
+			deletes = append(deletes, r.URL.Path)
+			mu.Unlock()
+			w.WriteHeader(http.StatusNoContent)
+		}
+	}))
+	defer server.Close()
+	defer close(release)
+
+	client := &BaseClient{HTTPClient: &http.Client{}, BaseURL: server.URL}
+	session, err := client.OpenBlobUpload(context.Background(), "app")
+	require.NoError(t, err)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-patchStarted
+		cancel()
+	}()
+
+	err = client.UploadChunk(ctx, session, []byte("data"))
+	require.ErrorIs(t, err, context.Canceled)
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"/v2/app/blobs/uploads/session-1"}, deletes)
+}
+
+func TestPushBlob_CancelOnContextCancel(t *testing.T) {
+	registry := newFakeRegistry(t)
+	patchStarted, release := make(chan struct{}), make(chan struct{})
+	defer close(release)
+	handler := registry.server.Config.Handler
+	registry.server.Config.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodPatch {
+			close(patchStarted)
+			<-release // hang until the client has given up
+			return
+		}
+		handler.ServeHTTP(w, r)
+	})
+
+	ctx, cancel := context.WithCancel(context.Background())
+	go func() {
+		<-patchStarted
+		cancel()
+	}()
+
+	_, err := registry.client().PushBlob(ctx, "app", []byte("data"))
+	require.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 1, registry.requestCount(http.MethodDelete, "/blobs/uploads/session-1"), "the session is cancelled once")
+}
+
+func TestBlobUpload_CancelOnFailedCommit(t *testing.T) {
+	registry := newFakeRegistry(t)
+	client := registry.client()
+
+	session, err := client.OpenBlobUpload(context.Background(), "app")
+	require.NoError(t, err)
+	require.NoError(t, client.UploadChunk(context.Background(), session, []byte("data")))
+
+	_, err = client.CommitUpload(context.Background(), session, sha256Digest([]byte("other")))
+	require.Error(t, err)
+	assert.Equal(t, 1, registry.requestCount(http.MethodDelete, "/blobs/uploads/session-1"))
+	assert.Empty(t, registry.uploads)
+}
+
+func TestBlobUpload_KeepSessionOnChunkError(t *testing.T) {
+	registry := newFakeRegistry(t)
+	client := registry.client()
+
+	session, err := client.OpenBlobUpload(context.Background(), "app")
+	require.NoError(t, err)
+	session.Offset = 5
+
+	require.Error(t, client.UploadChunk(context.Background(), session, []byte("data")))
+	assert.Zero(t, registry.requestCount(http.MethodDelete, "/blobs/uploads/session-1"), "a failed chunk can be resumed")
+}
+
+func TestCancelUpload(t *testing.T) {
+	registry := newFakeRegistry(t)
+	client := registry.client()
+
+	session, err := client.OpenBlobUpload(context.Background(), "app")
+	require.NoError(t, err)
+
+	require.NoError(t, client.CancelUpload(context.Background(), session))
+	assert.Empty(t, registry.uploads)
+	require.NoError(t, client.CancelUpload(context.Background(), session), "unknown sessions are already cancelled")
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	err = client.CancelUpload(context.Background(), &UploadSession{Location: server.URL + "/v2/app/blobs/uploads/x"})
+	require.Error(t, err)
+	assert.Contains(t, err.Error(), "cancel upload failed: 403")
+}